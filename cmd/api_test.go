@@ -0,0 +1,360 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+	authMiddleware "studentgit.kata.academy/Zhodaran/go-kata/internal/api/middleware"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/config"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/controllers"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/entities"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/facades"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/infrastructure/limiter"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/infrastructure/mailer"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/infrastructure/memory"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/infrastructure/password"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/infrastructure/tokens"
+)
+
+// testAPI маршруты поверх хранилищ в памяти
+type testAPI struct {
+	t      *testing.T
+	router http.Handler
+	keys   *tokens.KeySet
+}
+
+func newTestAPI(t *testing.T, loanCfg config.LoanConfig) *testAPI {
+	store := memory.NewStore()
+	authRepo := memory.NewAuthRepository(store)
+	apiKeyRepo := memory.NewAPIKeyRepository(store)
+	library := facades.NewLibraryFacade(authRepo, apiKeyRepo, memory.NewBookRepository(store), memory.NewAuthorRepository(store),
+		memory.NewUserRepository(store), memory.NewFineRepository(store), password.NewHasher(bcrypt.MinCost), loanCfg)
+
+	resp := controllers.NewResponder(zap.NewNop())
+	keys, err := tokens.NewKeySet(config.JWTKey{ID: "test", Algorithm: "HS256", Secret: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy := limiter.Policy{MaxFailures: 5, Lockout: time.Minute}
+	guard := limiter.NewGuard(limiter.NewMemoryLimiter(policy), limiter.NewMemoryLimiter(policy), zap.NewNop())
+	authCfg := config.AuthConfig{AccessTTL: time.Hour, RefreshTTL: 24 * time.Hour}
+	h := &handlers{
+		resp:   resp,
+		auth:   controllers.NewAuthController(library, keys, guard, mailer.NewLogMailer(zap.NewNop()), authCfg, config.MailConfig{}),
+		user:   controllers.NewUserController(library),
+		book:   controllers.NewBookController(library),
+		author: controllers.NewAuthorController(library),
+		fine:   controllers.NewFineController(library),
+		role:   controllers.NewRoleController(library),
+		apiKey: controllers.NewAPIKeyController(library),
+	}
+
+	r := h.router(authMiddleware.TokenAuthMiddleware(resp, keys, authRepo, apiKeyRepo))
+	return &testAPI{t: t, router: r, keys: keys}
+}
+
+// do выполняет запрос от имени пользователя с ролью и разбирает ответ в out, если он задан
+func (a *testAPI) do(method, path string, userID int, role string, body interface{}, out interface{}) *httptest.ResponseRecorder {
+	a.t.Helper()
+	_, token, err := a.keys.Encode(map[string]interface{}{
+		"user_id": userID,
+		"roles":   []string{role},
+		"jti":     fmt.Sprintf("%s-%d", role, userID),
+		"exp":     time.Now().Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		a.t.Fatal(err)
+	}
+	return a.send(method, path, map[string]string{"Authorization": "Bearer " + token}, body, out)
+}
+
+// send выполняет запрос с заголовками и разбирает ответ в out, если он задан
+func (a *testAPI) send(method, path string, headers map[string]string, body interface{}, out interface{}) *httptest.ResponseRecorder {
+	a.t.Helper()
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			a.t.Fatal(err)
+		}
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	rr := httptest.NewRecorder()
+	a.router.ServeHTTP(rr, req)
+
+	if out != nil && rr.Code < 300 {
+		if err := json.Unmarshal(rr.Body.Bytes(), out); err != nil {
+			a.t.Fatalf("%s %s: %v in %s", method, path, err, rr.Body)
+		}
+	}
+	return rr
+}
+
+// bearer заголовок авторизации с access-токеном
+func bearer(token string) map[string]string {
+	return map[string]string{"Authorization": "Bearer " + token}
+}
+
+func (a *testAPI) expect(rr *httptest.ResponseRecorder, want int, request string) {
+	a.t.Helper()
+	if rr.Code != want {
+		a.t.Fatalf("%s: status %d, want %d: %s", request, rr.Code, want, rr.Body)
+	}
+}
+
+func TestLibraryInMemory(t *testing.T) {
+	api := newTestAPI(t, config.LoanConfig{Period: 14 * 24 * time.Hour, MaxRenewals: 2, PickupWindow: time.Hour, FineDailyRate: 100, FineLimit: 1000})
+	const librarian, reader, other = 1, 2, 3
+
+	var book entities.Book
+	rr := api.do(http.MethodPost, "/api/book", librarian, entities.RoleLibrarian, controllers.AddaderBook{Book: "Dune", Author: "Frank Herbert"}, &book)
+	api.expect(rr, http.StatusCreated, "add book")
+	if loc := rr.Header().Get("Location"); loc != fmt.Sprintf("/api/books/%d", book.Index) {
+		t.Fatalf("Location = %q", loc)
+	}
+
+	var page controllers.BooksPage
+	api.expect(api.do(http.MethodGet, "/api/books?author=frank%20herbert", reader, entities.RolePatron, nil, &page), http.StatusOK, "list books")
+	if page.Total != 1 || page.Items[0].TotalCopies != 1 {
+		t.Fatalf("unexpected catalogue %+v", page)
+	}
+
+	take := fmt.Sprintf("/api/book/take/%d", book.Index)
+	api.expect(api.do(http.MethodPost, take, reader, entities.RolePatron, nil, nil), http.StatusOK, "take book")
+	api.expect(api.do(http.MethodPost, take, other, entities.RolePatron, nil, nil), http.StatusBadRequest, "take taken book")
+	api.expect(api.do(http.MethodPost, fmt.Sprintf("/api/book/hold/%d", book.Index), other, entities.RolePatron, nil, nil), http.StatusOK, "place hold")
+	api.expect(api.do(http.MethodDelete, fmt.Sprintf("/api/book/return/%d", book.Index), reader, entities.RolePatron, nil, nil), http.StatusOK, "return book")
+
+	var holds []entities.Hold
+	api.expect(api.do(http.MethodGet, fmt.Sprintf("/api/holds/%d", other), other, entities.RolePatron, nil, &holds), http.StatusOK, "list holds")
+	if len(holds) != 1 || holds[0].Status != entities.HoldReady {
+		t.Fatalf("returned book must be held for the next reader, got %+v", holds)
+	}
+	api.expect(api.do(http.MethodPost, take, other, entities.RolePatron, nil, nil), http.StatusOK, "take held book")
+
+	for _, records := range []string{"loans", "holds", "fines"} {
+		path := fmt.Sprintf("/api/%s/%d", records, other)
+		api.expect(api.do(http.MethodGet, path, reader, entities.RolePatron, nil, nil), http.StatusForbidden, "list "+records+" of another user")
+		api.expect(api.do(http.MethodGet, path, librarian, entities.RoleLibrarian, nil, nil), http.StatusOK, "list "+records+" as staff")
+	}
+
+	api.expect(api.do(http.MethodPost, "/api/authors", librarian, entities.RoleLibrarian, controllers.AuthorRequest{Name: "Ursula K. Le Guin"}, nil), http.StatusCreated, "add author")
+	api.expect(api.do(http.MethodPost, "/api/authors", librarian, entities.RoleLibrarian, controllers.AuthorRequest{Name: "Frank Herbert"}, nil), http.StatusConflict, "add existing author")
+	var authors []entities.Author
+	api.expect(api.do(http.MethodGet, "/api/authors", reader, entities.RolePatron, nil, &authors), http.StatusOK, "list authors")
+	if len(authors) != 2 || authors[0].Name != "Frank Herbert" {
+		t.Fatalf("unexpected authors %v", authors)
+	}
+}
+
+func TestAuthorsInMemory(t *testing.T) {
+	api := newTestAPI(t, config.LoanConfig{Period: 14 * 24 * time.Hour, FineLimit: 1000})
+	const librarian, reader = 1, 2
+
+	born, died := 1920, 1986
+	var author entities.Author
+	rr := api.do(http.MethodPost, "/api/authors", librarian, entities.RoleLibrarian, controllers.AuthorRequest{Name: "Frank Herbert", BirthYear: &born}, &author)
+	api.expect(rr, http.StatusCreated, "add author")
+	path := fmt.Sprintf("/api/authors/%d", author.ID)
+	if loc := rr.Header().Get("Location"); loc != path {
+		t.Fatalf("Location = %q", loc)
+	}
+	api.expect(api.do(http.MethodPut, path, librarian, entities.RoleLibrarian, controllers.AuthorRequest{Name: "Frank Herbert", BirthYear: &died, DeathYear: &born}, nil), http.StatusBadRequest, "death before birth")
+
+	var book entities.Book
+	api.expect(api.do(http.MethodPost, "/api/book", librarian, entities.RoleLibrarian, controllers.AddaderBook{Book: "Dune", Author: "Frank Herbert"}, &book), http.StatusCreated, "add book")
+
+	// Новое имя автора видно в его книгах
+	api.expect(api.do(http.MethodPut, path, librarian, entities.RoleLibrarian, controllers.AuthorRequest{Name: "Frank P. Herbert", BirthYear: &born, DeathYear: &died, Bio: "American writer"}, &author), http.StatusOK, "update author")
+	var books []entities.Book
+	api.expect(api.do(http.MethodGet, path+"/books", reader, entities.RolePatron, nil, &books), http.StatusOK, "list author books")
+	if len(books) != 1 || books[0].Index != book.Index || books[0].Author != "Frank P. Herbert" {
+		t.Fatalf("unexpected author books %+v", books)
+	}
+
+	api.expect(api.do(http.MethodDelete, path, librarian, entities.RoleLibrarian, nil, nil), http.StatusConflict, "delete author with books")
+	api.expect(api.do(http.MethodPost, fmt.Sprintf("/api/book/take/%d", book.Index), reader, entities.RolePatron, nil, nil), http.StatusOK, "take book")
+	api.expect(api.do(http.MethodDelete, path+"?cascade=true", librarian, entities.RoleLibrarian, nil, nil), http.StatusConflict, "delete author with borrowed book")
+	api.expect(api.do(http.MethodDelete, fmt.Sprintf("/api/book/return/%d", book.Index), reader, entities.RolePatron, nil, nil), http.StatusOK, "return book")
+	// История выдач не удаляется, поэтому выданную книгу нельзя удалить и вместе с автором
+	api.expect(api.do(http.MethodDelete, path+"?cascade=true", librarian, entities.RoleLibrarian, nil, nil), http.StatusConflict, "delete author with lent book")
+
+	var other entities.Author
+	api.expect(api.do(http.MethodPost, "/api/authors", librarian, entities.RoleLibrarian, controllers.AuthorRequest{Name: "Ursula K. Le Guin"}, &other), http.StatusCreated, "add author")
+	api.expect(api.do(http.MethodPost, "/api/book", librarian, entities.RoleLibrarian, controllers.AddaderBook{Book: "Earthsea", Author: "Ursula K. Le Guin"}, nil), http.StatusCreated, "add book")
+	otherPath := fmt.Sprintf("/api/authors/%d", other.ID)
+	api.expect(api.do(http.MethodDelete, otherPath+"?cascade=true", librarian, entities.RoleLibrarian, nil, nil), http.StatusNoContent, "delete author with books")
+
+	api.expect(api.do(http.MethodGet, otherPath, reader, entities.RolePatron, nil, nil), http.StatusNotFound, "get deleted author")
+	var page controllers.BooksPage
+	api.expect(api.do(http.MethodGet, "/api/books", reader, entities.RolePatron, nil, &page), http.StatusOK, "list books")
+	if page.Total != 1 || page.Items[0].Index != book.Index {
+		t.Fatalf("only the books of the deleted author must be deleted, got %+v", page.Items)
+	}
+}
+
+func TestUsersInMemory(t *testing.T) {
+	api := newTestAPI(t, config.LoanConfig{})
+	const admin = 1
+
+	var user entities.User
+	api.expect(api.do(http.MethodPost, "/api/users", admin, entities.RoleAdmin, controllers.UserRequest{Name: "Alice", Email: "alice@example.com"}, &user), http.StatusCreated, "create user")
+	api.expect(api.do(http.MethodPost, "/api/users", admin, entities.RoleAdmin, controllers.UserRequest{Name: "Other", Email: "ALICE@example.com"}, nil), http.StatusConflict, "duplicate email")
+
+	path := fmt.Sprintf("/api/users/%d", user.ID)
+	api.expect(api.do(http.MethodPut, path, admin, entities.RoleAdmin, controllers.UserRequest{Name: "Alice B.", Email: "alice@example.com"}, &user), http.StatusOK, "update user")
+	if user.Name != "Alice B." {
+		t.Fatalf("name = %q after update", user.Name)
+	}
+
+	var page controllers.UsersPage
+	api.expect(api.do(http.MethodGet, "/api/users?name~=alice", admin, entities.RoleAdmin, nil, &page), http.StatusOK, "list users")
+	if page.Total != 1 {
+		t.Fatalf("unexpected users %+v", page)
+	}
+
+	api.expect(api.do(http.MethodDelete, path, admin, entities.RoleAdmin, nil, nil), http.StatusNoContent, "delete user")
+	api.expect(api.do(http.MethodGet, path, admin, entities.RoleAdmin, nil, nil), http.StatusNotFound, "get deleted user")
+}
+
+func TestFinesInMemory(t *testing.T) {
+	// Отрицательный срок выдачи делает книгу просроченной сразу
+	api := newTestAPI(t, config.LoanConfig{Period: -48 * time.Hour, FineDailyRate: 100, FineLimit: 1000})
+	const librarian, reader = 1, 2
+
+	var book entities.Book
+	api.expect(api.do(http.MethodPost, "/api/book", librarian, entities.RoleLibrarian, controllers.AddaderBook{Book: "Dune", Author: "Frank Herbert"}, &book), http.StatusCreated, "add book")
+	api.expect(api.do(http.MethodPost, fmt.Sprintf("/api/book/take/%d", book.Index), reader, entities.RolePatron, nil, nil), http.StatusOK, "take book")
+	api.expect(api.do(http.MethodDelete, fmt.Sprintf("/api/book/return/%d", book.Index), reader, entities.RolePatron, nil, nil), http.StatusOK, "return book")
+
+	var fines controllers.FinesResponse
+	api.expect(api.do(http.MethodGet, fmt.Sprintf("/api/fines/%d", reader), reader, entities.RolePatron, nil, &fines), http.StatusOK, "list fines")
+	if len(fines.Fines) != 1 || fines.Balance != 300 {
+		t.Fatalf("unexpected fines %+v", fines)
+	}
+
+	pay := fmt.Sprintf("/api/fines/%d/pay", fines.Fines[0].ID)
+	api.expect(api.do(http.MethodPost, pay, librarian, entities.RoleLibrarian, controllers.PayFineRequest{Amount: 301}, nil), http.StatusBadRequest, "overpay fine")
+	var fine entities.Fine
+	api.expect(api.do(http.MethodPost, pay, librarian, entities.RoleLibrarian, controllers.PayFineRequest{Amount: 300, Note: "cash"}, &fine), http.StatusOK, "pay fine")
+	if fine.Status != entities.FinePaid || fine.ClosedAt == nil {
+		t.Fatalf("fine must be closed after full payment, got %+v", fine)
+	}
+}
+
+func TestSessionsInMemory(t *testing.T) {
+	api := newTestAPI(t, config.LoanConfig{})
+	credentials := entities.UserAuth{Username: "alice", Password: "correct horse", Email: "alice@example.com"}
+
+	api.expect(api.send(http.MethodPost, "/api/register", nil, credentials, nil), http.StatusCreated, "register")
+	api.expect(api.send(http.MethodPost, "/api/register", nil, credentials, nil), http.StatusConflict, "register twice")
+	api.expect(api.send(http.MethodPost, "/api/login", nil, entities.UserAuth{Username: "alice", Password: "wrong"}, nil), http.StatusUnauthorized, "login with wrong password")
+
+	var session controllers.TokenResponse
+	api.expect(api.send(http.MethodPost, "/api/login", nil, credentials, &session), http.StatusOK, "login")
+	api.expect(api.send(http.MethodGet, "/api/books", bearer(session.Token), nil, nil), http.StatusOK, "list books with access token")
+
+	var refreshed controllers.TokenResponse
+	api.expect(api.send(http.MethodPost, "/api/token/refresh", nil, controllers.RefreshRequest{RefreshToken: session.RefreshToken}, &refreshed), http.StatusOK, "refresh")
+	if refreshed.RefreshToken == "" || refreshed.RefreshToken == session.RefreshToken {
+		t.Fatalf("refresh must rotate the refresh token, got %+v", refreshed)
+	}
+	// Повторное предъявление погашенного токена закрывает всю сессию
+	api.expect(api.send(http.MethodPost, "/api/token/refresh", nil, controllers.RefreshRequest{RefreshToken: session.RefreshToken}, nil), http.StatusUnauthorized, "reuse refresh token")
+	api.expect(api.send(http.MethodPost, "/api/token/refresh", nil, controllers.RefreshRequest{RefreshToken: refreshed.RefreshToken}, nil), http.StatusUnauthorized, "refresh after reuse")
+
+	api.expect(api.send(http.MethodPost, "/api/login", nil, credentials, &session), http.StatusOK, "login again")
+	api.expect(api.send(http.MethodPost, "/api/logout", bearer(session.Token), nil, nil), http.StatusOK, "logout")
+	api.expect(api.send(http.MethodGet, "/api/books", bearer(session.Token), nil, nil), http.StatusUnauthorized, "use access token after logout")
+	api.expect(api.send(http.MethodPost, "/api/token/refresh", nil, controllers.RefreshRequest{RefreshToken: session.RefreshToken}, nil), http.StatusUnauthorized, "refresh after logout")
+}
+
+func TestRolesInMemory(t *testing.T) {
+	api := newTestAPI(t, config.LoanConfig{})
+	const admin = 1
+	credentials := entities.UserAuth{Username: "bob", Password: "correct horse"}
+
+	var registered struct {
+		Data struct {
+			UserID int `json:"user_id"`
+		} `json:"data"`
+	}
+	api.expect(api.send(http.MethodPost, "/api/register", nil, credentials, &registered), http.StatusCreated, "register")
+
+	var session controllers.TokenResponse
+	api.expect(api.send(http.MethodPost, "/api/login", nil, credentials, &session), http.StatusOK, "login")
+	api.expect(api.send(http.MethodPost, "/api/book", bearer(session.Token), controllers.AddaderBook{Book: "Dune", Author: "Frank Herbert"}, nil), http.StatusForbidden, "add book as patron")
+
+	var roles controllers.RolesResponse
+	api.expect(api.do(http.MethodPost, "/api/admin/users/bob/roles", admin, entities.RoleAdmin, controllers.RoleRequest{Role: entities.RoleLibrarian}, &roles), http.StatusOK, "grant role")
+	if len(roles.Roles) != 2 || roles.Roles[0] != entities.RolePatron || roles.Roles[1] != entities.RoleLibrarian {
+		t.Fatalf("unexpected roles %+v", roles)
+	}
+	api.expect(api.do(http.MethodGet, "/api/admin/users/nobody/roles", admin, entities.RoleAdmin, nil, nil), http.StatusNotFound, "list roles of unknown user")
+	api.expect(api.do(http.MethodPost, "/api/admin/users/nobody/roles", admin, entities.RoleAdmin, controllers.RoleRequest{Role: entities.RoleLibrarian}, nil), http.StatusNotFound, "grant role to unknown user")
+
+	// Новая роль попадает в токен при следующем входе
+	api.expect(api.send(http.MethodPost, "/api/login", nil, credentials, &session), http.StatusOK, "login as librarian")
+	api.expect(api.send(http.MethodPost, "/api/book", bearer(session.Token), controllers.AddaderBook{Book: "Dune", Author: "Frank Herbert"}, nil), http.StatusCreated, "add book as librarian")
+
+	revoke := "/api/admin/users/bob/roles/" + entities.RoleLibrarian
+	api.expect(api.do(http.MethodDelete, revoke, admin, entities.RoleAdmin, nil, &roles), http.StatusOK, "revoke role")
+	api.expect(api.do(http.MethodDelete, revoke, admin, entities.RoleAdmin, nil, nil), http.StatusNotFound, "revoke role twice")
+
+	// Удаленный пользователь не входит, а его сессии не продлеваются
+	api.expect(api.do(http.MethodDelete, fmt.Sprintf("/api/users/%d", registered.Data.UserID), admin, entities.RoleAdmin, nil, nil), http.StatusNoContent, "delete user")
+	api.expect(api.send(http.MethodPost, "/api/login", nil, credentials, nil), http.StatusUnauthorized, "login as deleted user")
+	api.expect(api.send(http.MethodPost, "/api/token/refresh", nil, controllers.RefreshRequest{RefreshToken: session.RefreshToken}, nil), http.StatusUnauthorized, "refresh as deleted user")
+}
+
+func TestAPIKeysInMemory(t *testing.T) {
+	api := newTestAPI(t, config.LoanConfig{})
+	const admin = 1
+
+	api.expect(api.do(http.MethodPost, "/api/admin/api-keys", admin, entities.RoleAdmin, controllers.APIKeyRequest{Name: "sync", Scopes: []string{"books:everything"}}, nil), http.StatusBadRequest, "create key with unknown scope")
+
+	var created controllers.APIKeyResponse
+	api.expect(api.do(http.MethodPost, "/api/admin/api-keys", admin, entities.RoleAdmin, controllers.APIKeyRequest{Name: "sync", Scopes: []string{entities.PermCatalogWrite}}, &created), http.StatusCreated, "create key")
+	if created.Key == "" || created.Prefix == "" {
+		t.Fatalf("unexpected key %+v", created)
+	}
+
+	withKey := map[string]string{"X-API-Key": created.Key}
+	api.expect(api.send(http.MethodPost, "/api/book", withKey, controllers.AddaderBook{Book: "Dune", Author: "Frank Herbert"}, nil), http.StatusCreated, "add book with key")
+	api.expect(api.send(http.MethodPost, "/api/fines/1/waive", withKey, nil, nil), http.StatusForbidden, "waive fine without scope")
+	api.expect(api.send(http.MethodPost, "/api/book", map[string]string{"X-API-Key": created.Key + "x"}, controllers.AddaderBook{Book: "Emma", Author: "Jane Austen"}, nil), http.StatusUnauthorized, "add book with wrong key")
+
+	// У ключа нет своего пользователя, но с областью loans:staff он выдает книги читателям
+	var staffKey controllers.APIKeyResponse
+	api.expect(api.do(http.MethodPost, "/api/admin/api-keys", admin, entities.RoleAdmin, controllers.APIKeyRequest{Name: "desk", Scopes: []string{entities.PermLoansStaff}}, &staffKey), http.StatusCreated, "create staff key")
+	withStaffKey := map[string]string{"X-API-Key": staffKey.Key}
+	var taken struct {
+		Data entities.Loan `json:"data"`
+	}
+	api.expect(api.send(http.MethodPost, "/api/staff/book/take/1", withStaffKey, controllers.TakeBookRequest{UserID: 2}, &taken), http.StatusOK, "take book for reader with key")
+	if taken.Data.UserID != 2 {
+		t.Fatalf("book must be lent to the reader, got %+v", taken.Data)
+	}
+	api.expect(api.send(http.MethodPost, "/api/book/take/1", withStaffKey, nil, nil), http.StatusUnauthorized, "take book for the key itself")
+
+	var keys []entities.APIKey
+	api.expect(api.do(http.MethodGet, "/api/admin/api-keys", admin, entities.RoleAdmin, nil, &keys), http.StatusOK, "list keys")
+	if len(keys) != 2 || keys[0].LastUsedAt == nil {
+		t.Fatalf("key use must be recorded, got %+v", keys)
+	}
+
+	path := fmt.Sprintf("/api/admin/api-keys/%d", created.ID)
+	api.expect(api.do(http.MethodDelete, path, admin, entities.RoleAdmin, nil, nil), http.StatusOK, "revoke key")
+	api.expect(api.do(http.MethodDelete, "/api/admin/api-keys/99", admin, entities.RoleAdmin, nil, nil), http.StatusNotFound, "revoke unknown key")
+	api.expect(api.send(http.MethodPost, "/api/book", withKey, controllers.AddaderBook{Book: "Emma", Author: "Jane Austen"}, nil), http.StatusUnauthorized, "add book with revoked key")
+}
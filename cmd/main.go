@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"database/sql"
 	"log"
 	"net/http"
 	"os"
@@ -12,13 +13,19 @@ import (
 	_ "github.com/lib/pq"
 	_ "studentgit.kata.academy/Zhodaran/go-kata/docs"
 
-	"github.com/go-chi/chi"
-	"github.com/go-chi/chi/middleware"
 	"github.com/joho/godotenv"
 	"go.uber.org/zap"
+	authMiddleware "studentgit.kata.academy/Zhodaran/go-kata/internal/api/middleware"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/config"
 	"studentgit.kata.academy/Zhodaran/go-kata/internal/controllers"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/entities"
 	"studentgit.kata.academy/Zhodaran/go-kata/internal/facades"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/infrastructure/limiter"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/infrastructure/mailer"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/infrastructure/password"
 	postgresRepo "studentgit.kata.academy/Zhodaran/go-kata/internal/infrastructure/postgres"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/infrastructure/tokens"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/workers"
 )
 
 type Server struct {
@@ -35,70 +42,102 @@ func main() {
 		log.Fatal("Error connecting to the database:", err) // Обработка ошибки
 	}
 	defer db.Close()
+
+	// library migrate up|down [n]|status
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(context.Background(), db, os.Args[2:], os.Stdout); err != nil {
+			log.Fatalf("Error running migrations: %v", err)
+		}
+		return
+	}
+	// library seed [-seed n] [-books n] ...
+	if len(os.Args) > 1 && os.Args[1] == "seed" {
+		if err := runSeed(context.Background(), db, os.Args[2:], os.Stdout); err != nil {
+			log.Fatalf("Error seeding database: %v", err)
+		}
+		return
+	}
+
 	postgresRepo.PullSQL()
 	logger, _ := zap.NewProduction()
 	defer logger.Sync()
-	postgresRepo.RunMigrations(db)
-	books := postgresRepo.CreateTableBook(db)
-	librar := controllers.NewLibrary()
-	librar.AddBooks(books)
+	migrator, err := postgresRepo.NewMigrator(db)
+	if err != nil {
+		log.Fatalf("Error loading migrations: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		log.Fatalf("Error running migrations: %v", err)
+	}
 
-	booksController := &controllers.BookController{DB: db}
+	loanCfg := config.LoadLoanConfig()
 
 	resp := controllers.NewResponder(logger)
 
+	// Фоновая пометка просроченных выдач
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	go workers.RunOverdueSweeper(workerCtx, postgresRepo.NewPostgresBookRepository(db), loanCfg.SweepInterval, logger)
+	go workers.RunHoldSweeper(workerCtx, postgresRepo.NewPostgresBookRepository(db), loanCfg.SweepInterval, loanCfg.PickupWindow, logger)
+
 	// Инициализация репозиториев
 	authRepo := postgresRepo.NewPostgresAuthRepository(db)
+	apiKeyRepo := postgresRepo.NewPostgresAPIKeyRepository(db)
 	bookRepo := postgresRepo.NewPostgresBookRepository(db)
 	authorRepo := postgresRepo.NewPostgresAuthorRepository(db)
 	userRepo := postgresRepo.NewPostgresUserRepository(db)
 
+	authCfg, err := config.LoadAuthConfig()
+	if err != nil {
+		log.Fatalf("Error loading auth config: %v", err)
+	}
+	mailCfg := config.LoadMailConfig()
+	hasher := password.NewHasher(authCfg.PasswordCost)
+
 	// Фасад
 	library := facades.NewLibraryFacade(
 		authRepo,
+		apiKeyRepo,
 		bookRepo,
 		authorRepo,
 		userRepo,
+		postgresRepo.NewPostgresFineRepository(db),
+		hasher,
+		loanCfg,
 	)
 
 	// Контроллеры
-	authController := controllers.NewAuthController(library)
+	keys, err := tokens.NewKeySet(authCfg.SigningKey, authCfg.VerifyKeys...)
+	if err != nil {
+		log.Fatalf("Error loading JWT keys: %v", err)
+	}
+	authController := controllers.NewAuthController(library, keys, newLoginGuard(workerCtx, db, authCfg.Login, logger), newMailer(mailCfg, logger), authCfg, mailCfg)
 	userController := controllers.NewUserController(library)
 	bookController := controllers.NewBookController(library)
 	authorController := controllers.NewAuthorController(library)
+	fineController := controllers.NewFineController(library)
+	roleController := controllers.NewRoleController(library)
+	apiKeyController := controllers.NewAPIKeyController(library)
+
+	go workers.RunTokenPurger(workerCtx, authRepo, loanCfg.SweepInterval, logger)
+	if admin := authCfg.BootstrapAdmin; admin != "" {
+		if err := authRepo.GrantRole(context.Background(), admin, entities.RoleAdmin); err != nil {
+			logger.Warn("could not grant admin role", zap.String("username", admin), zap.Error(err))
+		}
+	}
+
+	h := &handlers{
+		resp:   resp,
+		auth:   authController,
+		user:   userController,
+		book:   bookController,
+		author: authorController,
+		fine:   fineController,
+		role:   roleController,
+		apiKey: apiKeyController,
+	}
 
 	// Роутер
-	r := chi.NewRouter()
-	controllers.GenerateUsers(50)
-
-	// Middleware
-
-	// Публичные маршруты
-	r.Post("/api/register", authController.Register)
-	r.Post("/api/login", authController.Login)
-
-	// Приватные маршруты
-	r.Group(func(r chi.Router) {
-		r.Use(middleware.Logger)
-
-		// Пользователи
-		r.Post("/api/users", userController.CreateUser)
-		r.Get("/api/users/{id}", userController.GetUser)
-		r.Put("/api/users/{id}", userController.UpdateUser)
-		r.Delete("/api/users/{id}", userController.DeleteUser)
-		r.Get("/api/users", userController.ListUsers)
-
-		// Книги
-		r.Post("/api/book/take/{index}", bookController.TakeBookHandler(resp, db, &books, librar))
-		r.Delete("/api/book/return/{index}", bookController.ReturnBook(resp, db, &books, librar))
-		r.Post("/api/book", bookController.AddBookHandler(resp, db, librar, &books))
-		r.Get("/api/books", booksController.ListBooks)
-		r.Put("/api/books/{index}", bookController.UpdateBook(resp, db))
-
-		// Авторы
-		r.Post("/api/authors", authorController.AddAuthorHandler(resp, librar))
-		r.Get("/api/authors", authorController.ListAuthorsHandler(resp, librar))
-	})
+	r := h.router(authMiddleware.TokenAuthMiddleware(resp, keys, authRepo, apiKeyRepo))
 
 	// Запуск сервера
 	srv := &Server{
@@ -133,6 +172,43 @@ func WaitForShutdown(srv *Server) {
 func (s *Server) Serve() {
 	log.Println("Starting server...")
 	if err := s.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		log.Fatalf("Server error: &v", err)
+		log.Fatalf("Server error: %v", err)
+	}
+}
+
+// newLoginGuard собирает защиту входа с хранилищем счетчиков из настроек
+func newLoginGuard(ctx context.Context, db *sql.DB, cfg config.LoginLimitConfig, logger *zap.Logger) *limiter.Guard {
+	userPolicy := limiter.Policy{MaxFailures: cfg.MaxFailures, BaseDelay: cfg.BackoffBase, MaxDelay: cfg.BackoffMax, Lockout: cfg.Lockout}
+	ipPolicy := userPolicy
+	ipPolicy.MaxFailures = cfg.IPMaxFailures
+	audit := logger.Named("audit")
+
+	if cfg.Store == "postgres" {
+		users := postgresRepo.NewPostgresLoginLimiter(db, userPolicy, "user")
+		ips := postgresRepo.NewPostgresLoginLimiter(db, ipPolicy, "ip")
+		go workers.RunPurger(ctx, users, cfg.Lockout, logger, "stale login failures purged")
+		go workers.RunPurger(ctx, ips, cfg.Lockout, logger, "stale login failures purged")
+		return limiter.NewGuard(users, ips, audit)
+	}
+	users := limiter.NewMemoryLimiter(userPolicy)
+	ips := limiter.NewMemoryLimiter(ipPolicy)
+	go workers.RunPurger(ctx, users, cfg.Lockout, logger, "stale login failures purged")
+	go workers.RunPurger(ctx, ips, cfg.Lockout, logger, "stale login failures purged")
+	return limiter.NewGuard(users, ips, audit)
+}
+
+// newMailer выбирает способ отправки писем из настроек
+func newMailer(cfg config.MailConfig, logger *zap.Logger) mailer.Mailer {
+	switch cfg.Driver {
+	case "smtp":
+		return mailer.NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUser, cfg.SMTPPassword, cfg.From)
+	case "file":
+		m, err := mailer.NewFileMailer(cfg.Dir, cfg.From)
+		if err != nil {
+			log.Fatalf("Error creating mail directory: %v", err)
+		}
+		return m
+	default:
+		return mailer.NewLogMailer(logger.Named("mail"))
 	}
 }
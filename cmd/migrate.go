@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+
+	postgresRepo "studentgit.kata.academy/Zhodaran/go-kata/internal/infrastructure/postgres"
+)
+
+const migrateUsage = "usage: migrate up | down [steps] | status"
+
+// runMigrate выполняет подкоманду migrate: up применяет все миграции, down откатывает
+// последние steps (по умолчанию одну), status печатает состояние каждой версии
+func runMigrate(ctx context.Context, db *sql.DB, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+	migrator, err := postgresRepo.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			fmt.Fprintf(out, "applied %04d_%s\n", m.Version, m.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Fprintln(out, "no pending migrations")
+		}
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("steps must be a positive integer, got %q", args[1])
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		for _, m := range reverted {
+			fmt.Fprintf(out, "reverted %04d_%s\n", m.Version, m.Name)
+		}
+		return err
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATUS")
+		for _, s := range statuses {
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, migrationState(s))
+		}
+		return w.Flush()
+	}
+	return errors.New(migrateUsage)
+}
+
+func migrationState(s postgresRepo.MigrationStatus) string {
+	switch {
+	case s.Missing:
+		return "applied, file missing"
+	case s.Changed:
+		return "applied, checksum mismatch"
+	case s.AppliedAt != nil:
+		return "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+	}
+	return "pending"
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	authMiddleware "studentgit.kata.academy/Zhodaran/go-kata/internal/api/middleware"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/controllers"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/entities"
+)
+
+// route приватный маршрут и право, которое нужно для доступа к нему
+type route struct {
+	method     string
+	pattern    string
+	permission string // Пусто, если достаточно авторизации
+	handler    http.HandlerFunc
+}
+
+// handlers зависимости, из которых собираются приватные маршруты
+type handlers struct {
+	resp controllers.Responder
+
+	auth   *controllers.AuthController
+	user   *controllers.UserController
+	book   *controllers.BookController
+	author *controllers.AuthorController
+	fine   *controllers.FineController
+	role   *controllers.RoleController
+	apiKey *controllers.APIKeyController
+}
+
+// router собирает все маршруты API. authenticate проверяет токен или API-ключ на приватных маршрутах
+func (h *handlers) router(authenticate func(http.Handler) http.Handler) chi.Router {
+	r := chi.NewRouter()
+
+	// Публичные маршруты
+	r.Post("/api/register", h.auth.Register)
+	r.Post("/api/login", h.auth.Login)
+	r.Post("/api/token/refresh", h.auth.RefreshHandler(h.resp))
+	r.Get("/.well-known/jwks.json", h.auth.JWKSHandler(h.resp))
+	r.Post("/api/password/forgot", h.auth.ForgotPasswordHandler(h.resp))
+	r.Post("/api/password/reset", h.auth.ResetPasswordHandler(h.resp))
+	r.Post("/api/email/verify", h.auth.VerifyEmailHandler(h.resp))
+
+	// Приватные маршруты
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.Logger)
+		r.Use(authenticate)
+
+		mountRoutes(r, h.resp, h.privateRoutes())
+	})
+	return r
+}
+
+func (h *handlers) privateRoutes() []route {
+	return []route{
+		// Сессия
+		{http.MethodPost, "/api/logout", "", h.auth.LogoutHandler(h.resp)},
+		{http.MethodPost, "/api/email/verify/request", "", h.auth.RequestVerificationHandler(h.resp)},
+
+		// Пользователи
+		{http.MethodPost, "/api/users", entities.PermUsersAdmin, h.user.CreateUser(h.resp)},
+		{http.MethodGet, "/api/users/{id}", entities.PermUsersAdmin, h.user.GetUser(h.resp)},
+		{http.MethodPut, "/api/users/{id}", entities.PermUsersAdmin, h.user.UpdateUser(h.resp)},
+		{http.MethodDelete, "/api/users/{id}", entities.PermUsersAdmin, h.user.DeleteUser(h.resp)},
+		{http.MethodGet, "/api/users", entities.PermUsersAdmin, h.user.ListUsers(h.resp)},
+
+		// Роли
+		{http.MethodGet, "/api/admin/users/{username}/roles", entities.PermUsersAdmin, h.role.ListRolesHandler(h.resp)},
+		{http.MethodPost, "/api/admin/users/{username}/roles", entities.PermUsersAdmin, h.role.GrantRoleHandler(h.resp)},
+		{http.MethodDelete, "/api/admin/users/{username}/roles/{role}", entities.PermUsersAdmin, h.role.RevokeRoleHandler(h.resp)},
+		{http.MethodDelete, "/api/admin/users/{username}/lockout", entities.PermUsersAdmin, h.auth.UnlockHandler(h.resp)},
+
+		// API-ключи
+		{http.MethodPost, "/api/admin/api-keys", entities.PermUsersAdmin, h.apiKey.CreateAPIKeyHandler(h.resp)},
+		{http.MethodGet, "/api/admin/api-keys", entities.PermUsersAdmin, h.apiKey.ListAPIKeysHandler(h.resp)},
+		{http.MethodDelete, "/api/admin/api-keys/{id}", entities.PermUsersAdmin, h.apiKey.RevokeAPIKeyHandler(h.resp)},
+
+		// Книги
+		{http.MethodPost, "/api/book/take/{index}", "", h.book.TakeBookHandler(h.resp)},
+		{http.MethodDelete, "/api/book/return/{index}", "", h.book.ReturnBook(h.resp)},
+		{http.MethodPost, "/api/staff/book/take/{index}", entities.PermLoansStaff, h.book.StaffTakeBookHandler(h.resp)},
+		{http.MethodDelete, "/api/staff/book/return/{index}", entities.PermLoansStaff, h.book.StaffReturnBookHandler(h.resp)},
+		{http.MethodPost, "/api/book/renew/{index}", "", h.book.RenewBookHandler(h.resp)},
+		{http.MethodPost, "/api/book", entities.PermCatalogWrite, h.book.AddBookHandler(h.resp)},
+		{http.MethodGet, "/api/books", "", h.book.ListBooks(h.resp)},
+		{http.MethodGet, "/api/books/search", "", h.book.SearchBooks(h.resp)},
+		{http.MethodPut, "/api/books/{index}", entities.PermCatalogWrite, h.book.UpdateBook(h.resp)},
+		{http.MethodPost, "/api/book/{index}/copies", entities.PermCatalogWrite, h.book.AddCopyHandler(h.resp)},
+		{http.MethodGet, "/api/book/{index}/copies", "", h.book.ListCopiesHandler(h.resp)},
+
+		// Выдачи
+		{http.MethodGet, "/api/loans/{user_id}", "", h.book.ListLoansHandler(h.resp)},
+
+		// Брони
+		{http.MethodPost, "/api/book/hold/{index}", "", h.book.PlaceHoldHandler(h.resp)},
+		{http.MethodDelete, "/api/book/hold/{index}", "", h.book.CancelHoldHandler(h.resp)},
+		{http.MethodGet, "/api/holds/{user_id}", "", h.book.ListHoldsHandler(h.resp)},
+
+		// Штрафы
+		{http.MethodGet, "/api/fines/{user_id}", "", h.fine.ListFinesHandler(h.resp)},
+		{http.MethodPost, "/api/fines/{id}/pay", entities.PermFinesManage, h.fine.PayFineHandler(h.resp)},
+		{http.MethodPost, "/api/fines/{id}/waive", entities.PermFinesManage, h.fine.WaiveFineHandler(h.resp)},
+
+		// Авторы
+		{http.MethodPost, "/api/authors", entities.PermCatalogWrite, h.author.AddAuthorHandler(h.resp)},
+		{http.MethodGet, "/api/authors", "", h.author.ListAuthorsHandler(h.resp)},
+		{http.MethodGet, "/api/authors/{id}", "", h.author.GetAuthorHandler(h.resp)},
+		{http.MethodPut, "/api/authors/{id}", entities.PermCatalogWrite, h.author.UpdateAuthorHandler(h.resp)},
+		{http.MethodDelete, "/api/authors/{id}", entities.PermCatalogWrite, h.author.DeleteAuthorHandler(h.resp)},
+		{http.MethodGet, "/api/authors/{id}/books", "", h.author.ListAuthorBooksHandler(h.resp)},
+	}
+}
+
+// mountRoutes регистрирует маршруты, закрывая каждый требуемым правом
+func mountRoutes(r chi.Router, resp controllers.Responder, routes []route) {
+	for _, rt := range routes {
+		router := r
+		if rt.permission != "" {
+			router = r.With(authMiddleware.RequirePermission(resp, rt.permission))
+		}
+		router.Method(rt.method, rt.pattern, rt.handler)
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"go.uber.org/zap"
+	authMiddleware "studentgit.kata.academy/Zhodaran/go-kata/internal/api/middleware"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/config"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/controllers"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/entities"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/infrastructure/tokens"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+)
+
+type emptyDenylist struct{}
+
+type catalogKey struct{}
+
+func (catalogKey) AuthenticateAPIKey(ctx context.Context, key string) (entities.APIKey, error) {
+	if key != "lib_sync_secret" {
+		return entities.APIKey{}, repositories.ErrAPIKeyInvalid
+	}
+	return entities.APIKey{ID: 1, Prefix: "sync", Scopes: []string{entities.PermCatalogWrite}}, nil
+}
+
+func (emptyDenylist) IsAccessTokenDenied(ctx context.Context, jti string) (bool, error) {
+	return false, nil
+}
+
+func TestPrivateRoutePermissions(t *testing.T) {
+	resp := controllers.NewResponder(zap.NewNop())
+	keys, err := tokens.NewKeySet(config.JWTKey{ID: "test", Algorithm: "HS256", Secret: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := &handlers{
+		resp:   resp,
+		auth:   controllers.NewAuthController(nil, keys, nil, nil, config.AuthConfig{}, config.MailConfig{}),
+		user:   controllers.NewUserController(nil),
+		book:   controllers.NewBookController(nil),
+		author: controllers.NewAuthorController(nil),
+		fine:   controllers.NewFineController(nil),
+		role:   controllers.NewRoleController(nil),
+		apiKey: controllers.NewAPIKeyController(nil),
+	}
+
+	// Настоящие обработчики требуют базу, проверяем только права
+	routes := h.privateRoutes()
+	for i := range routes {
+		routes[i].handler = func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	}
+
+	r := chi.NewRouter()
+	r.Group(func(r chi.Router) {
+		r.Use(authMiddleware.TokenAuthMiddleware(resp, keys, emptyDenylist{}, catalogKey{}))
+		mountRoutes(r, resp, routes)
+	})
+
+	tokens := map[string]string{}
+	for _, role := range []string{entities.RolePatron, entities.RoleLibrarian, entities.RoleAdmin} {
+		_, token, err := keys.Encode(map[string]interface{}{
+			"user_id": 1,
+			"roles":   []string{role},
+			"jti":     role,
+			"exp":     time.Now().Add(time.Hour).Unix(),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		tokens[role] = token
+	}
+
+	expected := map[string][]string{
+		"":                        {entities.RolePatron, entities.RoleLibrarian, entities.RoleAdmin},
+		entities.PermCatalogWrite: {entities.RoleLibrarian, entities.RoleAdmin},
+		entities.PermLoansStaff:   {entities.RoleLibrarian, entities.RoleAdmin},
+		entities.PermFinesManage:  {entities.RoleLibrarian, entities.RoleAdmin},
+		entities.PermUsersAdmin:   {entities.RoleAdmin},
+	}
+
+	for _, rt := range routes {
+		path := strings.NewReplacer("{index}", "1", "{id}", "1", "{username}", "bob", "{user_id}", "2", "{role}", "librarian").Replace(rt.pattern)
+		allowed, ok := expected[rt.permission]
+		if !ok {
+			t.Fatalf("%s %s: unexpected permission %q", rt.method, rt.pattern, rt.permission)
+		}
+
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(rt.method, path, nil))
+		if rr.Code != http.StatusUnauthorized {
+			t.Errorf("%s %s without token: status %d, want 401", rt.method, rt.pattern, rr.Code)
+		}
+
+		for role, token := range tokens {
+			want := http.StatusForbidden
+			for _, a := range allowed {
+				if a == role {
+					want = http.StatusOK
+				}
+			}
+
+			req := httptest.NewRequest(rt.method, path, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+			if rr.Code != want {
+				t.Errorf("%s %s as %s: status %d, want %d", rt.method, rt.pattern, role, rr.Code, want)
+			}
+		}
+
+		// Ключ синхронизации каталога получает только свою область действия
+		want := http.StatusForbidden
+		if rt.permission == "" || rt.permission == entities.PermCatalogWrite {
+			want = http.StatusOK
+		}
+		req := httptest.NewRequest(rt.method, path, nil)
+		req.Header.Set("X-API-Key", "lib_sync_secret")
+		rr = httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		if rr.Code != want {
+			t.Errorf("%s %s with api key: status %d, want %d", rt.method, rt.pattern, rr.Code, want)
+		}
+	}
+}
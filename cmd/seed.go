@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"io"
+
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/config"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/infrastructure/password"
+	postgresRepo "studentgit.kata.academy/Zhodaran/go-kata/internal/infrastructure/postgres"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/infrastructure/seed"
+)
+
+// runSeed применяет миграции и заполняет пустую базу демонстрационными данными.
+// Если в базе уже есть книги или пользователи, ничего не меняет. Без -password
+// пароль учетных записей генерируется и печатается один раз
+func runSeed(ctx context.Context, db *sql.DB, args []string, out io.Writer) error {
+	cfg := seed.DefaultConfig()
+	flags := flag.NewFlagSet("seed", flag.ContinueOnError)
+	flags.SetOutput(out)
+	flags.Int64Var(&cfg.Seed, "seed", cfg.Seed, "random seed, the same seed gives the same data")
+	flags.IntVar(&cfg.Authors, "authors", cfg.Authors, "number of authors")
+	flags.IntVar(&cfg.Books, "books", cfg.Books, "number of books")
+	flags.IntVar(&cfg.Users, "users", cfg.Users, "number of patrons besides admin and librarian")
+	flags.IntVar(&cfg.Loans, "loans", cfg.Loans, "number of loans, about a fifth of them overdue")
+	flags.IntVar(&cfg.Holds, "holds", cfg.Holds, "number of holds")
+	pass := flags.String("password", "", "password of every seeded account, random if empty")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	for name, n := range map[string]int{"authors": cfg.Authors, "books": cfg.Books, "users": cfg.Users, "loans": cfg.Loans, "holds": cfg.Holds} {
+		if n < 0 {
+			return fmt.Errorf("-%s must not be negative", name)
+		}
+	}
+
+	migrator, err := postgresRepo.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		return err
+	}
+
+	authCfg, err := config.LoadAuthConfig()
+	if err != nil {
+		return err
+	}
+	generated := *pass == ""
+	if generated {
+		if *pass, err = randomPassword(); err != nil {
+			return err
+		}
+	}
+	hash, err := password.NewHasher(authCfg.PasswordCost).Hash(*pass)
+	if err != nil {
+		return err
+	}
+	result, err := seed.Apply(ctx, db, seed.Plan(cfg), hash)
+	if err != nil {
+		return err
+	}
+	if result.Skipped {
+		fmt.Fprintln(out, "database already has books or users, nothing seeded")
+		return nil
+	}
+	fmt.Fprintf(out, "seeded %d books (%d copies), %d users, %d loans, %d holds\n",
+		result.Books, result.Copies, result.Users, result.Loans, result.Holds)
+	if generated {
+		fmt.Fprintf(out, "log in as admin or librarian with password %s, it is not stored anywhere\n", *pass)
+	} else {
+		fmt.Fprintln(out, "log in as admin or librarian with the -password value")
+	}
+	return nil
+}
+
+// randomPassword пароль для учетных записей без -password: у admin не должно быть известного всем пароля
+func randomPassword() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
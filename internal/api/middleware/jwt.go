@@ -1,16 +1,52 @@
 package middleware
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"strings"
 
+	"github.com/go-chi/jwtauth"
+	"github.com/lestrrat-go/jwx/jwt"
 	"studentgit.kata.academy/Zhodaran/go-kata/internal/controllers"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/entities"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
 )
 
-func TokenAuthMiddleware(resp controllers.Responder) func(http.Handler) http.Handler {
+// TokenVerifier проверяет подпись и срок действия токена
+type TokenVerifier interface {
+	Verify(token string) (jwt.Token, error)
+}
+
+// TokenDenylist список отозванных access-токенов
+type TokenDenylist interface {
+	IsAccessTokenDenied(ctx context.Context, jti string) (bool, error)
+}
+
+// APIKeyAuthenticator проверяет ключ из заголовка X-API-Key
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, key string) (entities.APIKey, error)
+}
+
+// TokenAuthMiddleware проверяет Bearer-токен, сверяет его jti со списком отозванных
+// и кладет claims в контекст запроса. Сервисы вместо токена передают ключ в X-API-Key
+func TokenAuthMiddleware(resp controllers.Responder, verifier TokenVerifier, denylist TokenDenylist, apiKeys APIKeyAuthenticator) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if apiKey := r.Header.Get("X-API-Key"); apiKey != "" {
+				key, err := apiKeys.AuthenticateAPIKey(r.Context(), apiKey)
+				if errors.Is(err, repositories.ErrAPIKeyInvalid) {
+					resp.ErrorUnauthorized(w, err)
+					return
+				}
+				if err != nil {
+					resp.ErrorInternal(w, err)
+					return
+				}
+				next.ServeHTTP(w, r.WithContext(controllers.WithAPIKey(r.Context(), key)))
+				return
+			}
+
 			token := r.Header.Get("Authorization")
 			if token == "" {
 				resp.ErrorUnauthorized(w, errors.New("missing authorization token"))
@@ -19,13 +55,38 @@ func TokenAuthMiddleware(resp controllers.Responder) func(http.Handler) http.Han
 
 			token = strings.TrimPrefix(token, "Bearer ")
 
-			_, err := controllers.TokenAuth.Decode(token)
+			jwtToken, err := verifier.Verify(token)
 			if err != nil {
 				resp.ErrorUnauthorized(w, err)
 				return
 			}
 
-			next.ServeHTTP(w, r)
+			if _, ok := controllers.UserIDFromToken(jwtToken); !ok {
+				resp.ErrorUnauthorized(w, errors.New("token has no user_id claim"))
+				return
+			}
+
+			// Токены из писем подписаны тем же ключом, но для доступа к API не годятся
+			if _, ok := jwtToken.Get("purpose"); ok {
+				resp.ErrorUnauthorized(w, errors.New("not an access token"))
+				return
+			}
+			if jwtToken.JwtID() == "" {
+				resp.ErrorUnauthorized(w, errors.New("token has no jti claim"))
+				return
+			}
+			denied, err := denylist.IsAccessTokenDenied(r.Context(), jwtToken.JwtID())
+			if err != nil {
+				resp.ErrorInternal(w, err)
+				return
+			}
+			if denied {
+				resp.ErrorUnauthorized(w, errors.New("token has been revoked"))
+				return
+			}
+
+			ctx := jwtauth.NewContext(r.Context(), jwtToken, nil)
+			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
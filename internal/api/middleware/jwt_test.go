@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/config"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/controllers"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/entities"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/infrastructure/tokens"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+)
+
+type apiKeysStub map[string]entities.APIKey
+
+func (s apiKeysStub) AuthenticateAPIKey(ctx context.Context, key string) (entities.APIKey, error) {
+	apiKey, ok := s[key]
+	if !ok {
+		return entities.APIKey{}, repositories.ErrAPIKeyInvalid
+	}
+	return apiKey, nil
+}
+
+type denylistStub map[string]bool
+
+func (d denylistStub) IsAccessTokenDenied(ctx context.Context, jti string) (bool, error) {
+	return d[jti], nil
+}
+
+func TestTokenAuthMiddleware(t *testing.T) {
+	resp := controllers.NewResponder(zap.NewNop())
+	var gotUserID int
+	keys, err := tokens.NewKeySet(config.JWTKey{ID: "test", Algorithm: "HS256", Secret: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := TokenAuthMiddleware(resp, keys, denylistStub{"revoked": true}, apiKeysStub{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUserID, _ = controllers.UserIDFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	token := func(claims map[string]interface{}) string {
+		_, s, err := keys.Encode(claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return "Bearer " + s
+	}
+
+	cases := []struct {
+		name   string
+		header string
+		status int
+	}{
+		{"no token", "", http.StatusUnauthorized},
+		{"garbage", "Bearer not-a-token", http.StatusUnauthorized},
+		{"expired", token(map[string]interface{}{"user_id": 1, "jti": "a", "exp": time.Now().Add(-time.Hour).Unix()}), http.StatusUnauthorized},
+		{"username as user_id", token(map[string]interface{}{"user_id": "alice", "jti": "a", "exp": time.Now().Add(time.Hour).Unix()}), http.StatusUnauthorized},
+		{"no user_id", token(map[string]interface{}{"jti": "a", "exp": time.Now().Add(time.Hour).Unix()}), http.StatusUnauthorized},
+		{"no jti", token(map[string]interface{}{"user_id": 1, "exp": time.Now().Add(time.Hour).Unix()}), http.StatusUnauthorized},
+		{"revoked", token(map[string]interface{}{"user_id": 1, "jti": "revoked", "exp": time.Now().Add(time.Hour).Unix()}), http.StatusUnauthorized},
+		{"mail token", token(map[string]interface{}{"sub": "alice", "user_id": 1, "purpose": "password_reset", "jti": "a", "exp": time.Now().Add(time.Hour).Unix()}), http.StatusUnauthorized},
+		{"valid", token(map[string]interface{}{"user_id": 1, "jti": "a", "exp": time.Now().Add(time.Hour).Unix()}), http.StatusOK},
+	}
+
+	for _, c := range cases {
+		gotUserID = 0
+		req := httptest.NewRequest(http.MethodGet, "/api/books", nil)
+		if c.header != "" {
+			req.Header.Set("Authorization", c.header)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		if rr.Code != c.status {
+			t.Errorf("%s: status %d, want %d", c.name, rr.Code, c.status)
+		}
+	}
+
+	if gotUserID != 1 {
+		t.Errorf("user id from context = %d, want 1", gotUserID)
+	}
+}
+
+func TestTokenAuthMiddlewareAPIKey(t *testing.T) {
+	resp := controllers.NewResponder(zap.NewNop())
+	keys, err := tokens.NewKeySet(config.JWTKey{ID: "test", Algorithm: "HS256", Secret: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	apiKeys := apiKeysStub{"lib_kiosk_secret": {ID: 1, Prefix: "kiosk", Scopes: []string{entities.PermCatalogWrite}}}
+
+	var canWrite, canManageUsers, hasUser bool
+	handler := TokenAuthMiddleware(resp, keys, denylistStub{}, apiKeys)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		canWrite = controllers.HasPermission(r.Context(), entities.PermCatalogWrite)
+		canManageUsers = controllers.HasPermission(r.Context(), entities.PermUsersAdmin)
+		_, hasUser = controllers.UserIDFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/book", nil)
+	req.Header.Set("X-API-Key", "lib_unknown_secret")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("unknown key: status %d, want 401", rr.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/book", nil)
+	req.Header.Set("X-API-Key", "lib_kiosk_secret")
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("valid key: status %d, want 200", rr.Code)
+	}
+	if !canWrite || canManageUsers {
+		t.Errorf("permissions = catalog:write %v, users:admin %v; want only the key scopes", canWrite, canManageUsers)
+	}
+	if hasUser {
+		t.Error("api key request must not carry a user id")
+	}
+}
@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/controllers"
+)
+
+// RequirePermission пропускает запрос, только если роли из токена дают указанное право.
+// Ставится после TokenAuthMiddleware
+func RequirePermission(resp controllers.Responder, permission string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !controllers.HasPermission(r.Context(), permission) {
+				resp.ErrorForbidden(w, fmt.Errorf("permission %s required", permission))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
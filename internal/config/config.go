@@ -0,0 +1,161 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LoanConfig правила выдачи книг
+type LoanConfig struct {
+	Period         time.Duration // Срок, на который выдается книга
+	MaxRenewals    int           // Сколько раз можно продлить выдачу
+	SweepInterval  time.Duration // Как часто искать просроченные выдачи и брони
+	PickupWindow   time.Duration // Сколько отложенная по брони книга ждет читателя
+	FineDailyRate  int64         // Штраф за каждый день просрочки, в копейках
+	FineLimit      int64         // Долг, при превышении которого книги не выдаются, в копейках
+	MaxActiveLoans int           // Сколько книг читатель может держать одновременно, 0 без ограничения
+}
+
+// LoadLoanConfig читает правила выдачи из окружения, пропущенные значения берутся по умолчанию
+func LoadLoanConfig() LoanConfig {
+	return LoanConfig{
+		Period:         time.Duration(getInt("LOAN_PERIOD_DAYS", 14)) * 24 * time.Hour,
+		MaxRenewals:    getInt("LOAN_MAX_RENEWALS", 2),
+		SweepInterval:  getDuration("OVERDUE_SWEEP_INTERVAL", time.Hour),
+		PickupWindow:   time.Duration(getInt("HOLD_PICKUP_DAYS", 3)) * 24 * time.Hour,
+		FineDailyRate:  int64(getInt("FINE_DAILY_RATE", 1000)),
+		FineLimit:      int64(getInt("FINE_LIMIT", 50000)),
+		MaxActiveLoans: getInt("LOAN_MAX_ACTIVE", 5),
+	}
+}
+
+// AuthConfig параметры аутентификации
+type AuthConfig struct {
+	PasswordCost   int    // Стоимость bcrypt, при изменении хеши пересчитываются при входе
+	BootstrapAdmin string // Пользователь, которому при старте выдается роль admin
+	AccessTTL      time.Duration
+	RefreshTTL     time.Duration
+	SigningKey     JWTKey   // Ключ, которым подписываются новые токены
+	VerifyKeys     []JWTKey // Прежние ключи, токены которых еще принимаются во время ротации
+	Login          LoginLimitConfig
+}
+
+// LoginLimitConfig защита входа от перебора паролей
+type LoginLimitConfig struct {
+	Store         string        // memory для одного экземпляра или postgres для нескольких
+	MaxFailures   int           // Неудач по одному пользователю до блокировки
+	IPMaxFailures int           // Неудач с одного IP до блокировки
+	BackoffBase   time.Duration // Пауза после второй неудачи, дальше удваивается
+	BackoffMax    time.Duration
+	Lockout       time.Duration
+}
+
+// JWTKey ключ подписи токенов
+type JWTKey struct {
+	ID        string // Попадает в заголовок kid
+	Algorithm string // HS256, RS256 или EdDSA
+	Secret    string // Секрет HS256, если не задан File
+	File      string // PEM-файл ключа RS256 и EdDSA или файл с секретом HS256
+}
+
+// LoadAuthConfig читает параметры аутентификации. Ошибка означает неверный список JWT_VERIFY_KEYS
+func LoadAuthConfig() (AuthConfig, error) {
+	verifyKeys, err := getJWTKeys("JWT_VERIFY_KEYS")
+	if err != nil {
+		return AuthConfig{}, err
+	}
+	return AuthConfig{
+		PasswordCost:   getInt("PASSWORD_COST", 10),
+		BootstrapAdmin: os.Getenv("BOOTSTRAP_ADMIN"),
+		AccessTTL:      getDuration("ACCESS_TOKEN_TTL", 15*time.Minute),
+		RefreshTTL:     getDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour),
+		SigningKey: JWTKey{
+			ID:        getString("JWT_KID", "default"),
+			Algorithm: getString("JWT_ALG", "HS256"),
+			Secret:    os.Getenv("JWT_SECRET"),
+			File:      os.Getenv("JWT_KEY_FILE"),
+		},
+		VerifyKeys: verifyKeys,
+		Login: LoginLimitConfig{
+			Store:         getString("LOGIN_LIMITER_STORE", "memory"),
+			MaxFailures:   getInt("LOGIN_MAX_FAILURES", 5),
+			IPMaxFailures: getInt("LOGIN_IP_MAX_FAILURES", 20),
+			BackoffBase:   getDuration("LOGIN_BACKOFF_BASE", time.Second),
+			BackoffMax:    getDuration("LOGIN_BACKOFF_MAX", time.Minute),
+			Lockout:       getDuration("LOGIN_LOCKOUT", 15*time.Minute),
+		},
+	}, nil
+}
+
+// MailConfig отправка писем и ссылки в них
+type MailConfig struct {
+	Driver       string // smtp, file или log
+	From         string
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUser     string
+	SMTPPassword string
+	Dir          string        // Каталог для писем при Driver=file
+	BaseURL      string        // Адрес клиента, на который ведут ссылки из писем
+	ResetTTL     time.Duration // Срок действия ссылки сброса пароля
+	VerifyTTL    time.Duration // Срок действия ссылки подтверждения почты
+}
+
+func LoadMailConfig() MailConfig {
+	return MailConfig{
+		Driver:       getString("MAIL_DRIVER", "log"),
+		From:         getString("MAIL_FROM", "library@localhost"),
+		SMTPHost:     os.Getenv("SMTP_HOST"),
+		SMTPPort:     getInt("SMTP_PORT", 587),
+		SMTPUser:     os.Getenv("SMTP_USER"),
+		SMTPPassword: os.Getenv("SMTP_PASSWORD"),
+		Dir:          getString("MAIL_DIR", "mail"),
+		BaseURL:      getString("APP_BASE_URL", "http://localhost:8080"),
+		ResetTTL:     getDuration("PASSWORD_RESET_TTL", time.Hour),
+		VerifyTTL:    getDuration("EMAIL_VERIFY_TTL", 48*time.Hour),
+	}
+}
+
+func getString(key, def string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return def
+}
+
+// getJWTKeys разбирает список вида kid:alg:file,kid:alg:file.
+// Неполная запись считается ошибкой, чтобы ключ ротации не пропал незаметно
+func getJWTKeys(key string) ([]JWTKey, error) {
+	var keys []JWTKey
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		parts := strings.SplitN(item, ":", 3)
+		if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+			return nil, fmt.Errorf("%s: entry %q must be kid:alg:file", key, item)
+		}
+		keys = append(keys, JWTKey{ID: parts[0], Algorithm: parts[1], File: parts[2]})
+	}
+	return keys, nil
+}
+
+func getInt(key string, def int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+func getDuration(key string, def time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return def
+	}
+	return value
+}
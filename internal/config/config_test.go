@@ -0,0 +1,23 @@
+package config
+
+import "testing"
+
+func TestLoadAuthConfigVerifyKeys(t *testing.T) {
+	t.Setenv("JWT_VERIFY_KEYS", "old:RS256:/keys/old.pem, older:HS256:/keys/older")
+	cfg, err := LoadAuthConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.VerifyKeys) != 2 || cfg.VerifyKeys[1] != (JWTKey{ID: "older", Algorithm: "HS256", File: "/keys/older"}) {
+		t.Fatalf("unexpected verify keys %+v", cfg.VerifyKeys)
+	}
+}
+
+func TestLoadAuthConfigMalformedVerifyKey(t *testing.T) {
+	for _, value := range []string{"old:RS256", "old:RS256:/keys/old.pem,broken", ":HS256:/keys/secret"} {
+		t.Setenv("JWT_VERIFY_KEYS", value)
+		if _, err := LoadAuthConfig(); err == nil {
+			t.Errorf("JWT_VERIFY_KEYS=%q: expected an error", value)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/infrastructure/mailer"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/infrastructure/password"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+)
+
+// @Summary Request a password reset
+// @Description Sends a single-use password reset link to the email of the user. The response does not reveal whether the user exists.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body ForgotPasswordRequest true "Username or email"
+// @Success 202 {object} Response "Request accepted"
+// @Failure 400 {object} mErrorResponse "Invalid request"
+// @Failure 500 {object} mErrorResponse "Internal server error"
+// @Router /api/password/forgot [post]
+func (s *AuthController) ForgotPasswordHandler(resp Responder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var requestBody ForgotPasswordRequest
+		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil || requestBody.Login == "" {
+			resp.ErrorBadRequest(w, errors.New("login is required"))
+			return
+		}
+
+		username, email, err := s.facade.AuthService.FindByLogin(r.Context(), requestBody.Login)
+		switch {
+		case errors.Is(err, repositories.ErrUserNotFound), err == nil && email == "":
+			// Отвечаем так же, как при успехе, чтобы нельзя было перебирать пользователей
+		case err != nil:
+			resp.ErrorInternal(w, err)
+			return
+		default:
+			err = s.sendLink(r.Context(), username, email, repositories.PurposePasswordReset, s.mailCfg.ResetTTL,
+				"Password reset", "/reset-password", "To set a new password open the link below. It is valid for %s.\n\n%s\n\nIf you did not ask for a reset, ignore this email.")
+			if err != nil {
+				resp.ErrorInternal(w, err)
+				return
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json;charset=utf-8")
+		w.WriteHeader(http.StatusAccepted)
+		resp.OutputJSON(w, Response{Success: true, Message: "If the account exists, a reset link has been sent"})
+	}
+}
+
+// @Summary Reset the password
+// @Description Sets a new password using the token from the reset email. All sessions of the user are revoked.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body ResetPasswordRequest true "Token and new password"
+// @Success 200 {object} Response "Password changed"
+// @Failure 400 {object} mErrorResponse "Invalid, expired or used token"
+// @Failure 500 {object} mErrorResponse "Internal server error"
+// @Router /api/password/reset [post]
+func (s *AuthController) ResetPasswordHandler(resp Responder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var requestBody ResetPasswordRequest
+		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil || requestBody.Token == "" || requestBody.Password == "" {
+			resp.ErrorBadRequest(w, errors.New("token and password are required"))
+			return
+		}
+
+		jti, err := s.parseLinkToken(requestBody.Token, repositories.PurposePasswordReset)
+		if err != nil {
+			resp.ErrorBadRequest(w, errors.New("invalid or expired token"))
+			return
+		}
+
+		username, err := s.facade.AuthService.ResetPassword(r.Context(), jti, requestBody.Password)
+		if errors.Is(err, password.ErrPasswordTooLong) {
+			resp.ErrorBadRequest(w, errors.New("password is too long"))
+			return
+		}
+		if errors.Is(err, repositories.ErrTokenInvalid) {
+			resp.ErrorBadRequest(w, errors.New("invalid or expired token"))
+			return
+		}
+		if err != nil {
+			resp.ErrorInternal(w, err)
+			return
+		}
+		// После смены пароля блокировка за неудачные входы уже не нужна
+		if err := s.guard.Unlock(r.Context(), username, username); err != nil {
+			resp.ErrorInternal(w, err)
+			return
+		}
+
+		resp.OutputJSON(w, Response{Success: true, Message: "Password changed"})
+	}
+}
+
+// @Summary Send an email verification link
+// @Description Sends a verification link to the email of the authenticated user.
+// @Tags Auth
+// @Produce json
+// @Param Authorization header string true "Bearer Token"
+// @Success 202 {object} Response "Verification email sent"
+// @Failure 400 {object} mErrorResponse "No email or already verified"
+// @Failure 401 {object} mErrorResponse "Unauthorized"
+// @Failure 500 {object} mErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /api/email/verify/request [post]
+func (s *AuthController) RequestVerificationHandler(resp Responder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username, ok := UsernameFromContext(r.Context())
+		if !ok {
+			resp.ErrorUnauthorized(w, errors.New("missing authenticated user"))
+			return
+		}
+
+		email, verified, err := s.facade.AuthService.Email(r.Context(), username)
+		switch {
+		case errors.Is(err, repositories.ErrEmailNotSet):
+			resp.ErrorBadRequest(w, err)
+			return
+		case err != nil:
+			resp.ErrorInternal(w, err)
+			return
+		case verified:
+			resp.ErrorBadRequest(w, errors.New("email already verified"))
+			return
+		}
+
+		if err := s.sendVerification(r.Context(), username, email); err != nil {
+			resp.ErrorInternal(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json;charset=utf-8")
+		w.WriteHeader(http.StatusAccepted)
+		resp.OutputJSON(w, Response{Success: true, Message: "Verification email sent"})
+	}
+}
+
+// @Summary Verify the email
+// @Description Confirms the email address using the token from the verification email.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body VerifyEmailRequest true "Token"
+// @Success 200 {object} Response "Email verified"
+// @Failure 400 {object} mErrorResponse "Invalid, expired or used token"
+// @Failure 500 {object} mErrorResponse "Internal server error"
+// @Router /api/email/verify [post]
+func (s *AuthController) VerifyEmailHandler(resp Responder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var requestBody VerifyEmailRequest
+		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil || requestBody.Token == "" {
+			resp.ErrorBadRequest(w, errors.New("token is required"))
+			return
+		}
+
+		jti, err := s.parseLinkToken(requestBody.Token, repositories.PurposeVerifyEmail)
+		if err != nil {
+			resp.ErrorBadRequest(w, errors.New("invalid or expired token"))
+			return
+		}
+
+		_, err = s.facade.AuthService.VerifyEmail(r.Context(), jti)
+		if errors.Is(err, repositories.ErrTokenInvalid) {
+			resp.ErrorBadRequest(w, errors.New("invalid or expired token"))
+			return
+		}
+		if err != nil {
+			resp.ErrorInternal(w, err)
+			return
+		}
+		resp.OutputJSON(w, Response{Success: true, Message: "Email verified"})
+	}
+}
+
+func (s *AuthController) sendVerification(ctx context.Context, username, email string) error {
+	return s.sendLink(ctx, username, email, repositories.PurposeVerifyEmail, s.mailCfg.VerifyTTL,
+		"Confirm your email", "/verify-email", "To confirm your email open the link below. It is valid for %s.\n\n%s")
+}
+
+// sendLink выпускает подписанный одноразовый токен и отправляет ссылку с ним на почту.
+// text получает срок действия и ссылку
+func (s *AuthController) sendLink(ctx context.Context, username, email, purpose string, ttl time.Duration, subject, path, text string) error {
+	jti, err := newTokenID()
+	if err != nil {
+		return err
+	}
+	expiresAt := time.Now().Add(ttl)
+	_, signed, err := s.keys.Encode(map[string]interface{}{
+		"sub":     username,
+		"purpose": purpose, // Не дает использовать токен из письма как access-токен и наоборот
+		"jti":     jti,
+		"exp":     expiresAt.Unix(),
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := s.facade.AuthService.IssueLinkToken(ctx, jti, username, purpose, email, expiresAt); err != nil {
+		return err
+	}
+
+	link := s.mailCfg.BaseURL + path + "?token=" + url.QueryEscape(signed)
+	return s.mailer.Send(ctx, mailer.Message{To: email, Subject: subject, Body: fmt.Sprintf(text, ttl, link)})
+}
+
+// parseLinkToken проверяет подпись, срок и назначение токена из письма и возвращает его jti
+func (s *AuthController) parseLinkToken(token, purpose string) (string, error) {
+	parsed, err := s.keys.Verify(token)
+	if err != nil {
+		return "", err
+	}
+	if value, _ := parsed.Get("purpose"); value != purpose || parsed.JwtID() == "" {
+		return "", errors.New("token has wrong purpose")
+	}
+	return parsed.JwtID(), nil
+}
@@ -0,0 +1,131 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/entities"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+)
+
+// @Summary Create an API key
+// @Description Issues a key for a service. Scopes are permissions, the same ones roles grant. The key is shown only once.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer Token"
+// @Param body body APIKeyRequest true "Name and scopes"
+// @Success 201 {object} APIKeyResponse "Created key"
+// @Failure 400 {object} mErrorResponse "Invalid name or unknown scope"
+// @Failure 403 {object} mErrorResponse "Permission users:admin required"
+// @Failure 500 {object} mErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /api/admin/api-keys [post]
+func (kc *APIKeyController) CreateAPIKeyHandler(resp Responder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var requestBody APIKeyRequest
+		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+			resp.ErrorBadRequest(w, errors.New("invalid request body"))
+			return
+		}
+		requestBody.Name = strings.TrimSpace(requestBody.Name)
+		if requestBody.Name == "" {
+			resp.ErrorBadRequest(w, errors.New("name is required"))
+			return
+		}
+		if len(requestBody.Scopes) == 0 {
+			resp.ErrorBadRequest(w, errors.New("at least one scope is required"))
+			return
+		}
+		for _, scope := range requestBody.Scopes {
+			if !entities.IsValidPermission(scope) {
+				resp.ErrorBadRequest(w, fmt.Errorf("unknown scope %q", scope))
+				return
+			}
+		}
+
+		key, secret, err := kc.facade.AuthService.CreateAPIKey(r.Context(), requestBody.Name, requestBody.Scopes, actorName(r))
+		if err != nil {
+			resp.ErrorInternal(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json;charset=utf-8")
+		w.WriteHeader(http.StatusCreated)
+		resp.OutputJSON(w, APIKeyResponse{APIKey: key, Key: secret})
+	}
+}
+
+// @Summary List API keys
+// @Description Returns all keys with their scopes and last use time. Secrets are never returned.
+// @Tags Admin
+// @Produce json
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {array} entities.APIKey "Keys"
+// @Failure 403 {object} mErrorResponse "Permission users:admin required"
+// @Failure 500 {object} mErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /api/admin/api-keys [get]
+func (kc *APIKeyController) ListAPIKeysHandler(resp Responder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		keys, err := kc.facade.AuthService.APIKeys(r.Context())
+		if err != nil {
+			resp.ErrorInternal(w, err)
+			return
+		}
+		if keys == nil {
+			keys = []entities.APIKey{}
+		}
+		resp.OutputJSON(w, keys)
+	}
+}
+
+// @Summary Revoke an API key
+// @Description Revokes a key. Requests with it are rejected immediately.
+// @Tags Admin
+// @Produce json
+// @Param id path int true "Key ID"
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {object} entities.APIKey "Revoked key"
+// @Failure 400 {object} mErrorResponse "Invalid key ID"
+// @Failure 403 {object} mErrorResponse "Permission users:admin required"
+// @Failure 404 {object} mErrorResponse "Key not found"
+// @Failure 500 {object} mErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /api/admin/api-keys/{id} [delete]
+func (kc *APIKeyController) RevokeAPIKeyHandler(resp Responder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			resp.ErrorBadRequest(w, errors.New("invalid key id"))
+			return
+		}
+
+		key, err := kc.facade.AuthService.RevokeAPIKey(r.Context(), id)
+		if errors.Is(err, repositories.ErrAPIKeyNotFound) {
+			resp.ErrorNotFound(w, fmt.Errorf("api key %d not found", id))
+			return
+		}
+		if err != nil {
+			resp.ErrorInternal(w, err)
+			return
+		}
+		resp.OutputJSON(w, key)
+	}
+}
+
+// actorName имя того, кто выполняет запрос: пользователь из токена или API-ключ
+func actorName(r *http.Request) string {
+	if username, ok := UsernameFromContext(r.Context()); ok {
+		return username
+	}
+	if key, ok := APIKeyFromContext(r.Context()); ok {
+		return "api-key:" + key.Prefix
+	}
+	return ""
+}
@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/jwtauth"
+	"github.com/lestrrat-go/jwx/jwt"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/entities"
+)
+
+type apiKeyContextKey struct{}
+
+// WithAPIKey кладет в контекст ключ, которым авторизован запрос
+func WithAPIKey(ctx context.Context, key entities.APIKey) context.Context {
+	return context.WithValue(ctx, apiKeyContextKey{}, key)
+}
+
+// APIKeyFromContext возвращает ключ, если запрос авторизован через X-API-Key
+func APIKeyFromContext(ctx context.Context) (entities.APIKey, bool) {
+	key, ok := ctx.Value(apiKeyContextKey{}).(entities.APIKey)
+	return key, ok
+}
+
+// UserIDFromContext возвращает user_id из токена, который TokenAuthMiddleware положил в контекст
+func UserIDFromContext(ctx context.Context) (int, bool) {
+	token, _, err := jwtauth.FromContext(ctx)
+	if err != nil || token == nil {
+		return 0, false
+	}
+	return UserIDFromToken(token)
+}
+
+// UserIDFromToken достает числовой user_id из claims токена
+func UserIDFromToken(token jwt.Token) (int, bool) {
+	value, ok := token.Get("user_id")
+	if !ok {
+		return 0, false
+	}
+	var userID int
+	switch id := value.(type) {
+	case float64:
+		// После разбора токена числа приходят как float64
+		userID = int(id)
+		if float64(userID) != id {
+			return 0, false
+		}
+	case int:
+		userID = id
+	case int64:
+		userID = int(id)
+	}
+	return userID, userID > 0
+}
+
+// UsernameFromContext возвращает логин пользователя из токена
+func UsernameFromContext(ctx context.Context) (string, bool) {
+	token, _, err := jwtauth.FromContext(ctx)
+	if err != nil || token == nil {
+		return "", false
+	}
+	value, _ := token.Get("username")
+	username, ok := value.(string)
+	return username, ok && username != ""
+}
+
+// RolesFromContext возвращает роли из токена. Роль patron есть у любого авторизованного пользователя
+func RolesFromContext(ctx context.Context) []string {
+	roles := []string{entities.RolePatron}
+	token, _, err := jwtauth.FromContext(ctx)
+	if err != nil || token == nil {
+		return roles
+	}
+
+	value, _ := token.Get("roles")
+	switch claim := value.(type) {
+	case []string:
+		roles = append(roles, claim...)
+	case []interface{}:
+		// После разбора токена массив приходит как []interface{}
+		for _, item := range claim {
+			if role, ok := item.(string); ok {
+				roles = append(roles, role)
+			}
+		}
+	}
+	return roles
+}
+
+// HasPermission сообщает, дают ли роли из токена указанное право.
+// Для API-ключа права берутся из его областей действия
+func HasPermission(ctx context.Context, permission string) bool {
+	if key, ok := APIKeyFromContext(ctx); ok {
+		for _, scope := range key.Scopes {
+			if scope == permission {
+				return true
+			}
+		}
+		return false
+	}
+	return entities.HasPermission(RolesFromContext(ctx), permission)
+}
+
+// actingUser определяет, от чьего имени выполняется действие.
+// Обычный маршрут работает только от пользователя из токена, служебный требует роль сотрудника и user_id читателя.
+// У API-ключа нет своего пользователя, поэтому ему доступен только служебный маршрут с областью loans:staff
+func actingUser(resp Responder, w http.ResponseWriter, r *http.Request, requested int, staff bool) (int, bool) {
+	_, byKey := APIKeyFromContext(r.Context())
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok && !(staff && byKey) {
+		resp.ErrorUnauthorized(w, errors.New("missing authenticated user"))
+		return 0, false
+	}
+
+	if staff {
+		if !HasPermission(r.Context(), entities.PermLoansStaff) {
+			resp.ErrorForbidden(w, errors.New("only staff can act on behalf of another user"))
+			return 0, false
+		}
+		if requested == 0 {
+			resp.ErrorBadRequest(w, errors.New("user_id is required"))
+			return 0, false
+		}
+		return requested, true
+	}
+
+	if requested != 0 && requested != userID {
+		resp.ErrorForbidden(w, errors.New("cannot act on behalf of another user"))
+		return 0, false
+	}
+	return userID, true
+}
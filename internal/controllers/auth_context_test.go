@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/jwtauth"
+	"github.com/lestrrat-go/jwx/jwt"
+	"go.uber.org/zap"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/entities"
+)
+
+func TestActingUser(t *testing.T) {
+	resp := NewResponder(zap.NewNop())
+
+	cases := []struct {
+		name      string
+		roles     []string
+		requested int
+		staff     bool
+		want      int
+		status    int
+	}{
+		{"self implicit", nil, 0, false, 1, http.StatusOK},
+		{"self explicit", nil, 1, false, 1, http.StatusOK},
+		{"patron for other", nil, 2, false, 0, http.StatusForbidden},
+		{"librarian on patron route", []string{entities.RoleLibrarian}, 2, false, 0, http.StatusForbidden},
+		{"patron on staff route", nil, 2, true, 0, http.StatusForbidden},
+		{"librarian for patron", []string{entities.RoleLibrarian}, 2, true, 2, http.StatusOK},
+		{"librarian without user_id", []string{entities.RoleLibrarian}, 0, true, 0, http.StatusBadRequest},
+	}
+
+	for _, c := range cases {
+		token := jwt.New()
+		token.Set("user_id", 1)
+		token.Set("roles", c.roles)
+		req := httptest.NewRequest(http.MethodPost, "/api/book/take/1", nil)
+		req = req.WithContext(jwtauth.NewContext(req.Context(), token, nil))
+		rr := httptest.NewRecorder()
+
+		got, ok := actingUser(resp, rr, req, c.requested, c.staff)
+		if ok != (c.status == http.StatusOK) || got != c.want {
+			t.Errorf("%s: got (%d, %v), want %d", c.name, got, ok, c.want)
+		}
+		if rr.Code != c.status {
+			t.Errorf("%s: status %d, want %d", c.name, rr.Code, c.status)
+		}
+	}
+}
+
+func TestActingUserWithAPIKey(t *testing.T) {
+	resp := NewResponder(zap.NewNop())
+
+	cases := []struct {
+		name      string
+		scopes    []string
+		requested int
+		staff     bool
+		want      int
+		status    int
+	}{
+		{"staff scope for patron", []string{entities.PermLoansStaff}, 2, true, 2, http.StatusOK},
+		{"staff scope without user_id", []string{entities.PermLoansStaff}, 0, true, 0, http.StatusBadRequest},
+		{"catalogue scope on staff route", []string{entities.PermCatalogWrite}, 2, true, 0, http.StatusForbidden},
+		{"key on patron route", []string{entities.PermLoansStaff}, 2, false, 0, http.StatusUnauthorized},
+	}
+
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodPost, "/api/staff/book/take/1", nil)
+		req = req.WithContext(WithAPIKey(req.Context(), entities.APIKey{ID: 1, Scopes: c.scopes}))
+		rr := httptest.NewRecorder()
+
+		got, ok := actingUser(resp, rr, req, c.requested, c.staff)
+		if ok != (c.status == http.StatusOK) || got != c.want {
+			t.Errorf("%s: got (%d, %v), want %d", c.name, got, ok, c.want)
+		}
+		if rr.Code != c.status {
+			t.Errorf("%s: status %d, want %d", c.name, rr.Code, c.status)
+		}
+	}
+}
+
+func TestUserIDFromToken(t *testing.T) {
+	cases := []struct {
+		name  string
+		value interface{}
+		want  int
+		ok    bool
+	}{
+		{"parsed number", float64(42), 42, true},
+		{"int", 42, 42, true},
+		{"fraction", 4.2, 0, false},
+		{"legacy username", "alice", 0, false},
+		{"zero", float64(0), 0, false},
+	}
+
+	for _, c := range cases {
+		token := jwt.New()
+		token.Set("user_id", c.value)
+		got, ok := UserIDFromToken(token)
+		if got != c.want || ok != c.ok {
+			t.Errorf("%s: got (%d, %v), want (%d, %v)", c.name, got, ok, c.want, c.ok)
+		}
+	}
+}
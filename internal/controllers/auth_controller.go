@@ -2,11 +2,18 @@ package controllers
 
 import (
 	"encoding/json"
-	"fmt"
+	"errors"
+	"math"
+	"net"
 	"net/http"
-	"time"
+	"strconv"
+
+	"github.com/go-chi/chi"
 
 	"studentgit.kata.academy/Zhodaran/go-kata/internal/entities"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/infrastructure/password"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/usecases/usecasesAuth"
 )
 
 func (s *AuthController) Login(w http.ResponseWriter, r *http.Request) {
@@ -16,34 +23,83 @@ func (s *AuthController) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Получаем данные пользователя из мапы Users
-	storedUser, exists := Users[user.Username]
-	if !exists {
-		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
+	// Перебор паролей замедляется паузами и блокировкой по имени и по IP
+	ip := clientIP(r)
+	wait, err := s.guard.Allow(r.Context(), user.Username, ip)
+	if err != nil {
+		http.Error(w, "Could not check credentials", http.StatusInternalServerError)
 		return
 	}
-
-	// Проверяем совпадение пароля
-	if storedUser.Password != user.Password {
-		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
+	if wait > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		http.Error(w, "Too many failed login attempts, try again later", http.StatusTooManyRequests)
 		return
 	}
 
-	// Если авторизация успешна, создаем токен
-	claims := map[string]interface{}{
-		"user_id": user.Username, // Используем username как user_id
-		"exp":     time.Now().Add(time.Hour * 72).Unix(),
+	err = s.facade.AuthService.Authenticate(r.Context(), user.Username, user.Password)
+	if errors.Is(err, usecasesAuth.ErrInvalidCredentials) {
+		s.loginFailed(w, r, user.Username, ip)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Could not check credentials", http.StatusInternalServerError)
+		return
 	}
-	_, tokenString, err := TokenAuth.Encode(claims)
+	if err := s.guard.Succeeded(r.Context(), user.Username); err != nil {
+		http.Error(w, "Could not check credentials", http.StatusInternalServerError)
+		return
+	}
+
+	tokens, err := s.startSession(r.Context(), user.Username)
 	if err != nil {
 		http.Error(w, "Could not create token", http.StatusInternalServerError)
 		return
 	}
 
-	w.Header().Set("Authorization", "Bearer "+tokenString)
+	w.Header().Set("Authorization", "Bearer "+tokens.Token)
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(TokenResponse{Token: tokenString})
-	fmt.Println(tokenString)
+	json.NewEncoder(w).Encode(tokens)
+}
+
+// loginFailed учитывает неудачную попытку и отвечает одинаково для неизвестного пользователя и неверного пароля
+func (s *AuthController) loginFailed(w http.ResponseWriter, r *http.Request, username, ip string) {
+	if err := s.guard.Failed(r.Context(), username, ip); err != nil {
+		http.Error(w, "Could not check credentials", http.StatusInternalServerError)
+		return
+	}
+	http.Error(w, "Invalid credentials", http.StatusUnauthorized)
+}
+
+// @Summary Unlock a user
+// @Description Clears failed login attempts and lifts the lockout of a user.
+// @Tags Admin
+// @Produce json
+// @Param username path string true "Username"
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {object} Response "User unlocked"
+// @Failure 403 {object} mErrorResponse "Permission users:admin required"
+// @Failure 500 {object} mErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /api/admin/users/{username}/lockout [delete]
+func (s *AuthController) UnlockHandler(resp Responder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		admin, _ := UsernameFromContext(r.Context())
+		if err := s.guard.Unlock(r.Context(), chi.URLParam(r, "username"), admin); err != nil {
+			resp.ErrorInternal(w, err)
+			return
+		}
+		resp.OutputJSON(w, Response{Success: true, Message: "User unlocked"})
+	}
+}
+
+// clientIP адрес клиента без порта. Заголовкам прокси не доверяем, их легко подделать
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
 }
 
 func (s *AuthController) Register(w http.ResponseWriter, r *http.Request) {
@@ -53,19 +109,34 @@ func (s *AuthController) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	mu.Lock()
-	defer mu.Unlock()
-
-	if _, exists := Users[user.Username]; exists {
+	userID, err := s.facade.AuthService.Register(r.Context(), user.Username, user.Email, user.Password)
+	switch {
+	case errors.Is(err, usecasesAuth.ErrCredentialsRequired):
+		http.Error(w, "Username and password are required", http.StatusBadRequest)
+		return
+	case errors.Is(err, usecasesAuth.ErrInvalidEmail):
+		http.Error(w, "Invalid email", http.StatusBadRequest)
+		return
+	case errors.Is(err, password.ErrPasswordTooLong):
+		http.Error(w, "Password is too long", http.StatusBadRequest)
+		return
+	case errors.Is(err, repositories.ErrUserExists):
 		http.Error(w, "User already exists", http.StatusConflict)
 		return
+	case err != nil:
+		http.Error(w, "Could not register user", http.StatusInternalServerError)
+		return
 	}
 
-	Users[user.Username] = entities.UserAuth{
-		Username: user.Username,
-		Password: user.Password,
+	message := "User registered successfully"
+	if user.Email != "" {
+		if err := s.sendVerification(r.Context(), user.Username, user.Email); err != nil {
+			// Учетная запись уже создана, письмо можно запросить повторно
+			message = "User registered, but the verification email could not be sent"
+		}
 	}
 
-	// Используем логин пользователя в качестве user_id
-
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(Response{Success: true, Message: message, Data: map[string]int{"user_id": userID}})
 }
@@ -3,20 +3,31 @@ package controllers
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/entities"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/usecases/usecasesAuthor"
 )
 
 // @Summary Add a new author to the library
-// @Description This endpoint allows you to add a new author to the library.
+// @Description Registers an author who may not have books yet. Authors of added books are registered automatically.
 // @Tags Authors
 // @Accept json
 // @Produce json
-// @Param author body AuthorRequest true "Author name"
-// @Success 201 {object} string "Author added successfully"
+// @Param Authorization header string true "Bearer Token"
+// @Param author body AuthorRequest true "Author details"
+// @Success 201 {object} entities.Author "Created author"
+// @Header 201 {string} Location "URL of the created author"
 // @Failure 400 {object} mErrorResponse "Invalid request"
+// @Failure 409 {object} mErrorResponse "Author already exists"
 // @Failure 500 {object} mErrorResponse "Internal server error"
+// @Security BearerAuth
 // @Router /api/authors [post]
-func (a *AuthorController) AddAuthorHandler(resp Responder, library *Library) http.HandlerFunc {
+func (a *AuthorController) AddAuthorHandler(resp Responder) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var authorRequest AuthorRequest
 		if err := json.NewDecoder(r.Body).Decode(&authorRequest); err != nil {
@@ -24,19 +35,15 @@ func (a *AuthorController) AddAuthorHandler(resp Responder, library *Library) ht
 			return
 		}
 
-		if authorRequest.Name == "" {
-			resp.ErrorBadRequest(w, errors.New("author name is required"))
+		author, err := a.facade.AuthorService.Add(r.Context(), authorRequest.author(0))
+		if !handleAuthorError(resp, w, err) {
 			return
 		}
 
-		library.mu.Lock()         // Блокируем запись
-		defer library.mu.Unlock() // Разблокируем запись после завершения
-
-		// Добавление автора в библиотеку (можно добавить логику для проверки уникальности)
-		// Здесь предполагается, что у вас есть структура для хранения авторов
-		library.Authors = append(library.Authors, authorRequest.Name)
-
-		resp.OutputJSON(w, map[string]string{"message": "Author added successfully"})
+		w.Header().Set("Location", fmt.Sprintf("/api/authors/%d", author.ID))
+		w.Header().Set("Content-Type", "application/json;charset=utf-8")
+		w.WriteHeader(http.StatusCreated)
+		resp.OutputJSON(w, author)
 	}
 }
 
@@ -45,45 +52,199 @@ func (a *AuthorController) AddAuthorHandler(resp Responder, library *Library) ht
 // @Description Get a list of all authors in the library
 // @Tags Authors
 // @Produce json
-// @Success 200 {array} string "List of authors"
+// @Success 200 {array} entities.Author "List of authors"
 // @Failure 404 {object} mErrorResponse "No authors found"
 // @Router /api/get-authors [get]
-func (a *AuthorController) GetAuthorsHandler(resp Responder, library *Library) http.HandlerFunc {
+func (a *AuthorController) GetAuthorsHandler(resp Responder) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		library.mu.RLock()         // Блокируем чтение
-		defer library.mu.RUnlock() // Разблокируем чтение после завершения
+		authors, err := a.facade.AuthorService.List(r.Context())
+		if err != nil {
+			resp.ErrorInternal(w, err)
+			return
+		}
+		if len(authors) == 0 {
+			resp.ErrorNotFound(w, errors.New("no authors found"))
+			return
+		}
+		resp.OutputJSON(w, authors)
+	}
+}
 
-		// Проверяем, есть ли авторы
-		if len(library.Authors) == 0 {
-			http.Error(w, "No authors found", http.StatusNotFound)
+// @Summary List authors
+// @Description Authors of all books and authors added without books, in alphabetical order.
+// @Tags Authors
+// @Produce json
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {array} entities.Author "List of authors"
+// @Failure 500 {object} mErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /api/authors [get]
+func (a *AuthorController) ListAuthorsHandler(resp Responder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authors, err := a.facade.AuthorService.List(r.Context())
+		if err != nil {
+			resp.ErrorInternal(w, err)
 			return
 		}
+		resp.OutputJSON(w, authors)
+	}
+}
 
-		// Возвращаем список авторов в формате JSON
-		resp.OutputJSON(w, library.Authors)
+// @Summary Get an author
+// @Tags Authors
+// @Produce json
+// @Param id path int true "Author ID"
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {object} entities.Author "Author"
+// @Failure 400 {object} mErrorResponse "Invalid author ID"
+// @Failure 404 {object} mErrorResponse "Author not found"
+// @Failure 500 {object} mErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /api/authors/{id} [get]
+func (a *AuthorController) GetAuthorHandler(resp Responder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := authorIDFromPath(resp, w, r)
+		if !ok {
+			return
+		}
+
+		author, err := a.facade.AuthorService.Get(r.Context(), id)
+		if !handleAuthorError(resp, w, err) {
+			return
+		}
+		resp.OutputJSON(w, author)
 	}
 }
 
-func (a *AuthorController) ListAuthorsHandler(resp Responder, library *Library) http.HandlerFunc {
+// @Summary Update an author
+// @Description Replaces the name, years and biography of an author. A new name is applied to all of the author's books.
+// @Tags Authors
+// @Accept json
+// @Produce json
+// @Param id path int true "Author ID"
+// @Param Authorization header string true "Bearer Token"
+// @Param author body AuthorRequest true "Author details"
+// @Success 200 {object} entities.Author "Updated author"
+// @Failure 400 {object} mErrorResponse "Invalid request"
+// @Failure 404 {object} mErrorResponse "Author not found"
+// @Failure 409 {object} mErrorResponse "Author already exists"
+// @Failure 500 {object} mErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /api/authors/{id} [put]
+func (a *AuthorController) UpdateAuthorHandler(resp Responder) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		library.mu.RLock()         // Блокируем чтение
-		defer library.mu.RUnlock() // Разблокируем чтение после завершения
+		id, ok := authorIDFromPath(resp, w, r)
+		if !ok {
+			return
+		}
 
-		authorsSet := make(map[string]struct{}) // Используем множество для уникальных авторов
+		var authorRequest AuthorRequest
+		if err := json.NewDecoder(r.Body).Decode(&authorRequest); err != nil {
+			resp.ErrorBadRequest(w, errors.New("invalid request body"))
+			return
+		}
 
-		// Проходим по всем книгам в библиотеке и собираем авторов
-		for _, books := range library.Books {
-			for _, book := range books {
-				authorsSet[book.Author] = struct{}{} // Добавляем автора в множество
+		author, err := a.facade.AuthorService.Update(r.Context(), authorRequest.author(id))
+		if !handleAuthorError(resp, w, err) {
+			return
+		}
+		resp.OutputJSON(w, author)
+	}
+}
+
+// @Summary Delete an author
+// @Description Deletes an author. An author with books is only deleted with cascade=true, which also deletes the books with their copies. Books that have ever been lent or are on hold block the deletion, so loan, fine and payment records are never deleted.
+// @Tags Authors
+// @Param id path int true "Author ID"
+// @Param cascade query bool false "Delete the author's books as well"
+// @Param Authorization header string true "Bearer Token"
+// @Success 204 "Author deleted"
+// @Failure 400 {object} mErrorResponse "Invalid author ID"
+// @Failure 404 {object} mErrorResponse "Author not found"
+// @Failure 409 {object} mErrorResponse "Author has books or the books are in use"
+// @Failure 500 {object} mErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /api/authors/{id} [delete]
+func (a *AuthorController) DeleteAuthorHandler(resp Responder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := authorIDFromPath(resp, w, r)
+		if !ok {
+			return
+		}
+
+		cascade := false
+		if value := r.URL.Query().Get("cascade"); value != "" {
+			var err error
+			if cascade, err = strconv.ParseBool(value); err != nil {
+				resp.ErrorBadRequest(w, errors.New("cascade must be true or false"))
+				return
 			}
 		}
 
-		// Преобразуем множество в срез
-		var authors []string
-		for author := range authorsSet {
-			authors = append(authors, author)
+		err := a.facade.AuthorService.Delete(r.Context(), id, cascade)
+		if errors.Is(err, repositories.ErrAuthorHasBooks) {
+			resp.ErrorConflict(w, errors.New("author has books, pass cascade=true to delete them as well"))
+			return
+		}
+		if !handleAuthorError(resp, w, err) {
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// @Summary List books of an author
+// @Tags Authors
+// @Produce json
+// @Param id path int true "Author ID"
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {array} entities.Book "Books of the author"
+// @Failure 400 {object} mErrorResponse "Invalid author ID"
+// @Failure 404 {object} mErrorResponse "Author not found"
+// @Failure 500 {object} mErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /api/authors/{id}/books [get]
+func (a *AuthorController) ListAuthorBooksHandler(resp Responder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := authorIDFromPath(resp, w, r)
+		if !ok {
+			return
 		}
 
-		resp.OutputJSON(w, authors) // Возвращаем список авторов в формате JSON
+		books, err := a.facade.AuthorService.Books(r.Context(), id)
+		if !handleAuthorError(resp, w, err) {
+			return
+		}
+		resp.OutputJSON(w, books)
+	}
+}
+
+func (req AuthorRequest) author(id int) entities.Author {
+	return entities.Author{ID: id, Name: req.Name, BirthYear: req.BirthYear, DeathYear: req.DeathYear, Bio: req.Bio}
+}
+
+func authorIDFromPath(resp Responder, w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id <= 0 {
+		resp.ErrorBadRequest(w, errors.New("invalid author id"))
+		return 0, false
+	}
+	return id, true
+}
+
+// handleAuthorError отвечает на ошибку сервиса подходящим статусом и сообщает, можно ли продолжать
+func handleAuthorError(resp Responder, w http.ResponseWriter, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, usecasesAuthor.ErrInvalidName), errors.Is(err, usecasesAuthor.ErrInvalidBio), errors.Is(err, usecasesAuthor.ErrInvalidYears):
+		resp.ErrorBadRequest(w, err)
+	case errors.Is(err, repositories.ErrAuthorNotFound):
+		resp.ErrorNotFound(w, err)
+	case errors.Is(err, repositories.ErrAuthorExists), errors.Is(err, repositories.ErrAuthorBooksInUse):
+		resp.ErrorConflict(w, err)
+	default:
+		resp.ErrorInternal(w, err)
 	}
+	return false
 }
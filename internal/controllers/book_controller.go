@@ -1,32 +1,58 @@
 package controllers
 
 import (
-	"context"
-	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/go-chi/chi"
-	"studentgit.kata.academy/Zhodaran/go-kata/internal/entities"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/usecases/usecasesBook"
 )
 
-// @Summary Get Geo Coordinates by Address
-// @Description This endpoint allows you to get geo coordinates by address.
-// @Tags User
+// @Summary Take a book
+// @Description Borrows a copy of the book for the authenticated user. The user_id in the body may only name the caller.
+// @Tags Loans
 // @Accept json
 // @Produce json
 // @Param index path int true "Book INDEX"
 // @Param Authorization header string true "Bearer Token"
-// @Param body body TakeBookRequest true "Request body"
+// @Param body body TakeBookRequest false "Request body"
 // @Success 200 {object} Response "Успешное выполнение"
 // @Failure 400 {object} mErrorResponse "Ошибка запроса"
+// @Failure 401 {object} mErrorResponse "Unauthorized"
+// @Failure 403 {object} mErrorResponse "Acting for another user, fines or active loans over the limit"
 // @Failure 500 {object} mErrorResponse "Ошибка подключения к серверу"
 // @Security BearerAuth
 // @Router /api/book/take/{index} [post]
-func (l *BookController) TakeBookHandler(resp Responder, db *sql.DB, Books *[]entities.Book, library *Library) http.HandlerFunc {
+func (l *BookController) TakeBookHandler(resp Responder) http.HandlerFunc {
+	return l.takeBook(resp, false)
+}
+
+// @Summary Take a book for a patron
+// @Description Staff-only variant that borrows a copy of the book on behalf of the patron given by user_id.
+// @Tags Loans
+// @Accept json
+// @Produce json
+// @Param index path int true "Book INDEX"
+// @Param Authorization header string true "Bearer Token"
+// @Param body body TakeBookRequest true "Request body"
+// @Success 200 {object} Response "Успешное выполнение"
+// @Failure 400 {object} mErrorResponse "Ошибка запроса"
+// @Failure 401 {object} mErrorResponse "Unauthorized"
+// @Failure 403 {object} mErrorResponse "Staff role required, fines or active loans over the limit"
+// @Failure 500 {object} mErrorResponse "Ошибка подключения к серверу"
+// @Security BearerAuth
+// @Router /api/staff/book/take/{index} [post]
+func (l *BookController) StaffTakeBookHandler(resp Responder) http.HandlerFunc {
+	return l.takeBook(resp, true)
+}
+
+func (l *BookController) takeBook(resp Responder, staff bool) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		indexStr := chi.URLParam(r, "index")
 		index, err := strconv.Atoi(indexStr)
@@ -35,70 +61,84 @@ func (l *BookController) TakeBookHandler(resp Responder, db *sql.DB, Books *[]en
 			return
 		}
 
-		// Обновление записи в таблице book
-		result, err := db.Exec("UPDATE book SET block = $1, take_count = take_count + 1 WHERE index = $2 AND block = $3", true, index, false)
-		if err != nil {
-			resp.ErrorInternal(w, err)
-			return
-		}
-
-		var bookFind entities.Book
-		found := false
-
-		// Поиск книги по индексу
-		for i, book := range *Books {
-			if index == book.Index {
-				bookFind = book
-				// Удаление книги из массива
-				*Books = append((*Books)[:i], (*Books)[i+1:]...)
-				found = true
-				break
+		var requestBody TakeBookRequest
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+				resp.ErrorBadRequest(w, errors.New("invalid request body"))
+				return
 			}
 		}
 
-		if !found {
-			http.Error(w, fmt.Sprintf("book with index %d not found", index), http.StatusNotFound)
+		userID, ok := actingUser(resp, w, r, requestBody.UserID, staff)
+		if !ok {
 			return
 		}
 
-		// Проверка, была ли книга успешно обновлена
-		if rowsAffected, err := result.RowsAffected(); err != nil || rowsAffected == 0 {
-			resp.ErrorBadRequest(w, errors.New("book not found or already taken"))
+		loan, err := l.facade.BookService.Take(r.Context(), index, userID, requestBody.Barcode)
+		switch {
+		case errors.Is(err, repositories.ErrFineLimit), errors.Is(err, repositories.ErrLoanLimit):
+			resp.ErrorForbidden(w, err)
 			return
-		}
-
-		var requestBody TakeBookRequest
-		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
-			resp.ErrorBadRequest(w, errors.New("invalid request body"))
+		case errors.Is(err, repositories.ErrBookNotFound):
+			resp.ErrorNotFound(w, fmt.Errorf("book with index %d not found", index))
 			return
-		}
-
-		// Проверка, был ли передан username
-		if requestBody.Username == "" {
-			http.Error(w, "Username is required", http.StatusBadRequest)
+		case errors.Is(err, repositories.ErrBookTaken):
+			resp.ErrorBadRequest(w, fmt.Errorf("no available copies, place a hold via /api/book/hold/%d", index))
+			return
+		case errors.Is(err, repositories.ErrCopyNotAvailable):
+			resp.ErrorBadRequest(w, fmt.Errorf("copy %s not found or not available", requestBody.Barcode))
+			return
+		case errors.Is(err, repositories.ErrAlreadyBorrowed):
+			resp.ErrorBadRequest(w, errors.New("user already has a copy of this book"))
+			return
+		case err != nil:
+			resp.ErrorInternal(w, err)
 			return
 		}
 
-		// Добавление книги к пользователю
-		library.Books[requestBody.Username] = append(library.Books[requestBody.Username], bookFind)
-		resp.OutputJSON(w, map[string]string{"message": "Book taken successfully"})
+		resp.OutputJSON(w, Response{Success: true, Message: "Book taken successfully", Data: loan})
 	}
 }
 
-// @Summary Get Geo Coordinates by Address
-// @Description This endpoint allows you to get geo coordinates by address.
-// @Tags User
+// @Summary Return a book
+// @Description Returns the copy borrowed by the authenticated user. The user_id in the body may only name the caller.
+// @Tags Loans
 // @Accept json
 // @Produce json
 // @Param index path int true "Book INDEX"
 // @Param Authorization header string true "Bearer Token"
-// @Param body body TakeBookRequest true "Request body"
+// @Param body body TakeBookRequest false "Request body"
 // @Success 200 {object} Response "Успешное выполнение"
 // @Failure 400 {object} mErrorResponse "Ошибка запроса"
+// @Failure 401 {object} mErrorResponse "Unauthorized"
+// @Failure 403 {object} mErrorResponse "Acting for another user"
 // @Failure 500 {object} mErrorResponse "Ошибка подключения к серверу"
 // @Security BearerAuth
 // @Router /api/book/return/{index} [delete]
-func (l *BookController) ReturnBook(resp Responder, db *sql.DB, Books *[]entities.Book, library *Library) http.HandlerFunc {
+func (l *BookController) ReturnBook(resp Responder) http.HandlerFunc {
+	return l.returnBook(resp, false)
+}
+
+// @Summary Return a book for a patron
+// @Description Staff-only variant that accepts the copy borrowed by the patron given by user_id.
+// @Tags Loans
+// @Accept json
+// @Produce json
+// @Param index path int true "Book INDEX"
+// @Param Authorization header string true "Bearer Token"
+// @Param body body TakeBookRequest true "Request body"
+// @Success 200 {object} Response "Успешное выполнение"
+// @Failure 400 {object} mErrorResponse "Ошибка запроса"
+// @Failure 401 {object} mErrorResponse "Unauthorized"
+// @Failure 403 {object} mErrorResponse "Staff role required"
+// @Failure 500 {object} mErrorResponse "Ошибка подключения к серверу"
+// @Security BearerAuth
+// @Router /api/staff/book/return/{index} [delete]
+func (l *BookController) StaffReturnBookHandler(resp Responder) http.HandlerFunc {
+	return l.returnBook(resp, true)
+}
+
+func (l *BookController) returnBook(resp Responder, staff bool) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		indexStr := chi.URLParam(r, "index")
 		index, err := strconv.Atoi(indexStr)
@@ -108,120 +148,142 @@ func (l *BookController) ReturnBook(resp Responder, db *sql.DB, Books *[]entitie
 		}
 
 		var requestBody TakeBookRequest
-		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
-			resp.ErrorBadRequest(w, errors.New("invalid request body"))
-			return
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+				resp.ErrorBadRequest(w, errors.New("invalid request body"))
+				return
+			}
 		}
 
-		if requestBody.Username == "" {
-			http.Error(w, "Username is required", http.StatusBadRequest)
+		userID, ok := actingUser(resp, w, r, requestBody.UserID, staff)
+		if !ok {
 			return
 		}
 
-		userBooks, userExists := library.Books[requestBody.Username]
-		if !userExists {
-			http.Error(w, "User has no books", http.StatusNotFound)
+		bookFind, err := l.facade.BookService.Return(r.Context(), index, userID)
+		switch {
+		case errors.Is(err, repositories.ErrBookNotFound), errors.Is(err, repositories.ErrLoanNotFound):
+			resp.ErrorNotFound(w, fmt.Errorf("book with index %d not found for user", index))
+			return
+		case err != nil:
+			resp.ErrorInternal(w, err)
 			return
 		}
 
-		found := false
-		var bookFind entities.Book
+		resp.OutputJSON(w, Response{Success: true, Message: "Book returned successfully", Data: bookFind})
+	}
+}
 
-		// Поиск книги у пользователя
-		for i, book := range userBooks {
-			if book.Index == index {
-				bookFind = book
-				library.Books[requestBody.Username] = append(userBooks[:i], userBooks[i+1:]...) // Удаляем книгу из списка пользователя
-				found = true
-				break
+// @Summary Renew a borrowed book
+// @Description Extends the due date of an active loan of the authenticated user unless the renewal limit is reached.
+// @Tags Loans
+// @Accept json
+// @Produce json
+// @Param index path int true "Book INDEX"
+// @Param Authorization header string true "Bearer Token"
+// @Param body body TakeBookRequest false "Request body"
+// @Success 200 {object} entities.Loan "Renewed loan"
+// @Failure 400 {object} mErrorResponse "Ошибка запроса"
+// @Failure 401 {object} mErrorResponse "Unauthorized"
+// @Failure 403 {object} mErrorResponse "Acting for another user"
+// @Failure 404 {object} mErrorResponse "Loan not found"
+// @Failure 500 {object} mErrorResponse "Ошибка подключения к серверу"
+// @Security BearerAuth
+// @Router /api/book/renew/{index} [post]
+func (l *BookController) RenewBookHandler(resp Responder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		indexStr := chi.URLParam(r, "index")
+		index, err := strconv.Atoi(indexStr)
+		if err != nil {
+			resp.ErrorBadRequest(w, errors.New("invalid index"))
+			return
+		}
+
+		var requestBody TakeBookRequest
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+				resp.ErrorBadRequest(w, errors.New("invalid request body"))
+				return
 			}
 		}
 
-		if !found {
-			http.Error(w, fmt.Sprintf("book with index %d not found for user", index), http.StatusNotFound)
+		userID, ok := actingUser(resp, w, r, requestBody.UserID, false)
+		if !ok {
 			return
 		}
 
-		// Обновление записи в таблице book
-		result, err := db.Exec("UPDATE book SET block = $1 WHERE index = $2 AND block = $3", false, index, true)
-		if err != nil {
-			resp.ErrorInternal(w, err)
+		loan, err := l.facade.BookService.Renew(r.Context(), index, userID)
+		switch {
+		case errors.Is(err, repositories.ErrLoanNotFound):
+			resp.ErrorNotFound(w, fmt.Errorf("book with index %d not found for user", index))
 			return
-		}
-		if rowsAffected, err := result.RowsAffected(); err != nil || rowsAffected == 0 {
-			resp.ErrorBadRequest(w, errors.New("book not found or already returned"))
+		case errors.Is(err, repositories.ErrRenewLimit):
+			resp.ErrorBadRequest(w, err)
+			return
+		case errors.Is(err, repositories.ErrBookOnHold):
+			resp.ErrorBadRequest(w, errors.New("book is on hold for other readers and cannot be renewed"))
+			return
+		case err != nil:
+			resp.ErrorInternal(w, err)
 			return
 		}
 
-		// Добавление книги обратно в общий список книг
-		*Books = append(*Books, bookFind) // Добавляем книгу обратно в общий список
-		resp.OutputJSON(w, map[string]string{"message": "Book returned successfully"})
+		resp.OutputJSON(w, loan)
 	}
 }
 
-// @Summary Обновление информации о книге
-// @Description Этот эндпоинт позволяет обновить информацию о книге по индексу.
+// @Summary Update a book
+// @Description Changes the title and author of a book.
 // @Tags Books
 // @Accept json
 // @Produce json
-// @Param index path int true "Индекс книги"
+// @Param index path int true "Book INDEX"
 // @Param Authorization header string true "Bearer Token"
-// @Param body body models.Book true "Обновленная информация о книге"
-// @Success 200 {object} models.Book "Успешное обновление книги"
-// @Failure 400 {object} mErrorResponse "Ошибка запроса"
-// @Failure 404 {object} mErrorResponse "Книга не найдена"
-// @Failure 500 {object} mErrorResponse "Ошибка сервера"
-// @Router /api/book/{index} [put]
-func (l *BookController) UpdateBook(resp Responder, db *sql.DB) http.HandlerFunc {
+// @Param body body AddaderBook true "New title and author"
+// @Success 200 {object} entities.Book "Updated book"
+// @Failure 400 {object} mErrorResponse "Invalid request"
+// @Failure 404 {object} mErrorResponse "Book not found"
+// @Failure 409 {object} mErrorResponse "Book already exists"
+// @Failure 500 {object} mErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /api/books/{index} [put]
+func (l *BookController) UpdateBook(resp Responder) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPut {
-			http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
-			return
-		}
-
-		indexStr := chi.URLParam(r, "index")
-		index, err := strconv.Atoi(indexStr)
+		index, err := strconv.Atoi(chi.URLParam(r, "index"))
 		if err != nil {
-			resp.ErrorBadRequest(w, errors.New("недопустимый индекс"))
+			resp.ErrorBadRequest(w, errors.New("invalid book index"))
 			return
 		}
 
-		var updatedBook entities.Book
-		if err := json.NewDecoder(r.Body).Decode(&updatedBook); err != nil {
-			resp.ErrorBadRequest(w, errors.New("недопустимый формат данных"))
-			return
-		}
-
-		// Обновление записи в таблице book
-		result, err := db.Exec("UPDATE book SET book = $1, author = $2, block = $3 WHERE index = $4",
-			updatedBook.Book, updatedBook.Author, updatedBook.Block, index)
-		if err != nil {
-			resp.ErrorInternal(w, err)
+		var requestBody AddaderBook
+		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+			resp.ErrorBadRequest(w, errors.New("invalid request body"))
 			return
 		}
 
-		// Проверка, была ли книга успешно обновлена
-		if rowsAffected, err := result.RowsAffected(); err != nil || rowsAffected == 0 {
-			resp.ErrorBadRequest(w, errors.New("книга не найдена или не обновлена"))
+		book, err := l.facade.BookService.Update(r.Context(), index, requestBody.Book, requestBody.Author)
+		if !handleBookError(resp, w, err) {
 			return
 		}
-
-		// Возвращаем обновленную книгу
-		resp.OutputJSON(w, updatedBook)
+		resp.OutputJSON(w, book)
 	}
 }
 
 // @Summary Add a new book to the library
-// @Description This endpoint allows you to add a new book to the library.
+// @Description Adds a book with the given number of copies. The index is assigned by the database.
 // @Tags Books
 // @Accept json
 // @Produce json
-// @Param book body repository.AddaderBook false "Book details"
-// @Success 201 {object} models.Book "Book added successfully"
+// @Param Authorization header string true "Bearer Token"
+// @Param book body AddaderBook true "Book details"
+// @Success 201 {object} entities.Book "Book added successfully"
+// @Header 201 {string} Location "URL of the created book"
 // @Failure 400 {object} mErrorResponse "Invalid request"
+// @Failure 409 {object} mErrorResponse "Book already exists"
 // @Failure 500 {object} mErrorResponse "Internal server error"
+// @Security BearerAuth
 // @Router /api/book [post]
-func (l *BookController) AddBookHandler(resp Responder, db *sql.DB, library *Library, Books *[]entities.Book) http.HandlerFunc {
+func (l *BookController) AddBookHandler(resp Responder) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var addaderBook AddaderBook
 		if err := json.NewDecoder(r.Body).Decode(&addaderBook); err != nil {
@@ -229,136 +291,145 @@ func (l *BookController) AddBookHandler(resp Responder, db *sql.DB, library *Lib
 			return
 		}
 
-		// Проверка на существование книги
-		var exists bool
-		err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM book WHERE book = $1 AND author = $2)", addaderBook.Book, addaderBook.Author).Scan(&exists)
-		if err != nil {
-			resp.ErrorInternal(w, err)
-			return
-		}
-		if exists {
-			resp.ErrorBadRequest(w, errors.New("book already exists"))
+		book, err := l.facade.BookService.Create(r.Context(), addaderBook.Book, addaderBook.Author, addaderBook.Copies)
+		if !handleBookError(resp, w, err) {
 			return
 		}
 
-		var newBook entities.Book
-		newBook.Book = addaderBook.Book
-		newBook.Author = addaderBook.Author
+		w.Header().Set("Location", fmt.Sprintf("/api/books/%d", book.Index))
+		w.Header().Set("Content-Type", "application/json;charset=utf-8")
+		w.WriteHeader(http.StatusCreated)
+		resp.OutputJSON(w, book)
+	}
+}
 
-		bloc := false
-		newBook.Block = &bloc
+// @Summary List books
+// @Description Returns a page of the catalog with copy counts. Pages are linked by cursor: pass next_cursor as after to get the following page.
+// @Tags Books
+// @Produce json
+// @Param Authorization header string true "Bearer Token"
+// @Param limit query int false "Page size, 1-100" default(20)
+// @Param after query string false "Cursor from the previous page"
+// @Param sort query string false "Comma-separated fields, prefix - for descending: index, book, author, take_count, available_copies, total_copies, available"
+// @Param author query string false "Exact author, case-insensitive. Use author~ for a substring match"
+// @Param book query string false "Exact title, case-insensitive. Use book~ for a substring match"
+// @Param available query bool false "Only books with (true) or without (false) free copies"
+// @Success 200 {object} BooksPage "Books"
+// @Failure 400 {object} mErrorResponse "Invalid list parameters"
+// @Failure 500 {object} mErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /api/books [get]
+func (l *BookController) ListBooks(resp Responder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		opts, err := parseListOptions(r)
+		if err != nil {
+			resp.ErrorBadRequest(w, err)
+			return
+		}
 
-		// Вставка новой книги в базу данных
-		_, err = db.Exec("INSERT INTO book (book, author, block) VALUES ($1, $2, $3)", newBook.Book, newBook.Author, newBook.Block)
+		page, err := l.facade.BookService.List(r.Context(), opts)
+		if errors.Is(err, repositories.ErrInvalidListOptions) {
+			resp.ErrorBadRequest(w, err)
+			return
+		}
 		if err != nil {
 			resp.ErrorInternal(w, err)
 			return
 		}
-		bookPtr := &Books
-
-		// Получаем последний элемент
-		lastElement := (**bookPtr)[len(**bookPtr)-1].Index
-		newBook.Index = lastElement + 1
-
-		library.AddBook(newBook)
-		*Books = append(*Books, newBook)
-		resp.OutputJSON(w, newBook) // Возвращаем добавленную книгу
+		resp.OutputJSON(w, BooksPage{
+			Items:      page.Items,
+			Total:      page.Total,
+			NextCursor: page.NextCursor,
+			Next:       nextPageLink(r, page.NextCursor),
+		})
 	}
 }
 
-// @Summary List SQL book
-// @Description This description created new SQL user
+// MaxSearchQueryLength ограничивает длину поисковой строки
+const MaxSearchQueryLength = 200
+
+// @Summary Search books
+// @Description Full-text search over titles and authors, ranked by relevance. Author names tolerate typos. Highlight is HTML-escaped text with matches wrapped in <mark>. Facets count all matches and ignore the author and available filters.
 // @Tags Books
-// @Accept json
 // @Produce json
-// @Success 200 {object} CreateResponse "List successful"
-// @Failure 400 {object} rErrorResponse "Invalid request"
-// @Failure 401 {object} rErrorResponse "Invalid credentials"
-// @Failure 500 {object} rErrorResponse "Internal server error"
-// @Router /api/books [get]
-func (uc *BookController) ListBooks(w http.ResponseWriter, r *http.Request) {
-	// Получаем список книг из базы данных
-	books, err := uc.getBooksFromDB()
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	// Устанавливаем заголовок Content-Type
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK) // Устанавливаем статус 200 OK
+// @Param Authorization header string true "Bearer Token"
+// @Param q query string true "Search text, supports quotes, or and -word"
+// @Param author query string false "Only this author, case-insensitive"
+// @Param available query bool false "Only books with (true) or without (false) free copies"
+// @Param limit query int false "Page size, 1-100" default(20)
+// @Param offset query int false "Number of hits to skip" default(0)
+// @Success 200 {object} entities.BookSearchResult "Hits and facets"
+// @Failure 400 {object} mErrorResponse "Invalid search parameters"
+// @Failure 500 {object} mErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /api/books/search [get]
+func (l *BookController) SearchBooks(resp Responder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		search, err := parseBookSearch(r)
+		if err != nil {
+			resp.ErrorBadRequest(w, err)
+			return
+		}
 
-	// Кодируем и отправляем список книг
-	if err := json.NewEncoder(w).Encode(books); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+		result, err := l.facade.BookService.Search(r.Context(), search)
+		if err != nil {
+			resp.ErrorInternal(w, err)
+			return
+		}
+		resp.OutputJSON(w, result)
 	}
 }
 
-func (uc *BookController) getBooksFromDB() ([]entities.Book, error) {
-	query := "SELECT index, book, author, block, take_count FROM book"
-	rows, err := uc.DB.QueryContext(context.Background(), query)
-	if err != nil {
-		return nil, err
+// handleBookError переводит ошибки изменения каталога в статус ответа. Возвращает true, если ошибки нет
+func handleBookError(resp Responder, w http.ResponseWriter, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, usecasesBook.ErrInvalidTitle), errors.Is(err, usecasesBook.ErrInvalidAuthor), errors.Is(err, usecasesBook.ErrInvalidCopies):
+		resp.ErrorBadRequest(w, err)
+	case errors.Is(err, repositories.ErrBookNotFound):
+		resp.ErrorNotFound(w, err)
+	case errors.Is(err, repositories.ErrBookExists):
+		resp.ErrorConflict(w, err)
+	default:
+		resp.ErrorInternal(w, err)
 	}
-	defer rows.Close()
+	return false
+}
 
-	var books []entities.Book
-	for rows.Next() {
-		var book entities.Book
-		if err := rows.Scan(&book.Index, &book.Book, &book.Author, &book.Block, &book.TakeCount); err != nil {
-			return nil, err
-		}
-		books = append(books, book)
+func parseBookSearch(r *http.Request) (repositories.BookSearch, error) {
+	query := r.URL.Query()
+	search := repositories.BookSearch{
+		Query:  strings.TrimSpace(query.Get("q")),
+		Author: query.Get("author"),
+		Limit:  repositories.DefaultListLimit,
 	}
-
-	if err := rows.Err(); err != nil {
-		return nil, err
+	if search.Query == "" {
+		return repositories.BookSearch{}, errors.New("q is required")
 	}
-
-	return books, nil
-}
-
-func (l *Library) AddBook(book entities.Book) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-
-	// Получаем список книг автора
-	booksByAuthor := l.Books[book.Author]
-
-	// Находим первый свободный индекс
-	newIndex := 1
-	for {
-		found := false
-		for _, b := range booksByAuthor {
-			if b.Index == newIndex {
-				found = true
-				break
-			}
-		}
-		if !found {
-			break
+	if utf8.RuneCountInString(search.Query) > MaxSearchQueryLength {
+		return repositories.BookSearch{}, fmt.Errorf("q must be at most %d characters", MaxSearchQueryLength)
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > repositories.MaxListLimit {
+			return repositories.BookSearch{}, fmt.Errorf("limit must be between 1 and %d", repositories.MaxListLimit)
 		}
-		newIndex++
+		search.Limit = limit
 	}
-
-	// Присваиваем книге новый индекс
-	book.Index = newIndex
-
-	// Добавляем книгу в список
-	l.Books[book.Author] = append(booksByAuthor, book)
-
-	// Добавляем автора в список, если его там еще нет
-	if !contains(l.Authors, book.Author) {
-		l.Authors = append(l.Authors, book.Author)
+	if value := query.Get("offset"); value != "" {
+		offset, err := strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			return repositories.BookSearch{}, errors.New("offset must be a non-negative integer")
+		}
+		search.Offset = offset
 	}
-}
-
-func contains(authors []string, author string) bool {
-	for _, a := range authors {
-		if a == author {
-			return true
+	if value := query.Get("available"); value != "" {
+		available, err := strconv.ParseBool(value)
+		if err != nil {
+			return repositories.BookSearch{}, errors.New("available must be true or false")
 		}
+		search.Available = &available
 	}
-	return false
+	return search, nil
 }
@@ -2,22 +2,21 @@ package controllers
 
 import (
 	"context"
-	"database/sql"
 	"encoding/json"
 	"errors"
 	"net/http"
-	"sync"
 
-	"github.com/go-chi/jwtauth"
 	"go.uber.org/zap"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/config"
 	"studentgit.kata.academy/Zhodaran/go-kata/internal/entities"
 	"studentgit.kata.academy/Zhodaran/go-kata/internal/facades"
-	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/infrastructure/limiter"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/infrastructure/mailer"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/infrastructure/tokens"
 )
 
 type BookController struct {
 	facade *facades.LibraryFacade
-	DB     *sql.DB
 }
 
 func NewBookController(facade *facades.LibraryFacade) *BookController {
@@ -25,11 +24,16 @@ func NewBookController(facade *facades.LibraryFacade) *BookController {
 }
 
 type AuthController struct {
-	facade *facades.LibraryFacade
+	facade  *facades.LibraryFacade
+	keys    *tokens.KeySet
+	guard   *limiter.Guard
+	mailer  mailer.Mailer
+	cfg     config.AuthConfig
+	mailCfg config.MailConfig
 }
 
-func NewAuthController(facade *facades.LibraryFacade) *AuthController {
-	return &AuthController{facade: facade}
+func NewAuthController(facade *facades.LibraryFacade, keys *tokens.KeySet, guard *limiter.Guard, mailer mailer.Mailer, cfg config.AuthConfig, mailCfg config.MailConfig) *AuthController {
+	return &AuthController{facade: facade, keys: keys, guard: guard, mailer: mailer, cfg: cfg, mailCfg: mailCfg}
 }
 
 type AuthorController struct {
@@ -40,10 +44,32 @@ func NewAuthorController(facade *facades.LibraryFacade) *AuthorController {
 	return &AuthorController{facade: facade}
 }
 
+type FineController struct {
+	facade *facades.LibraryFacade
+}
+
+func NewFineController(facade *facades.LibraryFacade) *FineController {
+	return &FineController{facade: facade}
+}
+
+type RoleController struct {
+	facade *facades.LibraryFacade
+}
+
+func NewRoleController(facade *facades.LibraryFacade) *RoleController {
+	return &RoleController{facade: facade}
+}
+
+type APIKeyController struct {
+	facade *facades.LibraryFacade
+}
+
+func NewAPIKeyController(facade *facades.LibraryFacade) *APIKeyController {
+	return &APIKeyController{facade: facade}
+}
+
 type UserController struct {
-	facade   *facades.LibraryFacade
-	UserRepo repositories.UserRepository
-	Db       *sql.DB
+	facade *facades.LibraryFacade
 }
 
 func NewUserController(facade *facades.LibraryFacade) *UserController {
@@ -58,24 +84,14 @@ func NewResponder(logger *zap.Logger) Responder {
 	return &Respond{log: logger}
 }
 
-type Library struct {
-	Books   map[string][]entities.Book
-	Authors []string
-	mu      sync.RWMutex
-}
-
-func NewLibrary() *Library {
-	return &Library{
-		Books: make(map[string][]entities.Book),
-	}
-}
-
 type Responder interface {
 	OutputJSON(w http.ResponseWriter, responseData interface{})
 
 	ErrorUnauthorized(w http.ResponseWriter, err error)
 	ErrorBadRequest(w http.ResponseWriter, err error)
 	ErrorForbidden(w http.ResponseWriter, err error)
+	ErrorNotFound(w http.ResponseWriter, err error)
+	ErrorConflict(w http.ResponseWriter, err error)
 	ErrorInternal(w http.ResponseWriter, err error)
 }
 
@@ -112,6 +128,32 @@ func (r *Respond) ErrorForbidden(w http.ResponseWriter, err error) {
 	}
 }
 
+func (r *Respond) ErrorNotFound(w http.ResponseWriter, err error) {
+	r.log.Info("http response not found", zap.Error(err))
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	w.WriteHeader(http.StatusNotFound)
+	if err := json.NewEncoder(w).Encode(Response{
+		Success: false,
+		Message: err.Error(),
+		Data:    nil,
+	}); err != nil {
+		r.log.Error("response writer error on write", zap.Error(err))
+	}
+}
+
+func (r *Respond) ErrorConflict(w http.ResponseWriter, err error) {
+	r.log.Info("http response conflict", zap.Error(err))
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	w.WriteHeader(http.StatusConflict)
+	if err := json.NewEncoder(w).Encode(Response{
+		Success: false,
+		Message: err.Error(),
+		Data:    nil,
+	}); err != nil {
+		r.log.Error("response writer error on write", zap.Error(err))
+	}
+}
+
 func (r *Respond) ErrorUnauthorized(w http.ResponseWriter, err error) {
 	r.log.Warn("http resposne Unauthorized", zap.Error(err))
 	w.Header().Set("Content-Type", "application/json;charset=utf-8")
@@ -153,30 +195,111 @@ type mErrorResponse struct {
 	SuccefulRequest string `json:"200"`
 }
 
-var (
-	TokenAuth = jwtauth.New("HS256", []byte("your_secret_key"), nil)
-	Users     = make(map[string]entities.UserAuth) // Хранение пользователей
-	mu        sync.Mutex
-)
-
 type TokenResponse struct {
-	Token string `json:"token"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	ExpiresIn    int64  `json:"expires_in,omitempty"` // Срок жизни access-токена в секундах
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type UserRequest struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
 }
 
 type AuthorRequest struct {
-	Name string `json:"name"`
+	Name      string `json:"name"`
+	BirthYear *int   `json:"birth_year,omitempty"`
+	DeathYear *int   `json:"death_year,omitempty"`
+	Bio       string `json:"bio,omitempty"`
 }
 
 type TakeBookRequest struct {
-	Username string `json:"username"` // Поле для имени пользователя
+	UserID  int    `json:"user_id,omitempty"` // Читатель; на обычных маршрутах только сам пользователь из токена
+	Barcode string `json:"barcode,omitempty"` // Конкретный экземпляр, если нужен
 }
 
 type AddaderBook struct {
 	Book   string `json:"book"`
 	Author string `json:"author"`
+	Copies int    `json:"copies"` // Количество экземпляров, по умолчанию 1
+}
+
+type AddCopyRequest struct {
+	Barcode string `json:"barcode"` // Если пусто, формируется автоматически
 }
 
 type CreateResponse struct {
 	Message string          `json:"message"`
 	Books   []entities.Book `json:"books"` // Добавляем поле для списка книг
 }
+
+// BooksPage страница каталога. Next пуст на последней странице
+type BooksPage struct {
+	Items      []entities.Book `json:"items"`
+	Total      int             `json:"total"` // Число книг, подходящих под фильтры
+	NextCursor string          `json:"next_cursor,omitempty"`
+	Next       string          `json:"next,omitempty"` // Ссылка на следующую страницу
+}
+
+type UsersPage struct {
+	Items      []entities.User `json:"items"`
+	Total      int             `json:"total"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+	Next       string          `json:"next,omitempty"`
+}
+
+type LoansResponse struct {
+	Current []entities.Loan `json:"current"` // Книги, которые сейчас на руках
+	Past    []entities.Loan `json:"past"`    // Возвращенные книги
+}
+
+type FinesResponse struct {
+	Fines   []entities.Fine `json:"fines"`
+	Balance int64           `json:"balance"` // Неоплаченный остаток в копейках
+}
+
+type PayFineRequest struct {
+	Amount int64  `json:"amount"` // Сумма оплаты в копейках
+	Note   string `json:"note"`
+}
+
+type WaiveFineRequest struct {
+	Note string `json:"note"`
+}
+
+type RoleRequest struct {
+	Role string `json:"role"`
+}
+
+type RolesResponse struct {
+	Username string   `json:"username"`
+	Roles    []string `json:"roles"`
+}
+
+type APIKeyRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+// APIKeyResponse содержит ключ целиком. Повторно получить его нельзя
+type APIKeyResponse struct {
+	entities.APIKey
+	Key string `json:"key"`
+}
+
+type ForgotPasswordRequest struct {
+	Login string `json:"login"` // Имя пользователя или почта
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
@@ -0,0 +1,94 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+)
+
+// @Summary Add a copy of a book
+// @Description Registers a new physical copy. The barcode is generated when omitted.
+// @Tags Books
+// @Accept json
+// @Produce json
+// @Param index path int true "Book INDEX"
+// @Param Authorization header string true "Bearer Token"
+// @Param body body AddCopyRequest false "Copy details"
+// @Success 200 {object} entities.Copy "Copy added"
+// @Failure 400 {object} mErrorResponse "Invalid request"
+// @Failure 404 {object} mErrorResponse "Book not found"
+// @Failure 409 {object} mErrorResponse "Barcode already exists"
+// @Failure 500 {object} mErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /api/book/{index}/copies [post]
+func (l *BookController) AddCopyHandler(resp Responder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		index, err := strconv.Atoi(chi.URLParam(r, "index"))
+		if err != nil {
+			resp.ErrorBadRequest(w, errors.New("invalid index"))
+			return
+		}
+
+		var requestBody AddCopyRequest
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+				resp.ErrorBadRequest(w, errors.New("invalid request body"))
+				return
+			}
+		}
+
+		item, err := l.facade.BookService.AddCopy(r.Context(), index, requestBody.Barcode)
+		switch {
+		case errors.Is(err, repositories.ErrBookNotFound):
+			resp.ErrorNotFound(w, fmt.Errorf("book with index %d not found", index))
+			return
+		case errors.Is(err, repositories.ErrBarcodeExists):
+			resp.ErrorConflict(w, errors.New("barcode already exists"))
+			return
+		case err != nil:
+			resp.ErrorInternal(w, err)
+			return
+		}
+
+		resp.OutputJSON(w, item)
+	}
+}
+
+// @Summary List copies of a book
+// @Description Returns all physical copies of a book with their status.
+// @Tags Books
+// @Produce json
+// @Param index path int true "Book INDEX"
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {array} entities.Copy "Copies"
+// @Failure 400 {object} mErrorResponse "Invalid request"
+// @Failure 404 {object} mErrorResponse "Book not found"
+// @Failure 500 {object} mErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /api/book/{index}/copies [get]
+func (l *BookController) ListCopiesHandler(resp Responder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		index, err := strconv.Atoi(chi.URLParam(r, "index"))
+		if err != nil {
+			resp.ErrorBadRequest(w, errors.New("invalid index"))
+			return
+		}
+
+		copies, err := l.facade.BookService.Copies(r.Context(), index)
+		switch {
+		case errors.Is(err, repositories.ErrBookNotFound):
+			resp.ErrorNotFound(w, fmt.Errorf("book with index %d not found", index))
+			return
+		case err != nil:
+			resp.ErrorInternal(w, err)
+			return
+		}
+
+		resp.OutputJSON(w, copies)
+	}
+}
@@ -0,0 +1,131 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+)
+
+// @Summary List user fines
+// @Description Returns all fines of a user and the outstanding balance in minor units.
+// @Tags Fines
+// @Produce json
+// @Param user_id path int true "User ID"
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {object} FinesResponse "Fines of the user"
+// @Failure 400 {object} mErrorResponse "Invalid user ID"
+// @Failure 403 {object} mErrorResponse "Records of another user"
+// @Failure 500 {object} mErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /api/fines/{user_id} [get]
+func (f *FineController) ListFinesHandler(resp Responder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := userIDParam(resp, w, r)
+		if !ok {
+			return
+		}
+
+		fines, balance, err := f.facade.FineService.List(r.Context(), userID)
+		if err != nil {
+			resp.ErrorInternal(w, err)
+			return
+		}
+		resp.OutputJSON(w, FinesResponse{Fines: fines, Balance: balance})
+	}
+}
+
+// @Summary Pay a fine
+// @Description Records a manual payment. The fine is closed once paid in full.
+// @Tags Fines
+// @Accept json
+// @Produce json
+// @Param id path int true "Fine ID"
+// @Param Authorization header string true "Bearer Token"
+// @Param body body PayFineRequest true "Payment"
+// @Success 200 {object} entities.Fine "Updated fine"
+// @Failure 400 {object} mErrorResponse "Invalid request"
+// @Failure 404 {object} mErrorResponse "Fine not found"
+// @Failure 500 {object} mErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /api/fines/{id}/pay [post]
+func (f *FineController) PayFineHandler(resp Responder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			resp.ErrorBadRequest(w, errors.New("invalid fine id"))
+			return
+		}
+
+		var requestBody PayFineRequest
+		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+			resp.ErrorBadRequest(w, errors.New("invalid request body"))
+			return
+		}
+
+		fine, err := f.facade.FineService.Pay(r.Context(), id, requestBody.Amount, requestBody.Note)
+		if !writeFineError(resp, w, id, err) {
+			return
+		}
+		resp.OutputJSON(w, fine)
+	}
+}
+
+// @Summary Waive a fine
+// @Description Writes off the unpaid remainder of a fine.
+// @Tags Fines
+// @Accept json
+// @Produce json
+// @Param id path int true "Fine ID"
+// @Param Authorization header string true "Bearer Token"
+// @Param body body WaiveFineRequest false "Reason"
+// @Success 200 {object} entities.Fine "Updated fine"
+// @Failure 400 {object} mErrorResponse "Invalid request"
+// @Failure 404 {object} mErrorResponse "Fine not found"
+// @Failure 500 {object} mErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /api/fines/{id}/waive [post]
+func (f *FineController) WaiveFineHandler(resp Responder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			resp.ErrorBadRequest(w, errors.New("invalid fine id"))
+			return
+		}
+
+		var requestBody WaiveFineRequest
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+				resp.ErrorBadRequest(w, errors.New("invalid request body"))
+				return
+			}
+		}
+
+		fine, err := f.facade.FineService.Waive(r.Context(), id, requestBody.Note)
+		if !writeFineError(resp, w, id, err) {
+			return
+		}
+		resp.OutputJSON(w, fine)
+	}
+}
+
+// writeFineError отвечает клиенту по ошибке репозитория штрафов и возвращает true, если ошибки нет
+func writeFineError(resp Responder, w http.ResponseWriter, id int, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, repositories.ErrFineNotFound):
+		resp.ErrorNotFound(w, fmt.Errorf("fine with id %d not found", id))
+	case errors.Is(err, repositories.ErrFineClosed):
+		resp.ErrorBadRequest(w, err)
+	case errors.Is(err, repositories.ErrInvalidAmount):
+		resp.ErrorBadRequest(w, errors.New("amount must be positive and not exceed the unpaid remainder"))
+	default:
+		resp.ErrorInternal(w, err)
+	}
+	return false
+}
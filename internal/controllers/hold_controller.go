@@ -0,0 +1,166 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/entities"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+)
+
+// @Summary Place a hold on a taken book
+// @Description Puts the authenticated user into the FIFO queue for a book that is currently taken.
+// @Tags Holds
+// @Accept json
+// @Produce json
+// @Param index path int true "Book INDEX"
+// @Param Authorization header string true "Bearer Token"
+// @Param body body TakeBookRequest false "Request body"
+// @Success 200 {object} entities.Hold "Hold with queue position"
+// @Failure 400 {object} mErrorResponse "Invalid request"
+// @Failure 403 {object} mErrorResponse "Acting for another user"
+// @Failure 404 {object} mErrorResponse "Book not found"
+// @Failure 409 {object} mErrorResponse "Hold already placed"
+// @Failure 500 {object} mErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /api/book/hold/{index} [post]
+func (l *BookController) PlaceHoldHandler(resp Responder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		index, userID, ok := decodeHoldRequest(resp, w, r)
+		if !ok {
+			return
+		}
+
+		hold, err := l.facade.BookService.PlaceHold(r.Context(), index, userID)
+		switch {
+		case errors.Is(err, repositories.ErrBookNotFound):
+			resp.ErrorNotFound(w, fmt.Errorf("book with index %d not found", index))
+			return
+		case errors.Is(err, repositories.ErrBookAvailable):
+			resp.ErrorBadRequest(w, errors.New("book is available, take it instead"))
+			return
+		case errors.Is(err, repositories.ErrHoldExists):
+			resp.ErrorConflict(w, errors.New("hold already placed or book already taken by user"))
+			return
+		case err != nil:
+			resp.ErrorInternal(w, err)
+			return
+		}
+
+		resp.OutputJSON(w, hold)
+	}
+}
+
+// @Summary Cancel a hold
+// @Description Removes the authenticated user from the queue. A book already set aside for the user passes to the next in line.
+// @Tags Holds
+// @Accept json
+// @Produce json
+// @Param index path int true "Book INDEX"
+// @Param Authorization header string true "Bearer Token"
+// @Param body body TakeBookRequest false "Request body"
+// @Success 200 {object} Response "Hold cancelled"
+// @Failure 400 {object} mErrorResponse "Invalid request"
+// @Failure 403 {object} mErrorResponse "Acting for another user"
+// @Failure 404 {object} mErrorResponse "Hold not found"
+// @Failure 500 {object} mErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /api/book/hold/{index} [delete]
+func (l *BookController) CancelHoldHandler(resp Responder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		index, userID, ok := decodeHoldRequest(resp, w, r)
+		if !ok {
+			return
+		}
+
+		err := l.facade.BookService.CancelHold(r.Context(), index, userID)
+		switch {
+		case errors.Is(err, repositories.ErrBookNotFound), errors.Is(err, repositories.ErrHoldNotFound):
+			resp.ErrorNotFound(w, fmt.Errorf("hold on book with index %d not found for user", index))
+			return
+		case err != nil:
+			resp.ErrorInternal(w, err)
+			return
+		}
+
+		resp.OutputJSON(w, map[string]string{"message": "Hold cancelled successfully"})
+	}
+}
+
+// @Summary List user holds
+// @Description Returns the holds of a user with their queue positions.
+// @Tags Holds
+// @Produce json
+// @Param user_id path int true "User ID"
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {array} entities.Hold "Holds of the user"
+// @Failure 400 {object} mErrorResponse "Invalid user ID"
+// @Failure 403 {object} mErrorResponse "Records of another user"
+// @Failure 500 {object} mErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /api/holds/{user_id} [get]
+func (l *BookController) ListHoldsHandler(resp Responder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := userIDParam(resp, w, r)
+		if !ok {
+			return
+		}
+
+		holds, err := l.facade.BookService.Holds(r.Context(), userID)
+		if err != nil {
+			resp.ErrorInternal(w, err)
+			return
+		}
+		resp.OutputJSON(w, holds)
+	}
+}
+
+func decodeHoldRequest(resp Responder, w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	index, err := strconv.Atoi(chi.URLParam(r, "index"))
+	if err != nil {
+		resp.ErrorBadRequest(w, errors.New("invalid index"))
+		return 0, 0, false
+	}
+
+	var requestBody TakeBookRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+			resp.ErrorBadRequest(w, errors.New("invalid request body"))
+			return 0, 0, false
+		}
+	}
+
+	userID, ok := actingUser(resp, w, r, requestBody.UserID, false)
+	if !ok {
+		return 0, 0, false
+	}
+	return index, userID, true
+}
+
+// userIDParam разбирает {user_id} из пути. Читатель видит только свои данные,
+// чужие доступны сотрудникам, которые выдают книги или ведут штрафы
+func userIDParam(resp Responder, w http.ResponseWriter, r *http.Request) (int, bool) {
+	userID, err := strconv.Atoi(chi.URLParam(r, "user_id"))
+	if err != nil || userID <= 0 {
+		resp.ErrorBadRequest(w, errors.New("invalid user id"))
+		return 0, false
+	}
+
+	if HasPermission(r.Context(), entities.PermLoansStaff) || HasPermission(r.Context(), entities.PermFinesManage) {
+		return userID, true
+	}
+	callerID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		resp.ErrorUnauthorized(w, errors.New("missing authenticated user"))
+		return 0, false
+	}
+	if callerID != userID {
+		resp.ErrorForbidden(w, errors.New("cannot view another user's records"))
+		return 0, false
+	}
+	return userID, true
+}
@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+)
+
+// parseListOptions разбирает параметры списка: limit, after, sort=-take_count,author
+// и фильтры вида author=Tolkien, available=true, name~=ann. Проверку имен полей
+// выполняет репозиторий, здесь только синтаксис
+func parseListOptions(r *http.Request) (repositories.ListOptions, error) {
+	query := r.URL.Query()
+	var opts repositories.ListOptions
+
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		values := query[key]
+		switch key {
+		case "limit":
+			limit, err := strconv.Atoi(values[0])
+			if err != nil || len(values) > 1 {
+				return repositories.ListOptions{}, fmt.Errorf("%w: limit must be a single integer", repositories.ErrInvalidListOptions)
+			}
+			if limit < 1 || limit > repositories.MaxListLimit {
+				return repositories.ListOptions{}, fmt.Errorf("%w: limit must be between 1 and %d", repositories.ErrInvalidListOptions, repositories.MaxListLimit)
+			}
+			opts.Limit = limit
+		case "after":
+			if len(values) > 1 {
+				return repositories.ListOptions{}, fmt.Errorf("%w: after must be given once", repositories.ErrInvalidListOptions)
+			}
+			opts.After = values[0]
+		case "sort":
+			for _, value := range values {
+				for _, field := range strings.Split(value, ",") {
+					desc := strings.HasPrefix(field, "-")
+					field = strings.TrimPrefix(field, "-")
+					if field == "" {
+						return repositories.ListOptions{}, fmt.Errorf("%w: empty field in sort", repositories.ErrInvalidListOptions)
+					}
+					opts.Sort = append(opts.Sort, repositories.SortField{Field: field, Desc: desc})
+				}
+			}
+		default:
+			// name~=ann приходит как ключ "name~" со значением "ann"
+			op := repositories.FilterEquals
+			field := key
+			if strings.HasSuffix(key, "~") {
+				op = repositories.FilterContains
+				field = strings.TrimSuffix(key, "~")
+			}
+			for _, value := range values {
+				opts.Filters = append(opts.Filters, repositories.Filter{Field: field, Op: op, Value: value})
+			}
+		}
+	}
+	return opts, nil
+}
+
+// nextPageLink повторяет запрос с курсором следующей страницы. Пустой курсор означает последнюю страницу
+func nextPageLink(r *http.Request, cursor string) string {
+	if cursor == "" {
+		return ""
+	}
+	query := r.URL.Query()
+	query.Set("after", cursor)
+	return r.URL.Path + "?" + query.Encode()
+}
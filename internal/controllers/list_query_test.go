@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+)
+
+func TestParseListOptions(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/books?limit=5&after=abc&sort=-take_count,author&author=Tolkien&available=true&book~=ring", nil)
+	opts, err := parseListOptions(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := repositories.ListOptions{
+		Limit: 5,
+		After: "abc",
+		Sort:  []repositories.SortField{{Field: "take_count", Desc: true}, {Field: "author"}},
+		Filters: []repositories.Filter{
+			{Field: "author", Op: repositories.FilterEquals, Value: "Tolkien"},
+			{Field: "available", Op: repositories.FilterEquals, Value: "true"},
+			{Field: "book", Op: repositories.FilterContains, Value: "ring"},
+		},
+	}
+	if !reflect.DeepEqual(opts, want) {
+		t.Fatalf("options = %+v, want %+v", opts, want)
+	}
+}
+
+func TestParseListOptionsInvalid(t *testing.T) {
+	for _, query := range []string{"limit=abc", "limit=0", "limit=101", "limit=1&limit=2", "sort=author,", "sort=-", "after=a&after=b"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/users?"+query, nil)
+		if _, err := parseListOptions(req); !errors.Is(err, repositories.ErrInvalidListOptions) {
+			t.Errorf("%s: error %v, want ErrInvalidListOptions", query, err)
+		}
+	}
+}
+
+func TestNextPageLink(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/books?limit=2&after=old&author~=tol", nil)
+	if got, want := nextPageLink(req, "new"), "/api/books?after=new&author~=tol&limit=2"; got != want {
+		t.Fatalf("next = %q, want %q", got, want)
+	}
+	if got := nextPageLink(req, ""); got != "" {
+		t.Fatalf("last page next = %q, want empty", got)
+	}
+}
+
+func TestParseBookSearch(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/books/search?q=+hobbit+&available=false&limit=5&offset=10", nil)
+	search, err := parseBookSearch(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if search.Query != "hobbit" || search.Limit != 5 || search.Offset != 10 || search.Available == nil || *search.Available {
+		t.Fatalf("search = %+v", search)
+	}
+
+	long := strings.Repeat("a", MaxSearchQueryLength+1)
+	for _, query := range []string{"", "q=+", "q=" + long, "q=x&limit=0", "q=x&offset=-1", "q=x&available=maybe"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/books/search?"+query, nil)
+		if _, err := parseBookSearch(req); err == nil {
+			t.Errorf("%q: expected an error", query)
+		}
+	}
+}
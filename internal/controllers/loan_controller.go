@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"net/http"
+
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/entities"
+)
+
+// @Summary List user loans
+// @Description Returns the books a user currently holds and the loans already returned.
+// @Tags Loans
+// @Produce json
+// @Param user_id path int true "User ID"
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {object} LoansResponse "Loans of the user"
+// @Failure 400 {object} mErrorResponse "Invalid request"
+// @Failure 403 {object} mErrorResponse "Records of another user"
+// @Failure 500 {object} mErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /api/loans/{user_id} [get]
+func (l *BookController) ListLoansHandler(resp Responder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := userIDParam(resp, w, r)
+		if !ok {
+			return
+		}
+
+		loans, err := l.facade.BookService.Loans(r.Context(), userID)
+		if err != nil {
+			resp.ErrorInternal(w, err)
+			return
+		}
+
+		response := LoansResponse{
+			Current: []entities.Loan{},
+			Past:    []entities.Loan{},
+		}
+		for _, loan := range loans {
+			if loan.ReturnedAt == nil {
+				response.Current = append(response.Current, loan)
+			} else {
+				response.Past = append(response.Past, loan)
+			}
+		}
+
+		resp.OutputJSON(w, response)
+	}
+}
@@ -0,0 +1,135 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/entities"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+)
+
+// @Summary List user roles
+// @Description Returns the roles of a user. Every user has the patron role.
+// @Tags Admin
+// @Produce json
+// @Param username path string true "Username"
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {object} RolesResponse "Roles of the user"
+// @Failure 403 {object} mErrorResponse "Permission users:admin required"
+// @Failure 404 {object} mErrorResponse "User not found"
+// @Failure 500 {object} mErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /api/admin/users/{username}/roles [get]
+func (rc *RoleController) ListRolesHandler(resp Responder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username := chi.URLParam(r, "username")
+		roles, err := rc.facade.AuthService.Roles(r.Context(), username)
+		if errors.Is(err, repositories.ErrUserNotFound) {
+			resp.ErrorNotFound(w, fmt.Errorf("user %s not found", username))
+			return
+		}
+		if err != nil {
+			resp.ErrorInternal(w, err)
+			return
+		}
+		resp.OutputJSON(w, RolesResponse{Username: username, Roles: roles})
+	}
+}
+
+// @Summary Grant a role
+// @Description Grants the librarian or admin role to a user. Takes effect on the next login.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param username path string true "Username"
+// @Param Authorization header string true "Bearer Token"
+// @Param body body RoleRequest true "Role"
+// @Success 200 {object} RolesResponse "Roles of the user"
+// @Failure 400 {object} mErrorResponse "Unknown role"
+// @Failure 403 {object} mErrorResponse "Permission users:admin required"
+// @Failure 404 {object} mErrorResponse "User not found"
+// @Failure 500 {object} mErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /api/admin/users/{username}/roles [post]
+func (rc *RoleController) GrantRoleHandler(resp Responder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username := chi.URLParam(r, "username")
+
+		var requestBody RoleRequest
+		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+			resp.ErrorBadRequest(w, errors.New("invalid request body"))
+			return
+		}
+		if !grantableRole(requestBody.Role) {
+			resp.ErrorBadRequest(w, fmt.Errorf("unknown role %q", requestBody.Role))
+			return
+		}
+
+		err := rc.facade.AuthService.GrantRole(r.Context(), username, requestBody.Role)
+		if errors.Is(err, repositories.ErrUserNotFound) {
+			resp.ErrorNotFound(w, fmt.Errorf("user %s not found", username))
+			return
+		}
+		if err != nil {
+			resp.ErrorInternal(w, err)
+			return
+		}
+
+		roles, err := rc.facade.AuthService.Roles(r.Context(), username)
+		if err != nil {
+			resp.ErrorInternal(w, err)
+			return
+		}
+		resp.OutputJSON(w, RolesResponse{Username: username, Roles: roles})
+	}
+}
+
+// @Summary Revoke a role
+// @Description Revokes a previously granted role. Takes effect on the next login.
+// @Tags Admin
+// @Produce json
+// @Param username path string true "Username"
+// @Param role path string true "Role"
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {object} RolesResponse "Roles of the user"
+// @Failure 400 {object} mErrorResponse "Unknown role"
+// @Failure 403 {object} mErrorResponse "Permission users:admin required"
+// @Failure 404 {object} mErrorResponse "Role not granted"
+// @Failure 500 {object} mErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /api/admin/users/{username}/roles/{role} [delete]
+func (rc *RoleController) RevokeRoleHandler(resp Responder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username := chi.URLParam(r, "username")
+		role := chi.URLParam(r, "role")
+		if !grantableRole(role) {
+			resp.ErrorBadRequest(w, fmt.Errorf("unknown role %q", role))
+			return
+		}
+
+		err := rc.facade.AuthService.RevokeRole(r.Context(), username, role)
+		if errors.Is(err, repositories.ErrRoleNotGranted) {
+			resp.ErrorNotFound(w, fmt.Errorf("user %s has no role %s", username, role))
+			return
+		}
+		if err != nil {
+			resp.ErrorInternal(w, err)
+			return
+		}
+
+		roles, err := rc.facade.AuthService.Roles(r.Context(), username)
+		if err != nil {
+			resp.ErrorInternal(w, err)
+			return
+		}
+		resp.OutputJSON(w, RolesResponse{Username: username, Roles: roles})
+	}
+}
+
+// grantableRole роль patron есть у всех, поэтому выдавать и отзывать ее нельзя
+func grantableRole(role string) bool {
+	return role != entities.RolePatron && entities.IsValidRole(role)
+}
@@ -0,0 +1,179 @@
+package controllers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/jwtauth"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+)
+
+// @Summary Refresh tokens
+// @Description Exchanges a refresh token for a new access and refresh token pair. A refresh token can be used once; reusing it revokes the whole session.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body RefreshRequest true "Refresh token"
+// @Success 200 {object} TokenResponse "New token pair"
+// @Failure 400 {object} mErrorResponse "Invalid request"
+// @Failure 401 {object} mErrorResponse "Invalid, expired or reused refresh token"
+// @Failure 500 {object} mErrorResponse "Internal server error"
+// @Router /api/token/refresh [post]
+func (s *AuthController) RefreshHandler(resp Responder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var requestBody RefreshRequest
+		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil || requestBody.RefreshToken == "" {
+			resp.ErrorBadRequest(w, errors.New("refresh_token is required"))
+			return
+		}
+
+		refreshToken, err := newRefreshToken()
+		if err != nil {
+			resp.ErrorInternal(w, err)
+			return
+		}
+
+		username, familyID, err := s.facade.AuthService.Refresh(r.Context(), requestBody.RefreshToken, refreshToken, time.Now().Add(s.cfg.RefreshTTL))
+		if errors.Is(err, repositories.ErrTokenInvalid) || errors.Is(err, repositories.ErrTokenReused) {
+			resp.ErrorUnauthorized(w, err)
+			return
+		}
+		if err != nil {
+			resp.ErrorInternal(w, err)
+			return
+		}
+
+		tokens, err := s.issueAccessToken(r.Context(), username, familyID)
+		if err != nil {
+			resp.ErrorInternal(w, err)
+			return
+		}
+		tokens.RefreshToken = refreshToken
+		resp.OutputJSON(w, tokens)
+	}
+}
+
+// @Summary Log out
+// @Description Revokes the current access token and every refresh token of the session.
+// @Tags Auth
+// @Produce json
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {object} Response "Logged out"
+// @Failure 401 {object} mErrorResponse "Unauthorized"
+// @Failure 500 {object} mErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /api/logout [post]
+func (s *AuthController) LogoutHandler(resp Responder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, _, err := jwtauth.FromContext(r.Context())
+		if err != nil || token == nil {
+			resp.ErrorUnauthorized(w, errors.New("missing authenticated user"))
+			return
+		}
+
+		value, _ := token.Get("fid")
+		familyID, _ := value.(string)
+		if err := s.facade.AuthService.Logout(r.Context(), token.JwtID(), token.Expiration(), familyID); err != nil {
+			resp.ErrorInternal(w, err)
+			return
+		}
+
+		resp.OutputJSON(w, Response{Success: true, Message: "Logged out"})
+	}
+}
+
+// @Summary Public signing keys
+// @Description Returns the public keys that verify access tokens in JWKS format. Symmetric keys are never published.
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} object "JWK set"
+// @Failure 500 {object} mErrorResponse "Internal server error"
+// @Router /.well-known/jwks.json [get]
+func (s *AuthController) JWKSHandler(resp Responder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		set, err := s.keys.PublicKeys()
+		if err != nil {
+			resp.ErrorInternal(w, err)
+			return
+		}
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		resp.OutputJSON(w, set)
+	}
+}
+
+// startSession открывает новое семейство refresh-токенов и выдает первую пару токенов
+func (s *AuthController) startSession(ctx context.Context, username string) (TokenResponse, error) {
+	familyID, err := newTokenID()
+	if err != nil {
+		return TokenResponse{}, err
+	}
+	refreshToken, err := newRefreshToken()
+	if err != nil {
+		return TokenResponse{}, err
+	}
+
+	if err := s.facade.AuthService.StartSession(ctx, username, familyID, refreshToken, time.Now().Add(s.cfg.RefreshTTL)); err != nil {
+		return TokenResponse{}, err
+	}
+
+	tokens, err := s.issueAccessToken(ctx, username, familyID)
+	if err != nil {
+		return TokenResponse{}, err
+	}
+	tokens.RefreshToken = refreshToken
+	return tokens, nil
+}
+
+// issueAccessToken выпускает короткоживущий access-токен с user_id и актуальными ролями пользователя
+func (s *AuthController) issueAccessToken(ctx context.Context, username, familyID string) (TokenResponse, error) {
+	userID, err := s.facade.AuthService.UserID(ctx, username)
+	if err != nil {
+		return TokenResponse{}, err
+	}
+	roles, err := s.facade.AuthService.Roles(ctx, username)
+	if err != nil {
+		return TokenResponse{}, err
+	}
+	jti, err := newTokenID()
+	if err != nil {
+		return TokenResponse{}, err
+	}
+
+	now := time.Now()
+	claims := map[string]interface{}{
+		"user_id":  userID,
+		"username": username, // Логин нужен для операций с учетной записью
+		"roles":    roles,
+		"jti":      jti,
+		"fid":      familyID, // Семейство refresh-токенов, которое отзывается при выходе
+		"iat":      now.Unix(),
+		"exp":      now.Add(s.cfg.AccessTTL).Unix(),
+	}
+	_, tokenString, err := s.keys.Encode(claims)
+	if err != nil {
+		return TokenResponse{}, err
+	}
+	return TokenResponse{Token: tokenString, ExpiresIn: int64(s.cfg.AccessTTL.Seconds())}, nil
+}
+
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func newRefreshToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
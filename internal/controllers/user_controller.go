@@ -1,122 +1,202 @@
 package controllers
 
 import (
-	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/go-chi/chi"
 	"studentgit.kata.academy/Zhodaran/go-kata/internal/entities"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/usecases/usecasesUser"
 )
 
-func (uc *UserController) CreateUser(w http.ResponseWriter, r *http.Request) {
-	var user entities.User
-	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-	if err := uc.UserRepo.Create(context.Background(), user); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-	json.NewEncoder(w).Encode(CreateResponse{Message: "Create successful"})
-	w.WriteHeader(http.StatusCreated)
-}
+// @Summary Create a user
+// @Description Adds a library user without login credentials.
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer Token"
+// @Param body body UserRequest true "Name and email"
+// @Success 201 {object} entities.User "Created user"
+// @Header 201 {string} Location "URL of the created user"
+// @Failure 400 {object} mErrorResponse "Invalid name or email"
+// @Failure 403 {object} mErrorResponse "Permission users:admin required"
+// @Failure 409 {object} mErrorResponse "Email already in use"
+// @Failure 500 {object} mErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /api/users [post]
+func (uc *UserController) CreateUser(resp Responder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var requestBody UserRequest
+		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+			resp.ErrorBadRequest(w, errors.New("invalid request body"))
+			return
+		}
 
-func (uc *UserController) GetUser(w http.ResponseWriter, r *http.Request) {
-	id := chi.URLParam(r, "id")
-	user, err := uc.UserRepo.GetByID(context.Background(), id)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
-		return
-	}
-	json.NewEncoder(w).Encode(CreateResponse{Message: "Great successful"})
-	json.NewEncoder(w).Encode(user)
-}
+		user, err := uc.facade.UserService.Create(r.Context(), entities.User{Name: requestBody.Name, Email: requestBody.Email})
+		if !handleUserError(resp, w, err) {
+			return
+		}
 
-func (uc *UserController) UpdateUser(w http.ResponseWriter, r *http.Request) {
-	var user entities.User
-	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
+		w.Header().Set("Location", fmt.Sprintf("/api/users/%d", user.ID))
+		w.Header().Set("Content-Type", "application/json;charset=utf-8")
+		w.WriteHeader(http.StatusCreated)
+		resp.OutputJSON(w, user)
 	}
-	if err := uc.UserRepo.Update(context.Background(), user); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-	json.NewEncoder(w).Encode(CreateResponse{Message: "Update successful"})
-	w.WriteHeader(http.StatusNoContent)
 }
 
-func (uc *UserController) DeleteUser(w http.ResponseWriter, r *http.Request) {
-	id := chi.URLParam(r, "id")
-	if err := uc.UserRepo.Delete(context.Background(), id); err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
-		return
+// @Summary Get a user
+// @Tags Users
+// @Produce json
+// @Param id path int true "User ID"
+// @Param Authorization header string true "Bearer Token"
+// @Success 200 {object} entities.User "User"
+// @Failure 400 {object} mErrorResponse "Invalid user ID"
+// @Failure 403 {object} mErrorResponse "Permission users:admin required"
+// @Failure 404 {object} mErrorResponse "User not found"
+// @Failure 500 {object} mErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /api/users/{id} [get]
+func (uc *UserController) GetUser(resp Responder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := userIDFromPath(resp, w, r)
+		if !ok {
+			return
+		}
+
+		user, err := uc.facade.UserService.Get(r.Context(), id)
+		if !handleUserError(resp, w, err) {
+			return
+		}
+		resp.OutputJSON(w, user)
 	}
-	json.NewEncoder(w).Encode(CreateResponse{Message: "Delete successful"})
-	w.WriteHeader(http.StatusNoContent)
 }
 
-func (uc *UserController) ListUsers(w http.ResponseWriter, r *http.Request) {
-	limit := 10 // Установите значение по умолчанию
-	offset := 0 // Установите значение по умолчанию
-	users, err := uc.UserRepo.List(context.Background(), limit, offset)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+// @Summary Update a user
+// @Description Replaces the name and email of a user. Changing the email of an account resets its verification.
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param Authorization header string true "Bearer Token"
+// @Param body body UserRequest true "Name and email"
+// @Success 200 {object} entities.User "Updated user"
+// @Failure 400 {object} mErrorResponse "Invalid name or email"
+// @Failure 403 {object} mErrorResponse "Permission users:admin required"
+// @Failure 404 {object} mErrorResponse "User not found"
+// @Failure 409 {object} mErrorResponse "Email already in use"
+// @Failure 500 {object} mErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /api/users/{id} [put]
+func (uc *UserController) UpdateUser(resp Responder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := userIDFromPath(resp, w, r)
+		if !ok {
+			return
+		}
+
+		var requestBody UserRequest
+		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+			resp.ErrorBadRequest(w, errors.New("invalid request body"))
+			return
+		}
+
+		user, err := uc.facade.UserService.Update(r.Context(), entities.User{ID: id, Name: requestBody.Name, Email: requestBody.Email})
+		if !handleUserError(resp, w, err) {
+			return
+		}
+		resp.OutputJSON(w, user)
 	}
-	json.NewEncoder(w).Encode(CreateResponse{Message: "List successful"})
-	json.NewEncoder(w).Encode(users)
 }
 
-func (r *UserController) Create(ctx context.Context, user entities.User) error {
-	query := "INSERT INTO users (id, name, email) VALUES ($1, $2, $3)"
-	_, err := r.Db.ExecContext(ctx, query, user.ID, user.Name, user.Email)
-	return err
-}
+// @Summary Delete a user
+// @Description Marks a user as deleted.
+// @Tags Users
+// @Param id path int true "User ID"
+// @Param Authorization header string true "Bearer Token"
+// @Success 204 "User deleted"
+// @Failure 400 {object} mErrorResponse "Invalid user ID"
+// @Failure 403 {object} mErrorResponse "Permission users:admin required"
+// @Failure 404 {object} mErrorResponse "User not found"
+// @Failure 500 {object} mErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /api/users/{id} [delete]
+func (uc *UserController) DeleteUser(resp Responder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := userIDFromPath(resp, w, r)
+		if !ok {
+			return
+		}
 
-// GetByID получает пользователя по ID
-func (r *UserController) GetByID(ctx context.Context, id string) (entities.User, error) {
-	var user entities.User
-	query := "SELECT id, name, email, deleted_at FROM users WHERE id = $1"
-	err := r.Db.QueryRowContext(ctx, query, id).Scan(&user.ID, &user.Name, &user.Email, &user.DeletedAt)
-	if err != nil {
-		return entities.User{}, err
+		if !handleUserError(resp, w, uc.facade.UserService.Delete(r.Context(), id)) {
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
 	}
-	return user, nil
 }
 
-// Update обновляет данные пользователя
-func (r *UserController) Update(ctx context.Context, user entities.User) error {
-	query := "UPDATE users SET name = $1, email = $2 WHERE id = $3"
-	_, err := r.Db.ExecContext(ctx, query, user.Name, user.Email, user.ID)
-	return err
-}
+// @Summary List users
+// @Description Returns a page of users. Pages are linked by cursor: pass next_cursor as after to get the following page.
+// @Tags Users
+// @Produce json
+// @Param Authorization header string true "Bearer Token"
+// @Param limit query int false "Page size, 1-100" default(20)
+// @Param after query string false "Cursor from the previous page"
+// @Param sort query string false "Comma-separated fields, prefix - for descending: id, name, email, username"
+// @Param name query string false "Exact name, case-insensitive. Use name~ for a substring match"
+// @Param email query string false "Exact email, case-insensitive. Use email~ for a substring match"
+// @Success 200 {object} UsersPage "Users"
+// @Failure 400 {object} mErrorResponse "Invalid list parameters"
+// @Failure 403 {object} mErrorResponse "Permission users:admin required"
+// @Failure 500 {object} mErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /api/users [get]
+func (uc *UserController) ListUsers(resp Responder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		opts, err := parseListOptions(r)
+		if err != nil {
+			resp.ErrorBadRequest(w, err)
+			return
+		}
 
-// Delete помечает пользователя как удаленного
-func (r *UserController) Delete(ctx context.Context, id string) error {
-	query := "UPDATE users SET deleted_at = NOW() WHERE id = $1"
-	_, err := r.Db.ExecContext(ctx, query, id)
-	return err
+		page, err := uc.facade.UserService.List(r.Context(), opts)
+		if !handleUserError(resp, w, err) {
+			return
+		}
+		resp.OutputJSON(w, UsersPage{
+			Items:      page.Items,
+			Total:      page.Total,
+			NextCursor: page.NextCursor,
+			Next:       nextPageLink(r, page.NextCursor),
+		})
+	}
 }
 
-// List возвращает список пользователей с пагинацией
-func (r *UserController) List(ctx context.Context, limit, offset int) ([]entities.User, error) {
-	query := "SELECT id, name, email, deleted_at FROM users WHERE deleted_at IS NULL LIMIT $1 OFFSET $2"
-	rows, err := r.Db.QueryContext(ctx, query, limit, offset)
-	if err != nil {
-		return nil, err
+func userIDFromPath(resp Responder, w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id <= 0 {
+		resp.ErrorBadRequest(w, errors.New("invalid user id"))
+		return 0, false
 	}
-	defer rows.Close()
+	return id, true
+}
 
-	var users []entities.User
-	for rows.Next() {
-		var user entities.User
-		if err := rows.Scan(&user.ID, &user.Name, &user.Email, &user.DeletedAt); err != nil {
-			return nil, err
-		}
-		users = append(users, user)
+// handleUserError отвечает на ошибку сервиса подходящим статусом и сообщает, можно ли продолжать
+func handleUserError(resp Responder, w http.ResponseWriter, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, usecasesUser.ErrInvalidName), errors.Is(err, usecasesUser.ErrInvalidEmail), errors.Is(err, repositories.ErrInvalidListOptions):
+		resp.ErrorBadRequest(w, err)
+	case errors.Is(err, repositories.ErrUserNotFound):
+		resp.ErrorNotFound(w, err)
+	case errors.Is(err, repositories.ErrEmailExists):
+		resp.ErrorConflict(w, err)
+	default:
+		resp.ErrorInternal(w, err)
 	}
-	return users, nil
+	return false
 }
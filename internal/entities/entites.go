@@ -1,22 +1,224 @@
 package entities
 
+import (
+	"fmt"
+	"time"
+)
+
 type UserAuth struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
+	Email    string `json:"email,omitempty"` // Нужна для сброса пароля, при входе не используется
+}
+
+// Роль patron есть у каждого пользователя, остальные выдаются администратором
+const (
+	RolePatron    = "patron"
+	RoleLibrarian = "librarian"
+	RoleAdmin     = "admin"
+)
+
+const (
+	PermCatalogWrite = "catalog:write" // Добавление и изменение книг, экземпляров и авторов
+	PermLoansStaff   = "loans:staff"   // Выдача и прием книг за читателя
+	PermFinesManage  = "fines:manage"  // Оплата и списание штрафов
+	PermUsersAdmin   = "users:admin"   // Управление пользователями и их ролями
+)
+
+var rolePermissions = map[string][]string{
+	RolePatron:    {},
+	RoleLibrarian: {PermCatalogWrite, PermLoansStaff, PermFinesManage},
+	RoleAdmin:     {PermCatalogWrite, PermLoansStaff, PermFinesManage, PermUsersAdmin},
+}
+
+// IsValidPermission сообщает, известно ли право. Права служат и областями действия API-ключей
+func IsValidPermission(permission string) bool {
+	return HasPermission([]string{RoleAdmin}, permission)
+}
+
+// IsValidRole сообщает, известна ли роль
+func IsValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// HasPermission сообщает, дает ли хотя бы одна из ролей указанное право
+func HasPermission(roles []string, permission string) bool {
+	for _, role := range roles {
+		for _, p := range rolePermissions[role] {
+			if p == permission {
+				return true
+			}
+		}
+	}
+	return false
 }
 
+// User читатель библиотеки. Username заполнен, если у пользователя есть учетная запись для входа
 type User struct {
 	ID        int          `json:"id"`
+	Username  string       `json:"username,omitempty"`
 	Name      string       `json:"name"`
 	Email     string       `json:"email"`
 	DeletedAt *string      `json:"deleted_at"` // Для логического удаления
 	Books     map[int]Book `json:"books"`
 }
 
+// Book произведение (название и автор), физические экземпляры хранятся в Copy
 type Book struct {
-	Index     int    `json:"index"`
-	Book      string `json:"book"`
-	Author    string `json:"author"`
-	Block     *bool  `json:"block"`
-	TakeCount int    `json:"take_count"`
+	Index           int        `json:"index"`
+	Book            string     `json:"book"`
+	Author          string     `json:"author"`
+	Block           *bool      `json:"block"` // true, когда свободных экземпляров нет
+	TakeCount       int        `json:"take_count"`
+	AvailableCopies int        `json:"available_copies"`
+	TotalCopies     int        `json:"total_copies"`
+	DueAt           *time.Time `json:"due_at,omitempty"` // Ближайший срок возврата выданных экземпляров
+	Overdue         bool       `json:"overdue"`          // Хотя бы один экземпляр просрочен
+}
+
+// Author автор из справочника. Книги ссылаются на него по ID
+type Author struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	BirthYear *int   `json:"birth_year"`
+	DeathYear *int   `json:"death_year"`
+	Bio       string `json:"bio"`
+}
+
+// BookSearchHit книга, найденная поиском. Highlight содержит экранированные название и автора с совпадениями в <mark>
+type BookSearchHit struct {
+	Book
+	Rank      float64       `json:"rank"`
+	Highlight BookHighlight `json:"highlight"`
+}
+
+type BookHighlight struct {
+	Book   string `json:"book"`
+	Author string `json:"author"`
+}
+
+// FacetCount число найденных книг с данным значением
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+type BookFacets struct {
+	Authors      []FacetCount `json:"authors"`
+	Availability []FacetCount `json:"availability"` // Значения "true" и "false"
+}
+
+// BookSearchResult страница результатов поиска. Фасеты считаются по всем совпадениям, без учета фильтров
+type BookSearchResult struct {
+	Hits   []BookSearchHit `json:"hits"`
+	Total  int             `json:"total"`
+	Facets BookFacets      `json:"facets"`
+}
+
+const (
+	CopyAvailable = "available"
+	CopyOnLoan    = "on_loan"
+	CopyOnHold    = "on_hold" // Отложен для читателя по брони
+)
+
+// Copy физический экземпляр книги
+type Copy struct {
+	ID        int    `json:"id"`
+	BookIndex int    `json:"book_index"`
+	Barcode   string `json:"barcode"`
+	Status    string `json:"status"`
+}
+
+// CopyBarcode формирует штрихкод n-го экземпляра книги
+func CopyBarcode(index, n int) string {
+	return fmt.Sprintf("LIB-%06d-%02d", index, n)
+}
+
+// Loan запись о выдаче книги читателю
+type Loan struct {
+	ID         int        `json:"id"`
+	BookIndex  int        `json:"book_index"`
+	CopyID     int        `json:"copy_id"`
+	Barcode    string     `json:"barcode"`
+	Book       string     `json:"book"`
+	Author     string     `json:"author"`
+	UserID     int        `json:"user_id"`
+	TakenAt    time.Time  `json:"taken_at"`
+	DueAt      time.Time  `json:"due_at"`
+	ReturnedAt *time.Time `json:"returned_at"` // nil, пока книга на руках
+	Renewals   int        `json:"renewals"`
+	Overdue    bool       `json:"overdue"`
+}
+
+const (
+	HoldWaiting   = "waiting"   // В очереди
+	HoldReady     = "ready"     // Книга отложена и ждет читателя до PickupDeadline
+	HoldFulfilled = "fulfilled" // Читатель забрал книгу
+	HoldCancelled = "cancelled"
+	HoldExpired   = "expired" // Читатель не забрал книгу вовремя
+)
+
+// Hold бронь читателя на выданную книгу
+type Hold struct {
+	ID             int        `json:"id"`
+	BookIndex      int        `json:"book_index"`
+	Book           string     `json:"book"`
+	UserID         int        `json:"user_id"`
+	Status         string     `json:"status"`
+	Position       int        `json:"position,omitempty"` // Место в очереди для ожидающих броней
+	Barcode        string     `json:"barcode,omitempty"`  // Отложенный экземпляр для готовых броней
+	CreatedAt      time.Time  `json:"created_at"`
+	PickupDeadline *time.Time `json:"pickup_deadline,omitempty"`
+}
+
+const (
+	FineOutstanding = "outstanding"
+	FinePaid        = "paid"
+	FineWaived      = "waived"
+)
+
+// Fine штраф читателя. Суммы хранятся в копейках
+type Fine struct {
+	ID        int        `json:"id"`
+	UserID    int        `json:"user_id"`
+	LoanID    int        `json:"loan_id"`
+	BookIndex int        `json:"book_index"`
+	Amount    int64      `json:"amount"`
+	Paid      int64      `json:"paid"`
+	Status    string     `json:"status"`
+	Note      string     `json:"note,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	ClosedAt  *time.Time `json:"closed_at,omitempty"` // Когда штраф оплачен или списан
+}
+
+// OverdueFine считает штраф за каждый начатый день просрочки
+func OverdueFine(dueAt, returnedAt time.Time, rate int64) int64 {
+	late := returnedAt.Sub(dueAt)
+	if late <= 0 || rate <= 0 {
+		return 0
+	}
+	days := int64((late + 24*time.Hour - 1) / (24 * time.Hour))
+	return days * rate
+}
+
+// FinePayment ручная оплата штрафа
+type FinePayment struct {
+	ID        int       `json:"id"`
+	FineID    int       `json:"fine_id"`
+	Amount    int64     `json:"amount"`
+	Note      string    `json:"note,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// APIKey ключ доступа для сервисов. Сам ключ показывается один раз при создании, хранится только хеш
+type APIKey struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"` // Открытая часть ключа, по ней ключ можно узнать в списке
+	Scopes     []string   `json:"scopes"`
+	CreatedBy  string     `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
 }
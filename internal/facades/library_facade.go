@@ -3,10 +3,13 @@ package facades
 import (
 	"context"
 
-	"studentgit.kata.academy/Zhodaran/go-kata/internal/infrastructure/postgres"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/config"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/infrastructure/password"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
 	"studentgit.kata.academy/Zhodaran/go-kata/internal/usecases/usecasesAuth"
 	"studentgit.kata.academy/Zhodaran/go-kata/internal/usecases/usecasesAuthor"
 	"studentgit.kata.academy/Zhodaran/go-kata/internal/usecases/usecasesBook"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/usecases/usecasesFine"
 	"studentgit.kata.academy/Zhodaran/go-kata/internal/usecases/usecasesUser"
 )
 
@@ -15,14 +18,18 @@ type LibraryFacade struct {
 	BookService   *usecasesBook.BookService
 	AuthorService *usecasesAuthor.AuthorService
 	UserService   *usecasesUser.UserService
+	FineService   *usecasesFine.FineService
 	QueryContext  context.Context
 }
 
-func NewLibraryFacade(authRepo *postgres.PostgresAuthRepository, bookRepo *postgres.PostgresBookRepository, authorRepo *postgres.PostgresAuthorRepository, userRepo *postgres.PostgresUserRepository) *LibraryFacade {
+// NewLibraryFacade собирает сервисы. Правила выдачи берутся из loanCfg, пароли хеширует hasher
+func NewLibraryFacade(authRepo repositories.AuthRepository, apiKeyRepo repositories.APIKeyRepository, bookRepo repositories.BookRepository, authorRepo repositories.AuthorRepository, userRepo repositories.UserRepository,
+	fineRepo repositories.FineRepository, hasher *password.Hasher, loanCfg config.LoanConfig) *LibraryFacade {
 	return &LibraryFacade{
-		AuthService:   usecasesAuth.NewAuthService(authRepo),
-		BookService:   usecasesBook.NewBookService(bookRepo),
+		AuthService:   usecasesAuth.NewAuthService(authRepo, apiKeyRepo, hasher),
+		BookService:   usecasesBook.NewBookService(bookRepo, loanCfg),
 		AuthorService: usecasesAuthor.NewAuthorService(authorRepo),
 		UserService:   usecasesUser.NewUserService(userRepo),
+		FineService:   usecasesFine.NewFineService(fineRepo),
 	}
 }
@@ -0,0 +1,70 @@
+package limiter
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Guard защищает вход от перебора: отдельно считает неудачи по имени пользователя и по IP
+type Guard struct {
+	users Limiter
+	ips   Limiter
+	audit *zap.Logger
+}
+
+func NewGuard(users, ips Limiter, audit *zap.Logger) *Guard {
+	return &Guard{users: users, ips: ips, audit: audit}
+}
+
+// Allow возвращает, сколько ждать до следующей попытки входа, 0 если можно пробовать сейчас
+func (g *Guard) Allow(ctx context.Context, username, ip string) (time.Duration, error) {
+	userWait, err := g.users.Check(ctx, username)
+	if err != nil {
+		return 0, err
+	}
+	ipWait, err := g.ips.Check(ctx, ip)
+	if err != nil {
+		return 0, err
+	}
+	if ipWait > userWait {
+		return ipWait, nil
+	}
+	return userWait, nil
+}
+
+// Failed учитывает неудачный вход и пишет в аудит о каждой новой блокировке
+func (g *Guard) Failed(ctx context.Context, username, ip string) error {
+	locked, err := g.users.Fail(ctx, username)
+	if err != nil {
+		return err
+	}
+	if locked {
+		g.audit.Warn("account locked after failed logins", zap.String("username", username), zap.String("ip", ip))
+	}
+
+	locked, err = g.ips.Fail(ctx, ip)
+	if err != nil {
+		return err
+	}
+	if locked {
+		g.audit.Warn("ip locked after failed logins", zap.String("ip", ip), zap.String("username", username))
+	}
+	return nil
+}
+
+// Succeeded сбрасывает счетчик пользователя. Счетчик IP не сбрасывается, иначе один
+// известный пароль позволял бы перебирать остальные учетные записи
+func (g *Guard) Succeeded(ctx context.Context, username string) error {
+	return g.users.Reset(ctx, username)
+}
+
+// Unlock снимает блокировку пользователя вручную
+func (g *Guard) Unlock(ctx context.Context, username, by string) error {
+	if err := g.users.Reset(ctx, username); err != nil {
+		return err
+	}
+	g.audit.Info("account unlocked", zap.String("username", username), zap.String("by", by))
+	return nil
+}
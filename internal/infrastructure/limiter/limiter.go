@@ -0,0 +1,46 @@
+package limiter
+
+import (
+	"context"
+	"time"
+)
+
+// Policy правила блокировки после неудачных попыток входа
+type Policy struct {
+	MaxFailures int           // После стольких неудач подряд ключ блокируется на Lockout
+	BaseDelay   time.Duration // Пауза после второй неудачи, дальше удваивается
+	MaxDelay    time.Duration // Предел паузы до блокировки
+	Lockout     time.Duration // Длительность блокировки, заодно срок жизни счетчика
+}
+
+// Limiter считает неудачные попытки по ключу (имени пользователя или IP)
+type Limiter interface {
+	// Check возвращает, сколько ждать до следующей попытки
+	Check(ctx context.Context, key string) (time.Duration, error)
+	// Fail учитывает неудачную попытку. locked истинно, если эта попытка привела к блокировке
+	Fail(ctx context.Context, key string) (locked bool, err error)
+	// Reset обнуляет счетчик и снимает блокировку
+	Reset(ctx context.Context, key string) error
+}
+
+// Next вычисляет состояние ключа после очередной неудачи. Счетчик начинается заново,
+// если прошлая неудача была раньше, чем Lockout назад
+func (p Policy) Next(failures int, lastFailure, now time.Time) (newFailures int, blockedUntil time.Time, locked bool) {
+	if now.Sub(lastFailure) > p.Lockout {
+		failures = 0
+	}
+	failures++
+
+	if failures >= p.MaxFailures {
+		return failures, now.Add(p.Lockout), failures == p.MaxFailures
+	}
+	if failures == 1 {
+		return failures, now, false
+	}
+
+	delay := p.BaseDelay << (failures - 2)
+	if delay > p.MaxDelay || delay <= 0 {
+		delay = p.MaxDelay
+	}
+	return failures, now.Add(delay), false
+}
@@ -0,0 +1,122 @@
+package limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+var testPolicy = Policy{MaxFailures: 4, BaseDelay: time.Second, MaxDelay: 3 * time.Second, Lockout: time.Minute}
+
+func TestMemoryLimiterBackoffAndLockout(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	m := NewMemoryLimiter(testPolicy)
+	m.now = func() time.Time { return now }
+
+	wants := []struct {
+		wait   time.Duration
+		locked bool
+	}{
+		{0, false},               // первая неудача без паузы
+		{time.Second, false},     // дальше пауза удваивается
+		{2 * time.Second, false}, //
+		{time.Minute, true},      // четвертая неудача блокирует
+	}
+	for i, want := range wants {
+		locked, err := m.Fail(ctx, "alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		wait, err := m.Check(ctx, "alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if wait != want.wait || locked != want.locked {
+			t.Errorf("failure %d: wait %v locked %v, want %v %v", i+1, wait, locked, want.wait, want.locked)
+		}
+	}
+
+	// После окончания блокировки счетчик начинается заново
+	now = now.Add(time.Minute + time.Second)
+	if wait, _ := m.Check(ctx, "alice"); wait != 0 {
+		t.Fatalf("wait after lockout = %v", wait)
+	}
+	if locked, _ := m.Fail(ctx, "alice"); locked {
+		t.Fatal("first failure after lockout locked again")
+	}
+
+	if err := m.Reset(ctx, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if wait, _ := m.Check(ctx, "alice"); wait != 0 {
+		t.Fatalf("wait after reset = %v", wait)
+	}
+}
+
+func TestMemoryLimiterPurge(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	m := NewMemoryLimiter(testPolicy)
+	m.now = func() time.Time { return now }
+
+	m.Fail(ctx, "bob")
+	now = now.Add(testPolicy.Lockout + time.Second)
+	for i := 0; i < testPolicy.MaxFailures; i++ {
+		m.Fail(ctx, "alice")
+	}
+	m.Fail(ctx, "carol")
+
+	// Счетчик bob устарел, alice заблокирована, у carol свежая неудача
+	if purged, err := m.Purge(ctx); err != nil || purged != 1 {
+		t.Fatalf("purged %d, %v, want 1 stale entry", purged, err)
+	}
+	if _, ok := m.entries["bob"]; ok {
+		t.Fatal("stale entry of bob kept")
+	}
+	if len(m.entries) != 2 {
+		t.Fatalf("entries %v, want alice and carol", m.entries)
+	}
+}
+
+func TestPolicyDelayIsCapped(t *testing.T) {
+	p := Policy{MaxFailures: 100, BaseDelay: time.Second, MaxDelay: 5 * time.Second, Lockout: time.Hour}
+	now := time.Now()
+	_, until, _ := p.Next(50, now, now)
+	if got := until.Sub(now); got != 5*time.Second {
+		t.Fatalf("delay = %v, want cap 5s", got)
+	}
+}
+
+func TestGuardAuditsLockout(t *testing.T) {
+	ctx := context.Background()
+	core, logs := observer.New(zap.InfoLevel)
+	users := NewMemoryLimiter(Policy{MaxFailures: 2, Lockout: time.Minute})
+	ips := NewMemoryLimiter(Policy{MaxFailures: 10, Lockout: time.Minute})
+	g := NewGuard(users, ips, zap.New(core))
+
+	for i := 0; i < 2; i++ {
+		if err := g.Failed(ctx, "alice", "10.0.0.1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if wait, _ := g.Allow(ctx, "alice", "10.0.0.2"); wait == 0 {
+		t.Fatal("locked user allowed from another ip")
+	}
+	if wait, _ := g.Allow(ctx, "bob", "10.0.0.1"); wait != 0 {
+		t.Fatalf("other user on same ip waits %v", wait)
+	}
+	if n := logs.FilterMessage("account locked after failed logins").Len(); n != 1 {
+		t.Fatalf("lockout audit entries = %d, want 1", n)
+	}
+
+	if err := g.Unlock(ctx, "alice", "admin"); err != nil {
+		t.Fatal(err)
+	}
+	if wait, _ := g.Allow(ctx, "alice", "10.0.0.1"); wait != 0 {
+		t.Fatalf("wait after unlock = %v", wait)
+	}
+}
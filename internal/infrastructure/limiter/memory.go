@@ -0,0 +1,89 @@
+package limiter
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type entry struct {
+	failures     int
+	lastFailure  time.Time
+	blockedUntil time.Time
+}
+
+// MemoryLimiter хранит счетчики в памяти процесса, подходит для одного экземпляра сервиса
+type MemoryLimiter struct {
+	policy  Policy
+	now     func() time.Time
+	mu      sync.Mutex
+	entries map[string]*entry
+}
+
+func NewMemoryLimiter(policy Policy) *MemoryLimiter {
+	return &MemoryLimiter{policy: policy, now: time.Now, entries: make(map[string]*entry)}
+}
+
+func (m *MemoryLimiter) Check(ctx context.Context, key string) (time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.entries[key]
+	if !ok {
+		return 0, nil
+	}
+	now := m.now()
+	// Устаревшие счетчики удаляем, чтобы карта не росла бесконечно
+	if m.stale(e, now) {
+		delete(m.entries, key)
+		return 0, nil
+	}
+	if now.Before(e.blockedUntil) {
+		return e.blockedUntil.Sub(now), nil
+	}
+	return 0, nil
+}
+
+func (m *MemoryLimiter) Fail(ctx context.Context, key string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.entries[key]
+	if !ok {
+		e = &entry{}
+		m.entries[key] = e
+	}
+	now := m.now()
+	var locked bool
+	e.failures, e.blockedUntil, locked = m.policy.Next(e.failures, e.lastFailure, now)
+	e.lastFailure = now
+	return locked, nil
+}
+
+func (m *MemoryLimiter) Reset(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.entries, key)
+	return nil
+}
+
+// Purge удаляет счетчики, которые уже не влияют ни на паузу, ни на блокировку.
+// Check чистит только те ключи, которые проверяют снова, остальные убирает Purge
+func (m *MemoryLimiter) Purge(ctx context.Context) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	var purged int64
+	for key, e := range m.entries {
+		if m.stale(e, now) {
+			delete(m.entries, key)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+func (m *MemoryLimiter) stale(e *entry, now time.Time) bool {
+	return now.Sub(e.lastFailure) > m.policy.Lockout && !now.Before(e.blockedUntil)
+}
@@ -0,0 +1,41 @@
+package mailer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileMailer(t *testing.T) {
+	dir := t.TempDir()
+	m, err := NewFileMailer(dir, "library@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := Message{To: "alice@example.com\r\nBcc: mallory@example.com", Subject: "Password reset", Body: "line one\nline two"}
+	if err := m.Send(context.Background(), msg); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("files = %v, %v", files, err)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := string(data)
+
+	if strings.Contains(text, "\r\nBcc:") {
+		t.Fatalf("header injected:\n%s", text)
+	}
+	for _, want := range []string{"From: library@example.com\r\n", "Subject: Password reset\r\n", "line one\r\nline two"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("mail has no %q:\n%s", want, text)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package memory
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+)
+
+// authTokenRow одноразовый токен из письма
+type authTokenRow struct {
+	username  string
+	purpose   string
+	email     string
+	expiresAt time.Time
+	used      bool
+}
+
+// FindByLogin ищет пользователя по имени или почте и возвращает его имя и почту
+func (r *AuthRepository) FindByLogin(ctx context.Context, login string) (username, email string, err error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if account, ok := s.account(login); ok {
+		return login, account.email, nil
+	}
+	for name, account := range s.accounts {
+		if _, ok := s.user(account.userID); ok && account.email != "" && strings.EqualFold(account.email, login) {
+			return name, account.email, nil
+		}
+	}
+	return "", "", repositories.ErrUserNotFound
+}
+
+// GetEmail возвращает почту пользователя и признак ее подтверждения
+func (r *AuthRepository) GetEmail(ctx context.Context, username string) (email string, verified bool, err error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	account, ok := s.accounts[username]
+	if !ok {
+		return "", false, repositories.ErrUserNotFound
+	}
+	if account.email == "" {
+		return "", false, repositories.ErrEmailNotSet
+	}
+	return account.email, account.emailVerifiedAt != nil, nil
+}
+
+// CreateAuthToken регистрирует одноразовый токен. Прежние неиспользованные токены
+// того же назначения гасятся, действует только последняя ссылка
+func (r *AuthRepository) CreateAuthToken(ctx context.Context, jti, username, purpose, email string, expiresAt time.Time) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, t := range s.authTokens {
+		if t.username == username && t.purpose == purpose {
+			t.used = true
+		}
+	}
+	s.authTokens[jti] = &authTokenRow{username: username, purpose: purpose, email: email, expiresAt: expiresAt}
+	return nil
+}
+
+// ResetPassword гасит токен сброса, меняет хеш пароля и закрывает все сессии пользователя
+func (r *AuthRepository) ResetPassword(ctx context.Context, jti, passwordHash string) (string, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token, err := s.consumeAuthToken(jti, repositories.PurposePasswordReset)
+	if err != nil {
+		return "", err
+	}
+	if account, ok := s.accounts[token.username]; ok {
+		account.passwordHash = passwordHash
+	}
+	s.revokeSessions(token.username)
+	return token.username, nil
+}
+
+// VerifyEmail гасит токен подтверждения и отмечает почту подтвержденной,
+// если с момента отправки письма адрес не менялся
+func (r *AuthRepository) VerifyEmail(ctx context.Context, jti string) (string, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token, ok := s.authTokens[jti]
+	if !ok || !s.authTokenValid(token, repositories.PurposeVerifyEmail) {
+		return "", repositories.ErrTokenInvalid
+	}
+	account, ok := s.accounts[token.username]
+	if !ok || !strings.EqualFold(account.email, token.email) {
+		return "", repositories.ErrTokenInvalid
+	}
+	token.used = true
+	verifiedAt := s.now()
+	account.emailVerifiedAt = &verifiedAt
+	return token.username, nil
+}
+
+// consumeAuthToken гасит действующий токен. Вызывается под блокировкой
+func (s *Store) consumeAuthToken(jti, purpose string) (*authTokenRow, error) {
+	token, ok := s.authTokens[jti]
+	if !ok || !s.authTokenValid(token, purpose) {
+		return nil, repositories.ErrTokenInvalid
+	}
+	token.used = true
+	return token, nil
+}
+
+func (s *Store) authTokenValid(token *authTokenRow, purpose string) bool {
+	return token.purpose == purpose && !token.used && s.now().Before(token.expiresAt)
+}
@@ -0,0 +1,122 @@
+package memory
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/entities"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+)
+
+type APIKeyRepository struct {
+	store *Store
+}
+
+func NewAPIKeyRepository(store *Store) *APIKeyRepository {
+	return &APIKeyRepository{store: store}
+}
+
+// apiKeyRow ключ и хеш его секрета
+type apiKeyRow struct {
+	entities.APIKey
+	hash string
+}
+
+// Create выпускает ключ вида lib_<prefix>_<secret> и возвращает его единственный раз
+func (r *APIKeyRepository) Create(ctx context.Context, name string, scopes []string, createdBy string) (entities.APIKey, string, error) {
+	prefixBytes := make([]byte, 4)
+	secretBytes := make([]byte, 32)
+	if _, err := rand.Read(prefixBytes); err != nil {
+		return entities.APIKey{}, "", err
+	}
+	if _, err := rand.Read(secretBytes); err != nil {
+		return entities.APIKey{}, "", err
+	}
+	prefix := hex.EncodeToString(prefixBytes)
+	key := "lib_" + prefix + "_" + base64.RawURLEncoding.EncodeToString(secretBytes)
+
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	row := &apiKeyRow{
+		APIKey: entities.APIKey{
+			ID:        len(s.apiKeys) + 1,
+			Name:      name,
+			Prefix:    prefix,
+			Scopes:    append([]string(nil), scopes...),
+			CreatedBy: createdBy,
+			CreatedAt: s.now(),
+		},
+		hash: hashAPIKey(key),
+	}
+	s.apiKeys = append(s.apiKeys, row)
+	return row.APIKey, key, nil
+}
+
+// List возвращает все ключи, включая отозванные
+func (r *APIKeyRepository) List(ctx context.Context) ([]entities.APIKey, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var keys []entities.APIKey
+	for _, k := range s.apiKeys {
+		keys = append(keys, k.APIKey)
+	}
+	return keys, nil
+}
+
+// Revoke отзывает ключ. Повторный отзыв не меняет дату
+func (r *APIKeyRepository) Revoke(ctx context.Context, id int) (entities.APIKey, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if id < 1 || id > len(s.apiKeys) {
+		return entities.APIKey{}, repositories.ErrAPIKeyNotFound
+	}
+	key := s.apiKeys[id-1]
+	if key.RevokedAt == nil {
+		revokedAt := s.now()
+		key.RevokedAt = &revokedAt
+	}
+	return key.APIKey, nil
+}
+
+// AuthenticateAPIKey находит действующий ключ и отмечает время его использования
+func (r *APIKeyRepository) AuthenticateAPIKey(ctx context.Context, key string) (entities.APIKey, error) {
+	parts := strings.SplitN(key, "_", 3)
+	if len(parts) != 3 || parts[0] != "lib" {
+		return entities.APIKey{}, repositories.ErrAPIKeyInvalid
+	}
+
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, k := range s.apiKeys {
+		if k.Prefix != parts[1] || k.RevokedAt != nil {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(k.hash), []byte(hashAPIKey(key))) != 1 {
+			return entities.APIKey{}, repositories.ErrAPIKeyInvalid
+		}
+		apiKey := k.APIKey
+		usedAt := s.now()
+		k.LastUsedAt = &usedAt
+		return apiKey, nil
+	}
+	return entities.APIKey{}, repositories.ErrAPIKeyInvalid
+}
+
+// hashAPIKey у ключа достаточно энтропии, поэтому медленный хеш не нужен
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
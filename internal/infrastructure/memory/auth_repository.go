@@ -0,0 +1,144 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/entities"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+)
+
+type AuthRepository struct {
+	store *Store
+}
+
+func NewAuthRepository(store *Store) *AuthRepository {
+	return &AuthRepository{store: store}
+}
+
+// accountRow учетные данные пользователя. Остаются и после удаления пользователя,
+// поэтому его имя нельзя занять повторно
+type accountRow struct {
+	userID          int
+	email           string
+	passwordHash    string
+	emailVerifiedAt *time.Time
+	roles           map[string]bool // Выданные роли, кроме patron
+}
+
+// Create заводит пользователя и привязанные к нему учетные данные и возвращает user_id. Почта необязательна
+func (r *AuthRepository) Create(ctx context.Context, username, email, passwordHash string) (int, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.accounts[username]; ok || s.emailTaken(email, 0) {
+		return 0, repositories.ErrUserExists
+	}
+	name := []rune(username)
+	if len(name) > 50 {
+		name = name[:50]
+	}
+	user := &entities.User{ID: len(s.users) + 1, Username: username, Name: string(name), Email: email}
+	s.users = append(s.users, user)
+	s.accounts[username] = &accountRow{userID: user.ID, email: email, passwordHash: passwordHash, roles: map[string]bool{}}
+	return user.ID, nil
+}
+
+// GetUserID возвращает user_id учетной записи. Удаленный пользователь не находится
+func (r *AuthRepository) GetUserID(ctx context.Context, username string) (int, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	account, ok := s.account(username)
+	if !ok {
+		return 0, repositories.ErrUserNotFound
+	}
+	return account.userID, nil
+}
+
+// GetPasswordHash возвращает хеш пароля пользователя. Удаленный пользователь войти не может
+func (r *AuthRepository) GetPasswordHash(ctx context.Context, username string) (string, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	account, ok := s.account(username)
+	if !ok {
+		return "", repositories.ErrUserNotFound
+	}
+	return account.passwordHash, nil
+}
+
+// UpdatePasswordHash заменяет хеш пароля, например после смены стоимости хеширования
+func (r *AuthRepository) UpdatePasswordHash(ctx context.Context, username, passwordHash string) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if account, ok := s.accounts[username]; ok {
+		account.passwordHash = passwordHash
+	}
+	return nil
+}
+
+// GetRoles возвращает роли пользователя. Роль patron есть у всех и всегда идет первой.
+// Для имени без учетной записи возвращается ErrUserNotFound
+func (r *AuthRepository) GetRoles(ctx context.Context, username string) ([]string, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	account, ok := s.accounts[username]
+	if !ok {
+		return nil, repositories.ErrUserNotFound
+	}
+	var granted []string
+	for role := range account.roles {
+		granted = append(granted, role)
+	}
+	sort.Strings(granted)
+	return append([]string{entities.RolePatron}, granted...), nil
+}
+
+// GrantRole выдает пользователю роль. Повторная выдача ничего не меняет
+func (r *AuthRepository) GrantRole(ctx context.Context, username, role string) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, ok := s.accounts[username]
+	if !ok {
+		return repositories.ErrUserNotFound
+	}
+	account.roles[role] = true
+	return nil
+}
+
+// RevokeRole отзывает роль у пользователя
+func (r *AuthRepository) RevokeRole(ctx context.Context, username, role string) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, ok := s.accounts[username]
+	if !ok || !account.roles[role] {
+		return repositories.ErrRoleNotGranted
+	}
+	delete(account.roles, role)
+	return nil
+}
+
+// account ищет учетную запись пользователя, который не удален. Вызывается под блокировкой
+func (s *Store) account(username string) (*accountRow, bool) {
+	account, ok := s.accounts[username]
+	if !ok {
+		return nil, false
+	}
+	if _, ok := s.user(account.userID); !ok {
+		return nil, false
+	}
+	return account, true
+}
@@ -0,0 +1,172 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/entities"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+)
+
+type AuthorRepository struct {
+	store *Store
+}
+
+func NewAuthorRepository(store *Store) *AuthorRepository {
+	return &AuthorRepository{store: store}
+}
+
+// Add регистрирует автора. Авторы книг добавляются в справочник автоматически
+func (r *AuthorRepository) Add(ctx context.Context, author entities.Author) (entities.Author, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.authorByName(author.Name) != nil {
+		return entities.Author{}, repositories.ErrAuthorExists
+	}
+	author.ID = len(s.authors) + 1
+	s.authors = append(s.authors, &authorRow{Author: author})
+	return author, nil
+}
+
+// List возвращает авторов по алфавиту
+func (r *AuthorRepository) List(ctx context.Context) ([]entities.Author, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	authors := []entities.Author{}
+	for _, a := range s.authors {
+		if !a.deleted {
+			authors = append(authors, a.Author)
+		}
+	}
+	sort.Slice(authors, func(i, j int) bool { return authors[i].Name < authors[j].Name })
+	return authors, nil
+}
+
+// GetByID получает автора по ID
+func (r *AuthorRepository) GetByID(ctx context.Context, id int) (entities.Author, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	a, ok := s.author(id)
+	if !ok {
+		return entities.Author{}, repositories.ErrAuthorNotFound
+	}
+	return a.Author, nil
+}
+
+// Update меняет данные автора вместе с именем в его книгах
+func (r *AuthorRepository) Update(ctx context.Context, author entities.Author) (entities.Author, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.author(author.ID)
+	if !ok {
+		return entities.Author{}, repositories.ErrAuthorNotFound
+	}
+	if other := s.authorByName(author.Name); other != nil && other != a {
+		return entities.Author{}, repositories.ErrAuthorExists
+	}
+	a.Author = author
+	for _, b := range s.books {
+		if !b.deleted && b.authorID == author.ID {
+			b.author = author.Name
+		}
+	}
+	return a.Author, nil
+}
+
+// Delete удаляет автора. Автора с книгами можно удалить только вместе с ними (cascade),
+// и только если книги ни разу не выдавались и не ждут читателей по брони
+func (r *AuthorRepository) Delete(ctx context.Context, id int, cascade bool) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.author(id)
+	if !ok {
+		return repositories.ErrAuthorNotFound
+	}
+
+	books := map[int]bool{}
+	for _, b := range s.books {
+		if !b.deleted && b.authorID == id {
+			books[b.index] = true
+		}
+	}
+	if len(books) > 0 && !cascade {
+		return repositories.ErrAuthorHasBooks
+	}
+
+	for _, c := range s.copies {
+		if books[c.BookIndex] && c.Status != entities.CopyAvailable {
+			return repositories.ErrAuthorBooksInUse
+		}
+	}
+	for _, h := range s.holds {
+		if books[h.BookIndex] && (h.Status == entities.HoldWaiting || h.Status == entities.HoldReady) {
+			return repositories.ErrAuthorBooksInUse
+		}
+	}
+	for _, l := range s.loans {
+		if books[l.BookIndex] {
+			return repositories.ErrAuthorBooksInUse
+		}
+	}
+
+	for index := range books {
+		s.books[index-1].deleted = true
+	}
+	a.deleted = true
+	return nil
+}
+
+// ListBooks возвращает книги автора с числом экземпляров
+func (r *AuthorRepository) ListBooks(ctx context.Context, id int) ([]entities.Book, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.author(id); !ok {
+		return nil, repositories.ErrAuthorNotFound
+	}
+	books := []entities.Book{}
+	for _, b := range s.books {
+		if !b.deleted && b.authorID == id {
+			books = append(books, s.bookView(b))
+		}
+	}
+	return books, nil
+}
+
+// author ищет автора, который не удален
+func (s *Store) author(id int) (*authorRow, bool) {
+	if id < 1 || id > len(s.authors) || s.authors[id-1].deleted {
+		return nil, false
+	}
+	return s.authors[id-1], true
+}
+
+func (s *Store) authorByName(name string) *authorRow {
+	for _, a := range s.authors {
+		if !a.deleted && a.Name == name {
+			return a
+		}
+	}
+	return nil
+}
+
+// registerAuthor возвращает ID автора книги, добавляя его в справочник, если его там нет
+func (s *Store) registerAuthor(name string) int {
+	if a := s.authorByName(name); a != nil {
+		return a.ID
+	}
+	id := len(s.authors) + 1
+	s.authors = append(s.authors, &authorRow{Author: entities.Author{ID: id, Name: name}})
+	return id
+}
@@ -0,0 +1,389 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/entities"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+)
+
+type BookRepository struct {
+	store *Store
+}
+
+func NewBookRepository(store *Store) *BookRepository {
+	return &BookRepository{store: store}
+}
+
+// bookList поля каталога, те же, что у хранилища в базе
+var bookList = listSchema[entities.Book]{
+	fields: map[string]listField[entities.Book]{
+		"index":            {kind: kindInt, value: func(b entities.Book) interface{} { return b.Index }},
+		"book":             {kind: kindText, value: func(b entities.Book) interface{} { return b.Book }},
+		"author":           {kind: kindText, value: func(b entities.Book) interface{} { return b.Author }},
+		"take_count":       {kind: kindInt, value: func(b entities.Book) interface{} { return b.TakeCount }},
+		"available_copies": {kind: kindInt, value: func(b entities.Book) interface{} { return b.AvailableCopies }},
+		"total_copies":     {kind: kindInt, value: func(b entities.Book) interface{} { return b.TotalCopies }},
+		"available":        {kind: kindBool, value: func(b entities.Book) interface{} { return b.AvailableCopies > 0 }},
+	},
+	key: "index",
+}
+
+// List возвращает страницу каталога с фильтрами и сортировкой
+func (r *BookRepository) List(ctx context.Context, opts repositories.ListOptions) (repositories.Page[entities.Book], error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var books []entities.Book
+	for _, b := range s.books {
+		if !b.deleted {
+			books = append(books, s.bookView(b))
+		}
+	}
+	return bookList.list(books, opts)
+}
+
+// CreateBook добавляет книгу с copies экземплярами и регистрирует автора
+func (r *BookRepository) CreateBook(ctx context.Context, title, author string, copies int) (entities.Book, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, b := range s.books {
+		if !b.deleted && b.title == title && b.author == author {
+			return entities.Book{}, repositories.ErrBookExists
+		}
+	}
+
+	b := &bookRow{index: len(s.books) + 1, title: title, author: author, authorID: s.registerAuthor(author)}
+	s.books = append(s.books, b)
+	for n := 1; n <= copies; n++ {
+		s.copies = append(s.copies, &entities.Copy{
+			ID:        len(s.copies) + 1,
+			BookIndex: b.index,
+			Barcode:   entities.CopyBarcode(b.index, n),
+			Status:    entities.CopyAvailable,
+		})
+	}
+	return s.bookView(b), nil
+}
+
+// UpdateBook меняет название и автора книги
+func (r *BookRepository) UpdateBook(ctx context.Context, index int, title, author string) (entities.Book, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.book(index)
+	if !ok {
+		return entities.Book{}, repositories.ErrBookNotFound
+	}
+	for _, other := range s.books {
+		if !other.deleted && other != b && other.title == title && other.author == author {
+			return entities.Book{}, repositories.ErrBookExists
+		}
+	}
+	b.title, b.author, b.authorID = title, author, s.registerAuthor(author)
+	return s.bookView(b), nil
+}
+
+// AddCopy регистрирует новый экземпляр. Он сразу уходит первому в очереди, если она есть
+func (r *BookRepository) AddCopy(ctx context.Context, index int, barcode string, pickupWindow time.Duration) (entities.Copy, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.book(index)
+	if !ok {
+		return entities.Copy{}, repositories.ErrBookNotFound
+	}
+	if barcode == "" {
+		barcode = entities.CopyBarcode(index, s.bookView(b).TotalCopies+1)
+	}
+	for _, c := range s.copies {
+		if s.live(c.BookIndex) && c.Barcode == barcode {
+			return entities.Copy{}, repositories.ErrBarcodeExists
+		}
+	}
+
+	item := &entities.Copy{ID: len(s.copies) + 1, BookIndex: index, Barcode: barcode}
+	s.copies = append(s.copies, item)
+	s.assignNextHold(index, item.ID, pickupWindow)
+	return *item, nil
+}
+
+// ListCopies возвращает все экземпляры книги
+func (r *BookRepository) ListCopies(ctx context.Context, index int) ([]entities.Copy, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.book(index); !ok {
+		return nil, repositories.ErrBookNotFound
+	}
+	copies := []entities.Copy{}
+	for _, c := range s.copies {
+		if c.BookIndex == index {
+			copies = append(copies, *c)
+		}
+	}
+	return copies, nil
+}
+
+// TakeBook выдает экземпляр книги до dueAt. Экземпляр, отложенный для пользователя по брони,
+// выдается в первую очередь. Лимиты долга и числа книг на руках проверяются под той же блокировкой
+func (r *BookRepository) TakeBook(ctx context.Context, index, userID int, dueAt time.Time, barcode string, maxActive int, fineLimit int64) (entities.Loan, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.book(index)
+	if !ok {
+		return entities.Loan{}, repositories.ErrBookNotFound
+	}
+	if s.activeLoan(index, userID) != nil {
+		return entities.Loan{}, repositories.ErrAlreadyBorrowed
+	}
+	if balance := s.balance(userID); balance > fineLimit {
+		return entities.Loan{}, fmt.Errorf("%w: owed %d, limit %d", repositories.ErrFineLimit, balance, fineLimit)
+	}
+	if maxActive > 0 && s.activeLoans(userID) >= maxActive {
+		return entities.Loan{}, repositories.ErrLoanLimit
+	}
+
+	var item *entities.Copy
+	for _, h := range s.holds {
+		if h.BookIndex == index && h.UserID == userID && h.Status == entities.HoldReady {
+			h.Status = entities.HoldFulfilled
+			item = s.copies[h.copyID-1]
+			break
+		}
+	}
+	if item == nil {
+		for _, c := range s.copies {
+			if c.BookIndex == index && c.Status == entities.CopyAvailable && (barcode == "" || c.Barcode == barcode) {
+				item = c
+				break
+			}
+		}
+	}
+	if item == nil {
+		if barcode != "" {
+			return entities.Loan{}, repositories.ErrCopyNotAvailable
+		}
+		return entities.Loan{}, repositories.ErrBookTaken
+	}
+
+	item.Status = entities.CopyOnLoan
+	b.takeCount++
+	loan := &entities.Loan{
+		ID:        len(s.loans) + 1,
+		BookIndex: index,
+		CopyID:    item.ID,
+		Barcode:   item.Barcode,
+		Book:      b.title,
+		Author:    b.author,
+		UserID:    userID,
+		TakenAt:   s.now(),
+		DueAt:     dueAt,
+	}
+	s.loans = append(s.loans, loan)
+	return *loan, nil
+}
+
+// ReturnBook закрывает активную выдачу и начисляет fineRate за каждый начатый день просрочки.
+// Экземпляр откладывается первому в очереди или освобождается
+func (r *BookRepository) ReturnBook(ctx context.Context, index, userID int, pickupWindow time.Duration, fineRate int64) (entities.Book, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.book(index)
+	if !ok {
+		return entities.Book{}, repositories.ErrBookNotFound
+	}
+	loan := s.activeLoan(index, userID)
+	if loan == nil {
+		return entities.Book{}, repositories.ErrLoanNotFound
+	}
+
+	returnedAt := s.now()
+	loan.ReturnedAt = &returnedAt
+	if amount := entities.OverdueFine(loan.DueAt, returnedAt, fineRate); amount > 0 {
+		s.fines = append(s.fines, &entities.Fine{
+			ID:        len(s.fines) + 1,
+			UserID:    userID,
+			LoanID:    loan.ID,
+			BookIndex: index,
+			Amount:    amount,
+			Status:    entities.FineOutstanding,
+			CreatedAt: returnedAt,
+		})
+	}
+
+	s.assignNextHold(index, loan.CopyID, pickupWindow)
+	return s.bookView(b), nil
+}
+
+// RenewLoan продлевает активную выдачу на period. Новый срок отсчитывается от старого
+// или от текущего момента, если книга уже просрочена
+func (r *BookRepository) RenewLoan(ctx context.Context, index, userID int, period time.Duration, maxRenewals int) (entities.Loan, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	loan := s.activeLoan(index, userID)
+	if loan == nil {
+		return entities.Loan{}, repositories.ErrLoanNotFound
+	}
+	if loan.Renewals >= maxRenewals {
+		return entities.Loan{}, repositories.ErrRenewLimit
+	}
+	for _, h := range s.holds {
+		if h.BookIndex == index && h.Status == entities.HoldWaiting {
+			return entities.Loan{}, repositories.ErrBookOnHold
+		}
+	}
+
+	dueAt := loan.DueAt
+	if now := s.now(); dueAt.Before(now) {
+		dueAt = now
+	}
+	loan.DueAt = dueAt.Add(period)
+	loan.Renewals++
+	loan.Overdue = false
+	return *loan, nil
+}
+
+// ListLoans возвращает выдачи пользователя, начиная с последней
+func (r *BookRepository) ListLoans(ctx context.Context, userID int) ([]entities.Loan, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var loans []entities.Loan
+	for i := len(s.loans) - 1; i >= 0; i-- {
+		if s.loans[i].UserID != userID || !s.live(s.loans[i].BookIndex) {
+			continue
+		}
+		loan := *s.loans[i]
+		b := s.books[loan.BookIndex-1]
+		loan.Book, loan.Author = b.title, b.author
+		loans = append(loans, loan)
+	}
+	return loans, nil
+}
+
+// PlaceHold ставит пользователя в конец очереди на занятую книгу
+func (r *BookRepository) PlaceHold(ctx context.Context, index, userID int) (entities.Hold, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.book(index)
+	if !ok {
+		return entities.Hold{}, repositories.ErrBookNotFound
+	}
+	if s.bookView(b).AvailableCopies > 0 {
+		return entities.Hold{}, repositories.ErrBookAvailable
+	}
+	if s.openHold(index, userID) != nil || s.activeLoan(index, userID) != nil {
+		return entities.Hold{}, repositories.ErrHoldExists
+	}
+
+	hold := &holdRow{Hold: entities.Hold{
+		ID:        len(s.holds) + 1,
+		BookIndex: index,
+		Book:      b.title,
+		UserID:    userID,
+		Status:    entities.HoldWaiting,
+		CreatedAt: s.now(),
+	}}
+	s.holds = append(s.holds, hold)
+
+	result := hold.Hold
+	result.Position = s.position(hold)
+	return result, nil
+}
+
+// CancelHold отменяет бронь пользователя. Отложенный по ней экземпляр переходит следующему в очереди
+func (r *BookRepository) CancelHold(ctx context.Context, index, userID int, pickupWindow time.Duration) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.book(index); !ok {
+		return repositories.ErrBookNotFound
+	}
+	hold := s.openHold(index, userID)
+	if hold == nil {
+		return repositories.ErrHoldNotFound
+	}
+
+	ready := hold.Status == entities.HoldReady
+	hold.Status = entities.HoldCancelled
+	if ready {
+		s.assignNextHold(index, hold.copyID, pickupWindow)
+	}
+	return nil
+}
+
+// ListHolds возвращает брони пользователя вместе с местом в очереди
+func (r *BookRepository) ListHolds(ctx context.Context, userID int) ([]entities.Hold, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var holds []entities.Hold
+	for i := len(s.holds) - 1; i >= 0; i-- {
+		h := s.holds[i]
+		if h.UserID != userID || !s.live(h.BookIndex) {
+			continue
+		}
+		hold := h.Hold
+		hold.Book = s.books[hold.BookIndex-1].title
+		switch hold.Status {
+		case entities.HoldWaiting:
+			hold.Position = s.position(h)
+		case entities.HoldReady:
+			hold.Barcode = s.copies[h.copyID-1].Barcode
+		}
+		holds = append(holds, hold)
+	}
+	return holds, nil
+}
+
+// openHold ожидающая или готовая бронь пользователя на книгу
+func (s *Store) openHold(index, userID int) *holdRow {
+	for _, h := range s.holds {
+		if h.BookIndex == index && h.UserID == userID && (h.Status == entities.HoldWaiting || h.Status == entities.HoldReady) {
+			return h
+		}
+	}
+	return nil
+}
+
+// position место ожидающей брони в очереди на книгу
+func (s *Store) position(hold *holdRow) int {
+	n := 0
+	for _, h := range s.holds {
+		if h.BookIndex == hold.BookIndex && h.Status == entities.HoldWaiting && h.ID <= hold.ID {
+			n++
+		}
+	}
+	return n
+}
+
+// activeLoans считает книги, которые пользователь еще не вернул. Вызывается под блокировкой
+func (s *Store) activeLoans(userID int) int {
+	active := 0
+	for _, l := range s.loans {
+		if l.UserID == userID && l.ReturnedAt == nil {
+			active++
+		}
+	}
+	return active
+}
@@ -0,0 +1,250 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/entities"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+)
+
+func TestHoldQueue(t *testing.T) {
+	store := NewStore()
+	repo := NewBookRepository(store)
+	fines := NewFineRepository(store)
+	ctx := context.Background()
+
+	book, err := repo.CreateBook(ctx, "Title", "Author", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const owner, first, second = 1, 2, 3
+
+	if _, err := repo.PlaceHold(ctx, book.Index, first); !errors.Is(err, repositories.ErrBookAvailable) {
+		t.Fatalf("expected ErrBookAvailable, got %v", err)
+	}
+	if _, err := repo.TakeBook(ctx, book.Index, owner, time.Now().Add(-25*time.Hour), "", 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.TakeBook(ctx, book.Index, first, time.Now(), "", 0, 0); !errors.Is(err, repositories.ErrBookTaken) {
+		t.Fatalf("expected ErrBookTaken, got %v", err)
+	}
+
+	h1, err := repo.PlaceHold(ctx, book.Index, first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h2, err := repo.PlaceHold(ctx, book.Index, second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h1.Position != 1 || h2.Position != 2 {
+		t.Fatalf("unexpected queue positions %d and %d", h1.Position, h2.Position)
+	}
+	if _, err := repo.RenewLoan(ctx, book.Index, owner, time.Hour, 2); !errors.Is(err, repositories.ErrBookOnHold) {
+		t.Fatalf("expected ErrBookOnHold, got %v", err)
+	}
+
+	// Возврат на второй день просрочки откладывает книгу первому в очереди
+	returned, err := repo.ReturnBook(ctx, book.Index, owner, time.Hour, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !*returned.Block {
+		t.Fatal("returned book must stay blocked for the next holder")
+	}
+	if balance, _ := fines.Balance(ctx, owner); balance != 200 {
+		t.Fatalf("balance = %d, want 200", balance)
+	}
+	if _, err := repo.TakeBook(ctx, book.Index, second, time.Now(), "", 0, 0); !errors.Is(err, repositories.ErrBookTaken) {
+		t.Fatalf("expected ErrBookTaken for second holder, got %v", err)
+	}
+
+	// Отмена готовой брони передает книгу следующему
+	if err := repo.CancelHold(ctx, book.Index, first, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	holds, err := repo.ListHolds(ctx, second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(holds) != 1 || holds[0].Status != entities.HoldReady || holds[0].Barcode == "" {
+		t.Fatalf("second holder must get the copy, got %+v", holds)
+	}
+	if _, err := repo.TakeBook(ctx, book.Index, second, time.Now(), "", 0, 0); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTakeBookConcurrent(t *testing.T) {
+	repo := NewBookRepository(NewStore())
+	ctx := context.Background()
+	book, err := repo.CreateBook(ctx, "Title", "Author", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		success int
+	)
+	for userID := 1; userID <= 20; userID++ {
+		wg.Add(1)
+		go func(userID int) {
+			defer wg.Done()
+			_, err := repo.TakeBook(ctx, book.Index, userID, time.Now().Add(time.Hour), "", 0, 0)
+			if err == nil {
+				mu.Lock()
+				success++
+				mu.Unlock()
+			} else if !errors.Is(err, repositories.ErrBookTaken) {
+				t.Error(err)
+			}
+		}(userID)
+	}
+	wg.Wait()
+
+	if success != 1 {
+		t.Fatalf("%d readers took the only copy", success)
+	}
+}
+
+func TestTakeBookLimits(t *testing.T) {
+	store := NewStore()
+	repo := NewBookRepository(store)
+	ctx := context.Background()
+	const reader = 1
+
+	var books []entities.Book
+	for _, title := range []string{"A", "B", "C"} {
+		book, err := repo.CreateBook(ctx, title, "Author", 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		books = append(books, book)
+	}
+
+	// Книга, возвращенная с просрочкой, оставляет долг 100
+	if _, err := repo.TakeBook(ctx, books[0].Index, reader, time.Now().Add(-time.Hour), "", 2, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.ReturnBook(ctx, books[0].Index, reader, time.Hour, 100); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.TakeBook(ctx, books[1].Index, reader, time.Now(), "", 2, 99); !errors.Is(err, repositories.ErrFineLimit) {
+		t.Fatalf("expected ErrFineLimit, got %v", err)
+	}
+
+	for _, book := range books[:2] {
+		if _, err := repo.TakeBook(ctx, book.Index, reader, time.Now(), "", 2, 100); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := repo.TakeBook(ctx, books[2].Index, reader, time.Now(), "", 2, 100); !errors.Is(err, repositories.ErrLoanLimit) {
+		t.Fatalf("expected ErrLoanLimit, got %v", err)
+	}
+	if _, err := repo.TakeBook(ctx, books[2].Index, reader, time.Now(), "", 0, 100); err != nil {
+		t.Fatalf("0 must lift the loan limit, got %v", err)
+	}
+}
+
+func TestListCursor(t *testing.T) {
+	repo := NewBookRepository(NewStore())
+	ctx := context.Background()
+	for _, title := range []string{"C", "A", "B", "A2"} {
+		if _, err := repo.CreateBook(ctx, title, "Author", 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	opts := repositories.ListOptions{Limit: 3, Sort: []repositories.SortField{{Field: "book", Desc: true}}}
+	page, err := repo.List(ctx, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 4 || len(page.Items) != 3 || page.Items[0].Book != "C" || page.NextCursor == "" {
+		t.Fatalf("unexpected first page %+v", page)
+	}
+
+	opts.After = page.NextCursor
+	page, err = repo.List(ctx, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Items) != 1 || page.Items[0].Book != "A" || page.NextCursor != "" {
+		t.Fatalf("unexpected second page %+v", page)
+	}
+
+	opts.Sort = nil
+	if _, err := repo.List(ctx, opts); !errors.Is(err, repositories.ErrInvalidListOptions) {
+		t.Fatalf("cursor for another sort: expected ErrInvalidListOptions, got %v", err)
+	}
+
+	page, err = repo.List(ctx, repositories.ListOptions{Filters: []repositories.Filter{{Field: "book", Op: repositories.FilterContains, Value: "a"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 2 {
+		t.Fatalf("contains filter matched %d books, want 2", page.Total)
+	}
+}
+
+func TestSearchHighlightsAndFacets(t *testing.T) {
+	repo := NewBookRepository(NewStore())
+	ctx := context.Background()
+	for _, b := range []struct{ title, author string }{
+		{"War and Peace", "Leo Tolstoy"},
+		{"Warlock", "Wilbur Smith"},
+		{"Anna Karenina", "Leo Tolstoy"},
+	} {
+		if _, err := repo.CreateBook(ctx, b.title, b.author, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := repo.Search(ctx, repositories.BookSearch{Query: "war", Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != 2 || result.Hits[0].Highlight.Book != "<mark>War</mark> and Peace" {
+		t.Fatalf("unexpected hits %+v", result.Hits)
+	}
+	if len(result.Facets.Authors) != 2 || len(result.Facets.Availability) != 1 {
+		t.Fatalf("unexpected facets %+v", result.Facets)
+	}
+
+	result, err = repo.Search(ctx, repositories.BookSearch{Query: "war", Author: "leo tolstoy", Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != 1 || len(result.Facets.Authors) != 2 {
+		t.Fatalf("author filter must narrow hits but not facets: %+v", result)
+	}
+}
+
+func TestSearchHighlightEscapesText(t *testing.T) {
+	repo := NewBookRepository(NewStore())
+	ctx := context.Background()
+	if _, err := repo.CreateBook(ctx, "War <script>alert(1)</script>", "Tom & Jerry", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := repo.Search(ctx, repositories.BookSearch{Query: "war script", Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Hits) != 1 {
+		t.Fatalf("unexpected hits %+v", result.Hits)
+	}
+	want := "<mark>War</mark> &lt;<mark>script</mark>&gt;alert(1)&lt;/<mark>script</mark>&gt;"
+	if got := result.Hits[0].Highlight.Book; got != want {
+		t.Fatalf("highlight = %q, want %q", got, want)
+	}
+	if got := result.Hits[0].Highlight.Author; got != "Tom &amp; Jerry" {
+		t.Fatalf("author highlight = %q", got)
+	}
+}
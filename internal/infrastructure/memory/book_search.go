@@ -0,0 +1,134 @@
+package memory
+
+import (
+	"context"
+	"html"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/entities"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+)
+
+// Search ищет книги по словам запроса в названии и авторе. Слово совпадает, если с него
+// начинается слово книги, регистр не важен. Морфологии и опечаток, как в базе, здесь нет:
+// ранг равен числу совпавших слов
+func (r *BookRepository) Search(ctx context.Context, search repositories.BookSearch) (entities.BookSearchResult, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	terms := searchTerms(search.Query)
+	result := entities.BookSearchResult{
+		Hits:   []entities.BookSearchHit{},
+		Facets: entities.BookFacets{Authors: []entities.FacetCount{}, Availability: []entities.FacetCount{}},
+	}
+
+	var (
+		hits         []entities.BookSearchHit
+		authors      = map[string]int{}
+		availability = map[bool]int{}
+	)
+	for _, b := range s.books {
+		if b.deleted {
+			continue
+		}
+		title, titleHits := highlight(b.title, terms)
+		author, authorHits := highlight(b.author, terms)
+		if titleHits+authorHits == 0 {
+			continue
+		}
+
+		book := s.bookView(b)
+		authors[book.Author]++
+		availability[book.AvailableCopies > 0]++
+
+		if search.Author != "" && !strings.EqualFold(book.Author, search.Author) {
+			continue
+		}
+		if search.Available != nil && (book.AvailableCopies > 0) != *search.Available {
+			continue
+		}
+		hits = append(hits, entities.BookSearchHit{
+			Book:      book,
+			Rank:      float64(titleHits + authorHits),
+			Highlight: entities.BookHighlight{Book: title, Author: author},
+		})
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Rank > hits[j].Rank })
+	result.Total = len(hits)
+	if search.Offset < len(hits) {
+		hits = hits[search.Offset:]
+		if len(hits) > search.Limit {
+			hits = hits[:search.Limit]
+		}
+		result.Hits = append(result.Hits, hits...)
+	}
+
+	for author, count := range authors {
+		result.Facets.Authors = append(result.Facets.Authors, entities.FacetCount{Value: author, Count: count})
+	}
+	sortFacets(result.Facets.Authors)
+	if len(result.Facets.Authors) > repositories.MaxAuthorFacets {
+		result.Facets.Authors = result.Facets.Authors[:repositories.MaxAuthorFacets]
+	}
+	for available, count := range availability {
+		result.Facets.Availability = append(result.Facets.Availability, entities.FacetCount{Value: strconv.FormatBool(available), Count: count})
+	}
+	sortFacets(result.Facets.Availability)
+	return result, nil
+}
+
+// searchTerms слова запроса в нижнем регистре без знаков препинания
+func searchTerms(query string) []string {
+	return strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// highlight выделяет в тексте слова, начинающиеся с одного из terms, и возвращает их число.
+// Сам текст экранируется, в ответе безопасны только теги <mark>
+func highlight(text string, terms []string) (string, int) {
+	var (
+		b     strings.Builder
+		count int
+	)
+	words := strings.FieldsFunc(text, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+	rest := text
+	for _, word := range words {
+		at := strings.Index(rest, word)
+		b.WriteString(html.EscapeString(rest[:at]))
+		rest = rest[at+len(word):]
+
+		if matchesTerm(strings.ToLower(word), terms) {
+			count++
+			b.WriteString("<mark>" + html.EscapeString(word) + "</mark>")
+			continue
+		}
+		b.WriteString(html.EscapeString(word))
+	}
+	b.WriteString(html.EscapeString(rest))
+	return b.String(), count
+}
+
+func matchesTerm(word string, terms []string) bool {
+	for _, term := range terms {
+		if strings.HasPrefix(word, term) {
+			return true
+		}
+	}
+	return false
+}
+
+// sortFacets упорядочивает значения фасета по убыванию числа книг, затем по значению
+func sortFacets(facets []entities.FacetCount) {
+	sort.Slice(facets, func(i, j int) bool {
+		if facets[i].Count != facets[j].Count {
+			return facets[i].Count > facets[j].Count
+		}
+		return facets[i].Value < facets[j].Value
+	})
+}
@@ -0,0 +1,102 @@
+package memory
+
+import (
+	"context"
+
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/entities"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+)
+
+type FineRepository struct {
+	store *Store
+}
+
+func NewFineRepository(store *Store) *FineRepository {
+	return &FineRepository{store: store}
+}
+
+// List возвращает все штрафы пользователя, начиная с последних
+func (r *FineRepository) List(ctx context.Context, userID int) ([]entities.Fine, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var fines []entities.Fine
+	for i := len(s.fines) - 1; i >= 0; i-- {
+		if s.fines[i].UserID == userID && s.live(s.fines[i].BookIndex) {
+			fines = append(fines, *s.fines[i])
+		}
+	}
+	return fines, nil
+}
+
+// Balance возвращает неоплаченный остаток по всем открытым штрафам пользователя
+func (r *FineRepository) Balance(ctx context.Context, userID int) (int64, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.balance(userID), nil
+}
+
+// balance долг пользователя по открытым штрафам. Вызывается под блокировкой
+func (s *Store) balance(userID int) int64 {
+	var balance int64
+	for _, f := range s.fines {
+		if f.UserID == userID && f.Status == entities.FineOutstanding {
+			balance += f.Amount - f.Paid
+		}
+	}
+	return balance
+}
+
+// Pay записывает оплату штрафа. Штраф закрывается, когда оплачен полностью
+func (r *FineRepository) Pay(ctx context.Context, id int, amount int64, note string) (entities.Fine, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fine, err := s.openFine(id)
+	if err != nil {
+		return entities.Fine{}, err
+	}
+	if amount <= 0 || amount > fine.Amount-fine.Paid {
+		return entities.Fine{}, repositories.ErrInvalidAmount
+	}
+
+	fine.Paid += amount
+	if fine.Paid == fine.Amount {
+		closedAt := s.now()
+		fine.Status = entities.FinePaid
+		fine.ClosedAt = &closedAt
+	}
+	return *fine, nil
+}
+
+// Waive списывает остаток штрафа
+func (r *FineRepository) Waive(ctx context.Context, id int, note string) (entities.Fine, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fine, err := s.openFine(id)
+	if err != nil {
+		return entities.Fine{}, err
+	}
+	closedAt := s.now()
+	fine.Status = entities.FineWaived
+	fine.Note = note
+	fine.ClosedAt = &closedAt
+	return *fine, nil
+}
+
+func (s *Store) openFine(id int) (*entities.Fine, error) {
+	if id < 1 || id > len(s.fines) || !s.live(s.fines[id-1].BookIndex) {
+		return nil, repositories.ErrFineNotFound
+	}
+	fine := s.fines[id-1]
+	if fine.Status != entities.FineOutstanding {
+		return nil, repositories.ErrFineClosed
+	}
+	return fine, nil
+}
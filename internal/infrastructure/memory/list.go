@@ -0,0 +1,263 @@
+package memory
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+)
+
+type fieldKind int
+
+const (
+	kindText fieldKind = iota
+	kindInt
+	kindBool
+)
+
+// listField поле, по которому можно фильтровать и сортировать
+type listField[T any] struct {
+	kind  fieldKind
+	value func(T) interface{}
+}
+
+// listSchema разрешенные поля списка и ключ, замыкающий сортировку. Повторяет списки
+// из пакета postgres: те же имена полей, ошибки и курсор по значениям последней строки
+type listSchema[T any] struct {
+	fields map[string]listField[T]
+	key    string
+}
+
+type cursor struct {
+	Sort   string        `json:"s"`
+	Values []interface{} `json:"v"`
+}
+
+func (s listSchema[T]) list(items []T, opts repositories.ListOptions) (repositories.Page[T], error) {
+	limit := opts.Limit
+	if limit == 0 {
+		limit = repositories.DefaultListLimit
+	}
+	if limit < 1 || limit > repositories.MaxListLimit {
+		return repositories.Page[T]{}, fmt.Errorf("%w: limit must be between 1 and %d", repositories.ErrInvalidListOptions, repositories.MaxListLimit)
+	}
+
+	sortFields, err := s.sortFields(opts.Sort)
+	if err != nil {
+		return repositories.Page[T]{}, err
+	}
+
+	matched := []T{}
+	for _, item := range items {
+		ok, err := s.matches(item, opts.Filters)
+		if err != nil {
+			return repositories.Page[T]{}, err
+		}
+		if ok {
+			matched = append(matched, item)
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		return s.compare(sortFields, s.values(sortFields, matched[i]), s.values(sortFields, matched[j])) < 0
+	})
+
+	page := repositories.Page[T]{Items: []T{}, Total: len(matched)}
+	if opts.After != "" {
+		after, err := s.decodeCursor(opts.After, sortFields)
+		if err != nil {
+			return repositories.Page[T]{}, err
+		}
+		start := sort.Search(len(matched), func(i int) bool {
+			return s.compare(sortFields, s.values(sortFields, matched[i]), after) > 0
+		})
+		matched = matched[start:]
+	}
+
+	if len(matched) > limit {
+		page.Items = append(page.Items, matched[:limit]...)
+		page.NextCursor = s.encodeCursor(sortFields, matched[limit-1])
+		return page, nil
+	}
+	page.Items = append(page.Items, matched...)
+	return page, nil
+}
+
+func (s listSchema[T]) sortFields(requested []repositories.SortField) ([]repositories.SortField, error) {
+	fields := make([]repositories.SortField, 0, len(requested)+1)
+	seen := map[string]bool{}
+	for _, f := range requested {
+		if _, ok := s.fields[f.Field]; !ok {
+			return nil, fmt.Errorf("%w: cannot sort by %q", repositories.ErrInvalidListOptions, f.Field)
+		}
+		if seen[f.Field] {
+			return nil, fmt.Errorf("%w: %q appears twice in sort", repositories.ErrInvalidListOptions, f.Field)
+		}
+		seen[f.Field] = true
+		fields = append(fields, f)
+	}
+	if !seen[s.key] {
+		fields = append(fields, repositories.SortField{Field: s.key})
+	}
+	return fields, nil
+}
+
+func (s listSchema[T]) matches(item T, filters []repositories.Filter) (bool, error) {
+	for _, filter := range filters {
+		field, ok := s.fields[filter.Field]
+		if !ok {
+			return false, fmt.Errorf("%w: cannot filter by %q", repositories.ErrInvalidListOptions, filter.Field)
+		}
+		value := field.value(item)
+
+		switch {
+		case filter.Op == repositories.FilterContains && field.kind == kindText:
+			if !strings.Contains(strings.ToLower(value.(string)), strings.ToLower(filter.Value)) {
+				return false, nil
+			}
+			continue
+		case filter.Op == repositories.FilterContains:
+			return false, fmt.Errorf("%w: %q does not support ~=", repositories.ErrInvalidListOptions, filter.Field)
+		case filter.Op != repositories.FilterEquals:
+			return false, fmt.Errorf("%w: unknown filter operator %q", repositories.ErrInvalidListOptions, filter.Op)
+		}
+
+		want, err := parseValue(field.kind, filter.Value)
+		if err != nil {
+			return false, fmt.Errorf("%w: %s: %v", repositories.ErrInvalidListOptions, filter.Field, err)
+		}
+		if field.kind == kindText {
+			if !strings.EqualFold(value.(string), want.(string)) {
+				return false, nil
+			}
+		} else if value != want {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (s listSchema[T]) values(sortFields []repositories.SortField, item T) []interface{} {
+	values := make([]interface{}, len(sortFields))
+	for i, f := range sortFields {
+		values[i] = s.fields[f.Field].value(item)
+	}
+	return values
+}
+
+// compare сравнивает строки по полям сортировки с учетом направления каждого поля
+func (s listSchema[T]) compare(sortFields []repositories.SortField, a, b []interface{}) int {
+	for i, f := range sortFields {
+		c := compareValues(a[i], b[i])
+		if f.Desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+func compareValues(a, b interface{}) int {
+	switch a := a.(type) {
+	case int:
+		b := b.(int)
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+	case string:
+		return strings.Compare(a, b.(string))
+	case bool:
+		b := b.(bool)
+		switch {
+		case !a && b:
+			return -1
+		case a && !b:
+			return 1
+		}
+	}
+	return 0
+}
+
+func (s listSchema[T]) encodeCursor(sortFields []repositories.SortField, last T) string {
+	raw, _ := json.Marshal(cursor{Sort: sortString(sortFields), Values: s.values(sortFields, last)})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func (s listSchema[T]) decodeCursor(token string, sortFields []repositories.SortField) ([]interface{}, error) {
+	invalid := fmt.Errorf("%w: invalid cursor", repositories.ErrInvalidListOptions)
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, invalid
+	}
+	var c cursor
+	if err := json.Unmarshal(raw, &c); err != nil || len(c.Values) != len(sortFields) {
+		return nil, invalid
+	}
+	if c.Sort != sortString(sortFields) {
+		return nil, fmt.Errorf("%w: cursor was issued for a different sort", repositories.ErrInvalidListOptions)
+	}
+
+	values := make([]interface{}, len(sortFields))
+	for i, f := range sortFields {
+		value, ok := cursorValue(s.fields[f.Field].kind, c.Values[i])
+		if !ok {
+			return nil, invalid
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+func sortString(fields []repositories.SortField) string {
+	parts := make([]string, len(fields))
+	for i, f := range fields {
+		parts[i] = f.Field
+		if f.Desc {
+			parts[i] = "-" + f.Field
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+func parseValue(kind fieldKind, value string) (interface{}, error) {
+	switch kind {
+	case kindInt:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an integer", value)
+		}
+		return n, nil
+	case kindBool:
+		switch value {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+		return nil, fmt.Errorf("%q is not true or false", value)
+	}
+	return value, nil
+}
+
+// cursorValue приводит значение из JSON курсора к типу поля
+func cursorValue(kind fieldKind, value interface{}) (interface{}, bool) {
+	switch kind {
+	case kindInt:
+		n, ok := value.(float64)
+		return int(n), ok && n == float64(int(n))
+	case kindBool:
+		b, ok := value.(bool)
+		return b, ok
+	}
+	str, ok := value.(string)
+	return str, ok
+}
@@ -0,0 +1,137 @@
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/entities"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+)
+
+var (
+	_ repositories.BookRepository   = (*BookRepository)(nil)
+	_ repositories.UserRepository   = (*UserRepository)(nil)
+	_ repositories.AuthorRepository = (*AuthorRepository)(nil)
+	_ repositories.FineRepository   = (*FineRepository)(nil)
+	_ repositories.AuthRepository   = (*AuthRepository)(nil)
+	_ repositories.APIKeyRepository = (*APIKeyRepository)(nil)
+)
+
+// Store данные всех хранилищ в памяти. Выдачи, брони и штрафы связаны так же, как таблицы
+// в базе, поэтому хранилища работают под одной блокировкой. Записи не удаляются из срезов,
+// а помечаются удаленными, поэтому ID записи на единицу больше ее номера в срезе
+type Store struct {
+	mu      sync.RWMutex
+	books   []*bookRow
+	copies  []*entities.Copy
+	loans   []*entities.Loan
+	holds   []*holdRow
+	fines   []*entities.Fine
+	users   []*entities.User
+	authors []*authorRow
+	apiKeys []*apiKeyRow
+
+	accounts      map[string]*accountRow      // По имени пользователя
+	refreshTokens map[string]*refreshTokenRow // По хешу токена
+	deniedTokens  map[string]time.Time        // jti отозванного access-токена и срок его действия
+	authTokens    map[string]*authTokenRow    // По jti
+
+	now func() time.Time
+}
+
+type bookRow struct {
+	index     int
+	title     string
+	author    string
+	authorID  int
+	takeCount int
+	deleted   bool // Удалена вместе с автором
+}
+
+type authorRow struct {
+	entities.Author
+	deleted bool
+}
+
+// holdRow бронь и экземпляр, отложенный по ней
+type holdRow struct {
+	entities.Hold
+	copyID int
+}
+
+func NewStore() *Store {
+	return &Store{
+		accounts:      map[string]*accountRow{},
+		refreshTokens: map[string]*refreshTokenRow{},
+		deniedTokens:  map[string]time.Time{},
+		authTokens:    map[string]*authTokenRow{},
+		now:           time.Now,
+	}
+}
+
+// book ищет книгу по индексу. Вызывается под блокировкой
+func (s *Store) book(index int) (*bookRow, bool) {
+	if !s.live(index) {
+		return nil, false
+	}
+	return s.books[index-1], true
+}
+
+// live сообщает, есть ли книга в каталоге. Записи удаленных книг не видны через хранилища
+func (s *Store) live(index int) bool {
+	return index >= 1 && index <= len(s.books) && !s.books[index-1].deleted
+}
+
+// bookView собирает книгу с числом экземпляров и сроками, как ее возвращает база
+func (s *Store) bookView(b *bookRow) entities.Book {
+	book := entities.Book{Index: b.index, Book: b.title, Author: b.author, TakeCount: b.takeCount}
+	for _, c := range s.copies {
+		if c.BookIndex != b.index {
+			continue
+		}
+		book.TotalCopies++
+		if c.Status == entities.CopyAvailable {
+			book.AvailableCopies++
+		}
+	}
+	for _, l := range s.loans {
+		if l.BookIndex != b.index || l.ReturnedAt != nil {
+			continue
+		}
+		if book.DueAt == nil || l.DueAt.Before(*book.DueAt) {
+			dueAt := l.DueAt
+			book.DueAt = &dueAt
+		}
+		book.Overdue = book.Overdue || l.Overdue
+	}
+	block := book.AvailableCopies == 0
+	book.Block = &block
+	return book
+}
+
+// activeLoan выдача книги пользователю, которая еще не закрыта
+func (s *Store) activeLoan(index, userID int) *entities.Loan {
+	for _, l := range s.loans {
+		if l.BookIndex == index && l.UserID == userID && l.ReturnedAt == nil {
+			return l
+		}
+	}
+	return nil
+}
+
+// assignNextHold откладывает освободившийся экземпляр для первого в очереди
+// или делает его свободным, если очередь пуста
+func (s *Store) assignNextHold(index, copyID int, pickupWindow time.Duration) {
+	item := s.copies[copyID-1]
+	for _, h := range s.holds {
+		if h.BookIndex == index && h.Status == entities.HoldWaiting {
+			deadline := s.now().Add(pickupWindow)
+			h.Status = entities.HoldReady
+			h.copyID = copyID
+			h.PickupDeadline = &deadline
+			item.Status = entities.CopyOnHold
+			return
+		}
+	}
+	item.Status = entities.CopyAvailable
+}
@@ -0,0 +1,125 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+)
+
+// refreshTokenRow refresh-токен сессии. Хранится только его хеш
+type refreshTokenRow struct {
+	familyID  string
+	username  string
+	expiresAt time.Time
+	used      bool
+	revoked   bool
+}
+
+// CreateRefreshToken сохраняет хеш нового refresh-токена в семействе familyID
+func (r *AuthRepository) CreateRefreshToken(ctx context.Context, username, familyID, tokenHash string, expiresAt time.Time) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.refreshTokens[tokenHash] = &refreshTokenRow{familyID: familyID, username: username, expiresAt: expiresAt}
+	return nil
+}
+
+// RotateRefreshToken погашает refresh-токен и выпускает в том же семействе новый.
+// Повторное предъявление уже погашенного токена отзывает все семейство
+func (r *AuthRepository) RotateRefreshToken(ctx context.Context, oldHash, newHash string, expiresAt time.Time) (username, familyID string, err error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token, ok := s.refreshTokens[oldHash]
+	if !ok || token.revoked {
+		return "", "", repositories.ErrTokenInvalid
+	}
+	if token.used {
+		// Токен уже обменивали: его украли либо у клиента, либо у нас, закрываем всю сессию
+		s.revokeFamily(token.familyID)
+		return "", "", repositories.ErrTokenReused
+	}
+	if s.now().After(token.expiresAt) {
+		return "", "", repositories.ErrTokenInvalid
+	}
+
+	token.used = true
+	s.refreshTokens[newHash] = &refreshTokenRow{familyID: token.familyID, username: token.username, expiresAt: expiresAt}
+	return token.username, token.familyID, nil
+}
+
+// RevokeFamily отзывает все refresh-токены сессии
+func (r *AuthRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.revokeFamily(familyID)
+	return nil
+}
+
+// DenyAccessToken запрещает access-токен с указанным jti до истечения его срока
+func (r *AuthRepository) DenyAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.deniedTokens[jti]; !ok {
+		s.deniedTokens[jti] = expiresAt
+	}
+	return nil
+}
+
+// IsAccessTokenDenied сообщает, отозван ли access-токен
+func (r *AuthRepository) IsAccessTokenDenied(ctx context.Context, jti string) (bool, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, denied := s.deniedTokens[jti]
+	return denied, nil
+}
+
+// PurgeExpiredTokens удаляет истекшие записи списка отзыва и refresh-токенов
+func (r *AuthRepository) PurgeExpiredTokens(ctx context.Context) (int64, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	var total int64
+	for jti, expiresAt := range s.deniedTokens {
+		if expiresAt.Before(now) {
+			delete(s.deniedTokens, jti)
+			total++
+		}
+	}
+	for hash, token := range s.refreshTokens {
+		if token.expiresAt.Before(now) {
+			delete(s.refreshTokens, hash)
+			total++
+		}
+	}
+	return total, nil
+}
+
+// revokeFamily отзывает refresh-токены семейства. Вызывается под блокировкой
+func (s *Store) revokeFamily(familyID string) {
+	for _, token := range s.refreshTokens {
+		if token.familyID == familyID {
+			token.revoked = true
+		}
+	}
+}
+
+// revokeSessions отзывает все refresh-токены пользователя. Вызывается под блокировкой
+func (s *Store) revokeSessions(username string) {
+	for _, token := range s.refreshTokens {
+		if token.username == username {
+			token.revoked = true
+		}
+	}
+}
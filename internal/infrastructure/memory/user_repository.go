@@ -0,0 +1,129 @@
+package memory
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/entities"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+)
+
+type UserRepository struct {
+	store *Store
+}
+
+func NewUserRepository(store *Store) *UserRepository {
+	return &UserRepository{store: store}
+}
+
+// userList поля пользователей, те же, что у хранилища в базе
+var userList = listSchema[entities.User]{
+	fields: map[string]listField[entities.User]{
+		"id":       {kind: kindInt, value: func(u entities.User) interface{} { return u.ID }},
+		"username": {kind: kindText, value: func(u entities.User) interface{} { return u.Username }},
+		"name":     {kind: kindText, value: func(u entities.User) interface{} { return u.Name }},
+		"email":    {kind: kindText, value: func(u entities.User) interface{} { return u.Email }},
+	},
+	key: "id",
+}
+
+// Create добавляет пользователя и возвращает его с присвоенным ID
+func (r *UserRepository) Create(ctx context.Context, user entities.User) (entities.User, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.emailTaken(user.Email, 0) {
+		return entities.User{}, repositories.ErrEmailExists
+	}
+	created := &entities.User{ID: len(s.users) + 1, Name: user.Name, Email: user.Email}
+	s.users = append(s.users, created)
+	return *created, nil
+}
+
+// GetByID получает пользователя по ID. Удаленные пользователи не находятся
+func (r *UserRepository) GetByID(ctx context.Context, id int) (entities.User, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user, ok := s.user(id)
+	if !ok {
+		return entities.User{}, repositories.ErrUserNotFound
+	}
+	return *user, nil
+}
+
+// Update обновляет имя и почту пользователя
+func (r *UserRepository) Update(ctx context.Context, user entities.User) (entities.User, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.user(user.ID)
+	if !ok {
+		return entities.User{}, repositories.ErrUserNotFound
+	}
+	if s.emailTaken(user.Email, user.ID) {
+		return entities.User{}, repositories.ErrEmailExists
+	}
+	stored.Name, stored.Email = user.Name, user.Email
+	return *stored, nil
+}
+
+// Delete помечает пользователя как удаленного и закрывает его сессии
+func (r *UserRepository) Delete(ctx context.Context, id int) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.user(id)
+	if !ok {
+		return repositories.ErrUserNotFound
+	}
+	deletedAt := s.now().Format(time.RFC3339)
+	user.DeletedAt = &deletedAt
+
+	// Открытые сессии удаленного пользователя больше не продлеваются
+	if user.Username != "" {
+		s.revokeSessions(user.Username)
+	}
+	return nil
+}
+
+// List возвращает страницу пользователей с фильтрами и сортировкой
+func (r *UserRepository) List(ctx context.Context, opts repositories.ListOptions) (repositories.Page[entities.User], error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var users []entities.User
+	for _, u := range s.users {
+		if u.DeletedAt == nil {
+			users = append(users, *u)
+		}
+	}
+	return userList.list(users, opts)
+}
+
+// user ищет пользователя, который не удален
+func (s *Store) user(id int) (*entities.User, bool) {
+	if id < 1 || id > len(s.users) || s.users[id-1].DeletedAt != nil {
+		return nil, false
+	}
+	return s.users[id-1], true
+}
+
+// emailTaken сообщает, занята ли почта другим пользователем. Пустая почта не уникальна
+func (s *Store) emailTaken(email string, exceptID int) bool {
+	if email == "" {
+		return false
+	}
+	for _, u := range s.users {
+		if u.ID != exceptID && u.DeletedAt == nil && strings.EqualFold(u.Email, email) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package password
+
+import (
+	"errors"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var ErrPasswordTooLong = bcrypt.ErrPasswordTooLong
+
+// Hasher хеширует пароли bcrypt с заданной стоимостью
+type Hasher struct {
+	cost      int
+	dummyHash []byte
+}
+
+func NewHasher(cost int) *Hasher {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		cost = bcrypt.DefaultCost
+	}
+	// Хеш для сравнения, когда пользователь не найден, чтобы время ответа не выдавало несуществующие логины
+	dummyHash, _ := bcrypt.GenerateFromPassword([]byte("dummy password"), cost)
+	return &Hasher{cost: cost, dummyHash: dummyHash}
+}
+
+func (h *Hasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// Compare сравнивает пароль с хешем за постоянное время. needsRehash сообщает, что хеш
+// создан с другой стоимостью и его стоит пересчитать
+func (h *Hasher) Compare(hash, password string) (ok bool, needsRehash bool, err error) {
+	err = bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, false, nil
+	}
+	if err != nil {
+		return false, false, err
+	}
+
+	cost, err := bcrypt.Cost([]byte(hash))
+	if err != nil {
+		return false, false, err
+	}
+	return true, cost != h.cost, nil
+}
+
+// CompareDummy тратит на проверку столько же времени, сколько Compare, для несуществующих пользователей
+func (h *Hasher) CompareDummy(password string) {
+	_ = bcrypt.CompareHashAndPassword(h.dummyHash, []byte(password))
+}
@@ -0,0 +1,40 @@
+package password
+
+import (
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestHasherCompare(t *testing.T) {
+	h := NewHasher(bcrypt.MinCost)
+	hash, err := h.Hash("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash == "secret" {
+		t.Fatal("password stored in plain text")
+	}
+
+	ok, rehash, err := h.Compare(hash, "secret")
+	if err != nil || !ok || rehash {
+		t.Fatalf("expected match without rehash, got ok=%v rehash=%v err=%v", ok, rehash, err)
+	}
+
+	ok, _, err = h.Compare(hash, "wrong")
+	if err != nil || ok {
+		t.Fatalf("expected mismatch, got ok=%v err=%v", ok, err)
+	}
+}
+
+func TestHasherNeedsRehash(t *testing.T) {
+	hash, err := NewHasher(bcrypt.MinCost).Hash("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ok, rehash, err := NewHasher(bcrypt.MinCost+1).Compare(hash, "secret")
+	if err != nil || !ok || !rehash {
+		t.Fatalf("expected match with rehash after cost change, got ok=%v rehash=%v err=%v", ok, rehash, err)
+	}
+}
@@ -0,0 +1,112 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+)
+
+// FindByLogin ищет пользователя по имени или почте и возвращает его имя и почту
+func (r *PostgresAuthRepository) FindByLogin(ctx context.Context, login string) (username, email string, err error) {
+	var mail sql.NullString
+	err = r.db.QueryRowContext(ctx, `SELECT c.username, c.email FROM credentials c JOIN users u ON u.id = c.user_id
+		WHERE (c.username = $1 OR LOWER(c.email) = LOWER($1)) AND u.deleted_at IS NULL LIMIT 1`, login).
+		Scan(&username, &mail)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", repositories.ErrUserNotFound
+	}
+	return username, mail.String, err
+}
+
+// GetEmail возвращает почту пользователя и признак ее подтверждения
+func (r *PostgresAuthRepository) GetEmail(ctx context.Context, username string) (email string, verified bool, err error) {
+	var mail sql.NullString
+	err = r.db.QueryRowContext(ctx, "SELECT email, email_verified_at IS NOT NULL FROM credentials WHERE username = $1", username).
+		Scan(&mail, &verified)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, repositories.ErrUserNotFound
+	}
+	if err == nil && !mail.Valid {
+		return "", false, repositories.ErrEmailNotSet
+	}
+	return mail.String, verified, err
+}
+
+// CreateAuthToken регистрирует одноразовый токен. Прежние неиспользованные токены
+// того же назначения гасятся, действует только последняя ссылка
+func (r *PostgresAuthRepository) CreateAuthToken(ctx context.Context, jti, username, purpose, email string, expiresAt time.Time) error {
+	tx, err := r.db.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "UPDATE auth_tokens SET used_at = NOW() WHERE username = $1 AND purpose = $2 AND used_at IS NULL", username, purpose); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO auth_tokens (jti, username, purpose, email, expires_at) VALUES ($1, $2, $3, NULLIF($4, ''), $5)",
+		jti, username, purpose, email, expiresAt); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ResetPassword гасит токен сброса, меняет хеш пароля и закрывает все сессии пользователя
+func (r *PostgresAuthRepository) ResetPassword(ctx context.Context, jti, passwordHash string) (string, error) {
+	tx, err := r.db.begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	username, _, err := consumeAuthToken(ctx, tx, jti, repositories.PurposePasswordReset)
+	if err != nil {
+		return "", err
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE credentials SET password_hash = $1, updated_at = NOW() WHERE username = $2", passwordHash, username); err != nil {
+		return "", err
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE refresh_tokens SET revoked_at = NOW() WHERE username = $1 AND revoked_at IS NULL", username); err != nil {
+		return "", err
+	}
+	return username, tx.Commit()
+}
+
+// VerifyEmail гасит токен подтверждения и отмечает почту подтвержденной,
+// если с момента отправки письма адрес не менялся
+func (r *PostgresAuthRepository) VerifyEmail(ctx context.Context, jti string) (string, error) {
+	tx, err := r.db.begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	username, email, err := consumeAuthToken(ctx, tx, jti, repositories.PurposeVerifyEmail)
+	if err != nil {
+		return "", err
+	}
+	res, err := tx.ExecContext(ctx, "UPDATE credentials SET email_verified_at = NOW() WHERE username = $1 AND LOWER(email) = LOWER($2)", username, email)
+	if err != nil {
+		return "", err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		if err == nil {
+			err = repositories.ErrTokenInvalid
+		}
+		return "", err
+	}
+	return username, tx.Commit()
+}
+
+func consumeAuthToken(ctx context.Context, tx queryer, jti, purpose string) (username, email string, err error) {
+	var mail sql.NullString
+	err = tx.QueryRowContext(ctx, "UPDATE auth_tokens SET used_at = NOW() WHERE jti = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > NOW() RETURNING username, email",
+		jti, purpose).Scan(&username, &mail)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", repositories.ErrTokenInvalid
+	}
+	return username, mail.String, err
+}
@@ -0,0 +1,63 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+)
+
+func TestResetPasswordSingleUse(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewPostgresAuthRepository(db)
+	ctx := context.Background()
+
+	const username = "test-reset-user"
+	if _, err := repo.Create(ctx, username, "reset@example.com", "old"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Exec("DELETE FROM users WHERE username = $1", username) })
+
+	if err := repo.CreateRefreshToken(ctx, username, "family", "reset-refresh", time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	expiresAt := time.Now().Add(time.Hour)
+	if err := repo.CreateAuthToken(ctx, "first", username, repositories.PurposePasswordReset, "reset@example.com", expiresAt); err != nil {
+		t.Fatal(err)
+	}
+	// Новая ссылка гасит прежнюю
+	if err := repo.CreateAuthToken(ctx, "second", username, repositories.PurposePasswordReset, "reset@example.com", expiresAt); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.ResetPassword(ctx, "first", "new"); !errors.Is(err, repositories.ErrTokenInvalid) {
+		t.Fatalf("superseded token: %v, want repositories.ErrTokenInvalid", err)
+	}
+
+	got, err := repo.ResetPassword(ctx, "second", "new")
+	if err != nil || got != username {
+		t.Fatalf("reset = %q, %v", got, err)
+	}
+	if _, err := repo.ResetPassword(ctx, "second", "newer"); !errors.Is(err, repositories.ErrTokenInvalid) {
+		t.Fatalf("reused token: %v, want repositories.ErrTokenInvalid", err)
+	}
+
+	hash, err := repo.GetPasswordHash(ctx, username)
+	if err != nil || hash != "new" {
+		t.Fatalf("hash = %q, %v", hash, err)
+	}
+	// Сессии, открытые до сброса, закрыты
+	if _, _, err := repo.RotateRefreshToken(ctx, "reset-refresh", "reset-refresh-2", expiresAt); !errors.Is(err, repositories.ErrTokenInvalid) {
+		t.Fatalf("refresh after reset: %v, want repositories.ErrTokenInvalid", err)
+	}
+
+	// Токен подтверждения привязан к адресу, на который ушло письмо
+	if err := repo.CreateAuthToken(ctx, "verify", username, repositories.PurposeVerifyEmail, "other@example.com", expiresAt); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.VerifyEmail(ctx, "verify"); !errors.Is(err, repositories.ErrTokenInvalid) {
+		t.Fatalf("verify for changed email: %v, want repositories.ErrTokenInvalid", err)
+	}
+}
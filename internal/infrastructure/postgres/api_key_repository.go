@@ -0,0 +1,122 @@
+package postgres
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/entities"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+)
+
+// apiKeyTouchInterval как часто обновлять last_used_at, чтобы не писать в базу на каждый запрос
+const apiKeyTouchInterval = time.Minute
+
+const apiKeyColumns = "id, name, prefix, scopes, created_by, created_at, last_used_at, revoked_at"
+
+type PostgresAPIKeyRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresAPIKeyRepository(db *sql.DB) *PostgresAPIKeyRepository {
+	return &PostgresAPIKeyRepository{db: db}
+}
+
+// Create выпускает ключ вида lib_<prefix>_<secret> и возвращает его единственный раз
+func (r *PostgresAPIKeyRepository) Create(ctx context.Context, name string, scopes []string, createdBy string) (entities.APIKey, string, error) {
+	prefixBytes := make([]byte, 4)
+	secretBytes := make([]byte, 32)
+	if _, err := rand.Read(prefixBytes); err != nil {
+		return entities.APIKey{}, "", err
+	}
+	if _, err := rand.Read(secretBytes); err != nil {
+		return entities.APIKey{}, "", err
+	}
+	prefix := hex.EncodeToString(prefixBytes)
+	key := "lib_" + prefix + "_" + base64.RawURLEncoding.EncodeToString(secretBytes)
+
+	row := r.db.QueryRowContext(ctx, "INSERT INTO api_keys (name, prefix, key_hash, scopes, created_by) VALUES ($1, $2, $3, $4, $5) RETURNING "+apiKeyColumns,
+		name, prefix, hashAPIKey(key), pq.Array(scopes), createdBy)
+	apiKey, err := scanAPIKey(row)
+	if err != nil {
+		return entities.APIKey{}, "", err
+	}
+	return apiKey, key, nil
+}
+
+// List возвращает все ключи, включая отозванные
+func (r *PostgresAPIKeyRepository) List(ctx context.Context) ([]entities.APIKey, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+apiKeyColumns+" FROM api_keys ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []entities.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// Revoke отзывает ключ. Повторный отзыв не меняет дату
+func (r *PostgresAPIKeyRepository) Revoke(ctx context.Context, id int) (entities.APIKey, error) {
+	row := r.db.QueryRowContext(ctx, "UPDATE api_keys SET revoked_at = COALESCE(revoked_at, NOW()) WHERE id = $1 RETURNING "+apiKeyColumns, id)
+	key, err := scanAPIKey(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entities.APIKey{}, repositories.ErrAPIKeyNotFound
+	}
+	return key, err
+}
+
+// AuthenticateAPIKey находит действующий ключ и отмечает время его использования
+func (r *PostgresAPIKeyRepository) AuthenticateAPIKey(ctx context.Context, key string) (entities.APIKey, error) {
+	parts := strings.SplitN(key, "_", 3)
+	if len(parts) != 3 || parts[0] != "lib" {
+		return entities.APIKey{}, repositories.ErrAPIKeyInvalid
+	}
+
+	var storedHash string
+	apiKey, err := scanAPIKey(r.db.QueryRowContext(ctx, "SELECT "+apiKeyColumns+", key_hash FROM api_keys WHERE prefix = $1 AND revoked_at IS NULL", parts[1]), &storedHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entities.APIKey{}, repositories.ErrAPIKeyInvalid
+	}
+	if err != nil {
+		return entities.APIKey{}, err
+	}
+	if subtle.ConstantTimeCompare([]byte(storedHash), []byte(hashAPIKey(key))) != 1 {
+		return entities.APIKey{}, repositories.ErrAPIKeyInvalid
+	}
+
+	if apiKey.LastUsedAt == nil || time.Since(*apiKey.LastUsedAt) > apiKeyTouchInterval {
+		if _, err := r.db.ExecContext(ctx, "UPDATE api_keys SET last_used_at = NOW() WHERE id = $1", apiKey.ID); err != nil {
+			return entities.APIKey{}, err
+		}
+	}
+	return apiKey, nil
+}
+
+// hashAPIKey у ключа достаточно энтропии, поэтому медленный хеш не нужен
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func scanAPIKey(row fineScanner, extra ...interface{}) (entities.APIKey, error) {
+	var key entities.APIKey
+	dest := append([]interface{}{&key.ID, &key.Name, &key.Prefix, pq.Array(&key.Scopes), &key.CreatedBy, &key.CreatedAt, &key.LastUsedAt, &key.RevokedAt}, extra...)
+	err := row.Scan(dest...)
+	return key, err
+}
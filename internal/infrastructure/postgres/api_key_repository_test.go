@@ -0,0 +1,61 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/entities"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+)
+
+func TestAPIKeyLifecycle(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewPostgresAPIKeyRepository(db)
+	ctx := context.Background()
+
+	created, secret, err := repo.Create(ctx, "catalogue sync", []string{entities.PermCatalogWrite}, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Exec("DELETE FROM api_keys WHERE id = $1", created.ID) })
+
+	var stored string
+	if err := db.QueryRow("SELECT key_hash FROM api_keys WHERE id = $1", created.ID).Scan(&stored); err != nil {
+		t.Fatal(err)
+	}
+	if stored == secret {
+		t.Fatal("key stored in plain text")
+	}
+
+	key, err := repo.AuthenticateAPIKey(ctx, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.ID != created.ID || len(key.Scopes) != 1 || key.Scopes[0] != entities.PermCatalogWrite {
+		t.Fatalf("authenticated key = %+v", key)
+	}
+	if _, err := repo.AuthenticateAPIKey(ctx, secret+"x"); !errors.Is(err, repositories.ErrAPIKeyInvalid) {
+		t.Fatalf("wrong secret: %v, want repositories.ErrAPIKeyInvalid", err)
+	}
+
+	keys, err := repo.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range keys {
+		if k.ID == created.ID && k.LastUsedAt == nil {
+			t.Fatal("last_used_at not updated")
+		}
+	}
+
+	if _, err := repo.Revoke(ctx, created.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.AuthenticateAPIKey(ctx, secret); !errors.Is(err, repositories.ErrAPIKeyInvalid) {
+		t.Fatalf("revoked key: %v, want repositories.ErrAPIKeyInvalid", err)
+	}
+	if _, err := repo.Revoke(ctx, -1); !errors.Is(err, repositories.ErrAPIKeyNotFound) {
+		t.Fatalf("revoke unknown: %v, want repositories.ErrAPIKeyNotFound", err)
+	}
+}
@@ -0,0 +1,121 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/entities"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+)
+
+// Create заводит пользователя и привязанные к нему учетные данные и возвращает user_id. Почта необязательна
+func (r *PostgresAuthRepository) Create(ctx context.Context, username, email, passwordHash string) (int, error) {
+	tx, err := r.db.begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var userID int
+	err = tx.QueryRowContext(ctx, "INSERT INTO users (name, email, username) VALUES (LEFT($1, 50), $2, $1) RETURNING id", username, email).Scan(&userID)
+	if err != nil {
+		return 0, userExistsError(err)
+	}
+	_, err = tx.ExecContext(ctx, "INSERT INTO credentials (username, user_id, email, password_hash) VALUES ($1, $2, NULLIF($3, ''), $4)", username, userID, email, passwordHash)
+	if err != nil {
+		return 0, userExistsError(err)
+	}
+	return userID, tx.Commit()
+}
+
+// GetUserID возвращает user_id учетной записи. Удаленный пользователь не находится
+func (r *PostgresAuthRepository) GetUserID(ctx context.Context, username string) (int, error) {
+	var userID int
+	err := r.db.QueryRowContext(ctx, `SELECT c.user_id FROM credentials c JOIN users u ON u.id = c.user_id
+		WHERE c.username = $1 AND u.deleted_at IS NULL`, username).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, repositories.ErrUserNotFound
+	}
+	return userID, err
+}
+
+// GetPasswordHash возвращает хеш пароля пользователя. Удаленный пользователь войти не может
+func (r *PostgresAuthRepository) GetPasswordHash(ctx context.Context, username string) (string, error) {
+	var hash string
+	err := r.db.QueryRowContext(ctx, `SELECT c.password_hash FROM credentials c JOIN users u ON u.id = c.user_id
+		WHERE c.username = $1 AND u.deleted_at IS NULL`, username).Scan(&hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", repositories.ErrUserNotFound
+	}
+	return hash, err
+}
+
+// UpdatePasswordHash заменяет хеш пароля, например после смены стоимости хеширования
+func (r *PostgresAuthRepository) UpdatePasswordHash(ctx context.Context, username, passwordHash string) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE credentials SET password_hash = $1, updated_at = NOW() WHERE username = $2", passwordHash, username)
+	return err
+}
+
+// GetRoles возвращает роли пользователя. Роль patron есть у всех и всегда идет первой.
+// Для имени без учетной записи возвращается ErrUserNotFound
+func (r *PostgresAuthRepository) GetRoles(ctx context.Context, username string) ([]string, error) {
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM credentials WHERE username = $1)", username).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, repositories.ErrUserNotFound
+	}
+
+	rows, err := r.db.QueryContext(ctx, "SELECT role FROM user_roles WHERE username = $1 ORDER BY role", username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := []string{entities.RolePatron}
+	for rows.Next() {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+	return roles, rows.Err()
+}
+
+// GrantRole выдает пользователю роль. Повторная выдача ничего не меняет
+func (r *PostgresAuthRepository) GrantRole(ctx context.Context, username, role string) error {
+	_, err := r.db.ExecContext(ctx, "INSERT INTO user_roles (username, role) VALUES ($1, $2) ON CONFLICT DO NOTHING", username, role)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+		return repositories.ErrUserNotFound
+	}
+	return err
+}
+
+// RevokeRole отзывает роль у пользователя
+func (r *PostgresAuthRepository) RevokeRole(ctx context.Context, username, role string) error {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM user_roles WHERE username = $1 AND role = $2", username, role)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return repositories.ErrRoleNotGranted
+	}
+	return nil
+}
+
+func userExistsError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return repositories.ErrUserExists
+	}
+	return err
+}
@@ -0,0 +1,84 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/entities"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+)
+
+func TestGrantAndRevokeRole(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewPostgresAuthRepository(db)
+	ctx := context.Background()
+
+	const username = "test-role-user"
+	if _, err := repo.Create(ctx, username, "", "hash"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Exec("DELETE FROM users WHERE username = $1", username) })
+
+	if err := repo.GrantRole(ctx, username, entities.RoleLibrarian); err != nil {
+		t.Fatal(err)
+	}
+	// Повторная выдача не ошибка
+	if err := repo.GrantRole(ctx, username, entities.RoleLibrarian); err != nil {
+		t.Fatal(err)
+	}
+
+	roles, err := repo.GetRoles(ctx, username)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{entities.RolePatron, entities.RoleLibrarian}; !reflect.DeepEqual(roles, want) {
+		t.Fatalf("roles = %v, want %v", roles, want)
+	}
+
+	if err := repo.RevokeRole(ctx, username, entities.RoleLibrarian); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.RevokeRole(ctx, username, entities.RoleLibrarian); !errors.Is(err, repositories.ErrRoleNotGranted) {
+		t.Fatalf("second revoke: %v, want repositories.ErrRoleNotGranted", err)
+	}
+	if err := repo.GrantRole(ctx, "no-such-user", entities.RoleAdmin); !errors.Is(err, repositories.ErrUserNotFound) {
+		t.Fatalf("grant to missing user: %v, want repositories.ErrUserNotFound", err)
+	}
+	if _, err := repo.GetRoles(ctx, "no-such-user"); !errors.Is(err, repositories.ErrUserNotFound) {
+		t.Fatalf("roles of missing user: %v, want repositories.ErrUserNotFound", err)
+	}
+}
+
+func TestCreateLinksUser(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewPostgresAuthRepository(db)
+	ctx := context.Background()
+
+	const username = "test-linked-user"
+	id, err := repo.Create(ctx, username, "linked@example.com", "hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Exec("DELETE FROM users WHERE username = $1", username) })
+
+	var name, email string
+	if err := db.QueryRow("SELECT name, email FROM users WHERE id = $1", id).Scan(&name, &email); err != nil {
+		t.Fatal(err)
+	}
+	if name != username || email != "linked@example.com" {
+		t.Fatalf("users row = (%s, %s)", name, email)
+	}
+	if got, err := repo.GetUserID(ctx, username); err != nil || got != id {
+		t.Fatalf("GetUserID = %d, %v, want %d", got, err, id)
+	}
+
+	// Повторная регистрация с тем же именем отклоняется
+	if _, err := repo.Create(ctx, username, "", "hash"); !errors.Is(err, repositories.ErrUserExists) {
+		t.Fatalf("duplicate: %v, want repositories.ErrUserExists", err)
+	}
+	if _, err := repo.GetUserID(ctx, "missing-user"); !errors.Is(err, repositories.ErrUserNotFound) {
+		t.Fatalf("missing: %v, want repositories.ErrUserNotFound", err)
+	}
+}
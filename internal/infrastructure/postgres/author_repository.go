@@ -0,0 +1,201 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/entities"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+)
+
+const authorColumns = "id, name, birth_year, death_year, bio"
+
+// Add регистрирует автора, у которого еще может не быть книг
+func (r *PostgresAuthorRepository) Add(ctx context.Context, author entities.Author) (entities.Author, error) {
+	query := "INSERT INTO authors (name, birth_year, death_year, bio) VALUES ($1, $2, $3, $4) RETURNING " + authorColumns
+	created, err := scanAuthor(r.db.QueryRowContext(ctx, query, author.Name, author.BirthYear, author.DeathYear, author.Bio))
+	return created, authorExistsError(err)
+}
+
+// List возвращает авторов по алфавиту
+func (r *PostgresAuthorRepository) List(ctx context.Context) ([]entities.Author, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+authorColumns+" FROM authors ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	authors := []entities.Author{}
+	for rows.Next() {
+		author, err := scanAuthor(rows)
+		if err != nil {
+			return nil, err
+		}
+		authors = append(authors, author)
+	}
+	return authors, rows.Err()
+}
+
+// GetByID получает автора по ID
+func (r *PostgresAuthorRepository) GetByID(ctx context.Context, id int) (entities.Author, error) {
+	author, err := scanAuthor(r.db.QueryRowContext(ctx, "SELECT "+authorColumns+" FROM authors WHERE id = $1", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return entities.Author{}, repositories.ErrAuthorNotFound
+	}
+	return author, err
+}
+
+// Update меняет данные автора. Новое имя сразу видно в его книгах и поиске по ним
+func (r *PostgresAuthorRepository) Update(ctx context.Context, author entities.Author) (entities.Author, error) {
+	tx, err := r.db.begin(ctx)
+	if err != nil {
+		return entities.Author{}, err
+	}
+	defer tx.Rollback()
+
+	query := "UPDATE authors SET name = $2, birth_year = $3, death_year = $4, bio = $5 WHERE id = $1 RETURNING " + authorColumns
+	updated, err := scanAuthor(tx.QueryRowContext(ctx, query, author.ID, author.Name, author.BirthYear, author.DeathYear, author.Bio))
+	if errors.Is(err, sql.ErrNoRows) {
+		return entities.Author{}, repositories.ErrAuthorNotFound
+	}
+	if err != nil {
+		return entities.Author{}, authorExistsError(err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE book SET author = $1 WHERE author_id = $2", updated.Name, updated.ID); err != nil {
+		return entities.Author{}, err
+	}
+	return updated, tx.Commit()
+}
+
+// Delete удаляет автора. Автора с книгами можно удалить только вместе с ними (cascade),
+// и только если книги ни разу не выдавались и не ждут читателей по брони. История выдач
+// и штрафов не удаляется никогда, вместе с книгами удаляются только экземпляры и старые брони
+func (r *PostgresAuthorRepository) Delete(ctx context.Context, id int, cascade bool) error {
+	tx, err := r.db.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, "SELECT id FROM authors WHERE id = $1 FOR UPDATE", id).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return repositories.ErrAuthorNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	// Строки книг блокируются, как в TakeBook, чтобы книгу не выдали во время удаления
+	rows, err := tx.QueryContext(ctx, "SELECT index FROM book WHERE author_id = $1 ORDER BY index FOR UPDATE", id)
+	if err != nil {
+		return err
+	}
+	var books []int64
+	for rows.Next() {
+		var index int64
+		if err := rows.Scan(&index); err != nil {
+			rows.Close()
+			return err
+		}
+		books = append(books, index)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if len(books) > 0 {
+		if !cascade {
+			return repositories.ErrAuthorHasBooks
+		}
+		if err := deleteBooks(ctx, tx, books); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM authors WHERE id = $1", id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// deleteBooks удаляет книги с экземплярами и старыми бронями. Книги, которые выдавались,
+// не удаляются: на выдачи ссылаются штрафы и платежи, а журнал выдач нужен библиотеке
+func deleteBooks(ctx context.Context, tx queryer, books []int64) error {
+	indexes := pq.Array(books)
+
+	var inUse bool
+	query := `
+	SELECT EXISTS(SELECT 1 FROM copies WHERE book_index = ANY($1) AND status <> $2)
+		OR EXISTS(SELECT 1 FROM holds WHERE book_index = ANY($1) AND status IN ($3, $4))
+		OR EXISTS(SELECT 1 FROM loans WHERE book_index = ANY($1))`
+	err := tx.QueryRowContext(ctx, query, indexes, entities.CopyAvailable, entities.HoldWaiting, entities.HoldReady).Scan(&inUse)
+	if err != nil {
+		return err
+	}
+	if inUse {
+		return repositories.ErrAuthorBooksInUse
+	}
+
+	for _, query := range []string{
+		"DELETE FROM holds WHERE book_index = ANY($1)",
+		"DELETE FROM copies WHERE book_index = ANY($1)",
+		"DELETE FROM book WHERE index = ANY($1)",
+	} {
+		if _, err := tx.ExecContext(ctx, query, indexes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListBooks возвращает книги автора с числом экземпляров
+func (r *PostgresAuthorRepository) ListBooks(ctx context.Context, id int) ([]entities.Book, error) {
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM authors WHERE id = $1)", id).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, repositories.ErrAuthorNotFound
+	}
+
+	rows, err := r.db.QueryContext(ctx, bookSummary+" WHERE b.author_id = $1 GROUP BY b.index ORDER BY b.index", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	books := []entities.Book{}
+	for rows.Next() {
+		book, err := scanListedBook(rows)
+		if err != nil {
+			return nil, err
+		}
+		books = append(books, book)
+	}
+	return books, rows.Err()
+}
+
+// registerAuthor возвращает ID автора книги, добавляя его в справочник, если его там нет
+func registerAuthor(ctx context.Context, tx queryer, name string) (int, error) {
+	var id int
+	err := tx.QueryRowContext(ctx, "INSERT INTO authors (name) VALUES ($1) ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name RETURNING id", name).Scan(&id)
+	return id, err
+}
+
+func scanAuthor(row fineScanner) (entities.Author, error) {
+	var author entities.Author
+	err := row.Scan(&author.ID, &author.Name, &author.BirthYear, &author.DeathYear, &author.Bio)
+	return author, err
+}
+
+func authorExistsError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return repositories.ErrAuthorExists
+	}
+	return err
+}
@@ -0,0 +1,101 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/entities"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+)
+
+func TestAuthorDeleteRequiresCascade(t *testing.T) {
+	db := openTestDB(t)
+	authors := NewPostgresAuthorRepository(db)
+	books := NewPostgresBookRepository(db)
+	ctx := context.Background()
+	name := fmt.Sprintf("Author test %d", time.Now().UnixNano())
+
+	born := 1920
+	author, err := authors.Add(ctx, entities.Author{Name: name, BirthYear: &born, Bio: "Writer"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Exec("DELETE FROM authors WHERE id = $1", author.ID) })
+	if _, err := authors.Add(ctx, entities.Author{Name: name}); !errors.Is(err, repositories.ErrAuthorExists) {
+		t.Fatalf("second Add error = %v, want repositories.ErrAuthorExists", err)
+	}
+
+	book, err := books.CreateBook(ctx, "Author test book", name, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Exec("DELETE FROM copies WHERE book_index = $1", book.Index)
+		db.Exec("DELETE FROM book WHERE index = $1", book.Index)
+	})
+
+	// Переименование автора меняет имя в его книгах
+	author.Name = name + " renamed"
+	if _, err := authors.Update(ctx, author); err != nil {
+		t.Fatal(err)
+	}
+	listed, err := authors.ListBooks(ctx, author.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listed) != 1 || listed[0].Index != book.Index || listed[0].Author != author.Name {
+		t.Fatalf("author books = %+v", listed)
+	}
+
+	if err := authors.Delete(ctx, author.ID, false); !errors.Is(err, repositories.ErrAuthorHasBooks) {
+		t.Fatalf("Delete without cascade error = %v, want repositories.ErrAuthorHasBooks", err)
+	}
+	if err := authors.Delete(ctx, author.ID, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := authors.GetByID(ctx, author.ID); !errors.Is(err, repositories.ErrAuthorNotFound) {
+		t.Fatalf("GetByID after delete error = %v, want repositories.ErrAuthorNotFound", err)
+	}
+	if _, err := books.ListCopies(ctx, book.Index); !errors.Is(err, repositories.ErrBookNotFound) {
+		t.Fatalf("book must be deleted with its author, ListCopies error = %v", err)
+	}
+}
+
+func TestAuthorCascadeKeepsLoanHistory(t *testing.T) {
+	db := openTestDB(t)
+	authors := NewPostgresAuthorRepository(db)
+	books := NewPostgresBookRepository(db)
+	ctx := context.Background()
+	name := fmt.Sprintf("Author history %d", time.Now().UnixNano())
+	reader := insertTestUser(t, db, "reader")
+
+	book, err := books.CreateBook(ctx, "Author history book", name, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := books.TakeBook(ctx, book.Index, reader, time.Now().Add(time.Hour), "", 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := books.ReturnBook(ctx, book.Index, reader, time.Hour, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	var authorID int
+	if err := db.QueryRow("SELECT author_id FROM book WHERE index = $1", book.Index).Scan(&authorID); err != nil {
+		t.Fatal(err)
+	}
+	// Книгу, которая выдавалась, нельзя удалить даже вместе с автором
+	if err := authors.Delete(ctx, authorID, true); !errors.Is(err, repositories.ErrAuthorBooksInUse) {
+		t.Fatalf("cascade Delete error = %v, want repositories.ErrAuthorBooksInUse", err)
+	}
+	var loans int
+	if err := db.QueryRow("SELECT COUNT(*) FROM loans WHERE book_index = $1", book.Index).Scan(&loans); err != nil {
+		t.Fatal(err)
+	}
+	if loans != 1 {
+		t.Fatalf("loan history of the book has %d loans, want 1", loans)
+	}
+}
@@ -0,0 +1,372 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/entities"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+)
+
+// TakeBook выдает пользователю экземпляр книги до dueAt. Если barcode пустой, выдается любой свободный
+// экземпляр. Экземпляр, отложенный для пользователя по брони, выдается в первую очередь.
+// Должнику с долгом больше fineLimit и читателю, у которого уже maxActive книг, книга не выдается,
+// 0 в maxActive снимает ограничение. Блокировка строки книги, проверка лимитов, смена статуса
+// экземпляра, запись в журнал выдач и увеличение take_count выполняются в одной транзакции
+func (r *PostgresBookRepository) TakeBook(ctx context.Context, index, userID int, dueAt time.Time, barcode string, maxActive int, fineLimit int64) (entities.Loan, error) {
+	tx, err := r.db.begin(ctx)
+	if err != nil {
+		return entities.Loan{}, err
+	}
+	defer tx.Rollback()
+
+	book, err := lockBook(ctx, tx, index)
+	if err != nil {
+		return entities.Loan{}, err
+	}
+
+	var borrowed bool
+	err = tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM loans WHERE book_index = $1 AND user_id = $2 AND returned_at IS NULL)",
+		index, userID).Scan(&borrowed)
+	if err != nil {
+		return entities.Loan{}, err
+	}
+	if borrowed {
+		return entities.Loan{}, repositories.ErrAlreadyBorrowed
+	}
+	if err := checkLoanLimits(ctx, tx, userID, maxActive, fineLimit); err != nil {
+		return entities.Loan{}, err
+	}
+
+	loan := entities.Loan{
+		BookIndex: index,
+		Book:      book.Book,
+		Author:    book.Author,
+		UserID:    userID,
+		DueAt:     dueAt,
+	}
+
+	// Экземпляр, отложенный по брони, может забрать только тот, для кого он отложен
+	err = tx.QueryRowContext(ctx, `
+	UPDATE holds h SET status = $1 FROM copies c
+	WHERE h.book_index = $2 AND h.user_id = $3 AND h.status = $4 AND c.id = h.copy_id
+	RETURNING c.id, c.barcode`,
+		entities.HoldFulfilled, index, userID, entities.HoldReady).Scan(&loan.CopyID, &loan.Barcode)
+	if errors.Is(err, sql.ErrNoRows) {
+		query := "SELECT id, barcode FROM copies WHERE book_index = $1 AND status = $2 AND ($3::text = '' OR barcode = $3) ORDER BY id LIMIT 1 FOR UPDATE"
+		err = tx.QueryRowContext(ctx, query, index, entities.CopyAvailable, barcode).Scan(&loan.CopyID, &loan.Barcode)
+		if errors.Is(err, sql.ErrNoRows) {
+			if barcode != "" {
+				return entities.Loan{}, repositories.ErrCopyNotAvailable
+			}
+			return entities.Loan{}, repositories.ErrBookTaken
+		}
+	}
+	if err != nil {
+		return entities.Loan{}, err
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE copies SET status = $1 WHERE id = $2", entities.CopyOnLoan, loan.CopyID); err != nil {
+		return entities.Loan{}, err
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE book SET take_count = take_count + 1 WHERE index = $1", index); err != nil {
+		return entities.Loan{}, err
+	}
+	err = tx.QueryRowContext(ctx, "INSERT INTO loans (book_index, copy_id, user_id, due_at) VALUES ($1, $2, $3, $4) RETURNING id, taken_at",
+		index, loan.CopyID, userID, dueAt).Scan(&loan.ID, &loan.TakenAt)
+	if err != nil {
+		return entities.Loan{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return entities.Loan{}, err
+	}
+	return loan, nil
+}
+
+// checkLoanLimits проверяет долг и число книг на руках читателя. Строка читателя блокируется,
+// чтобы одновременные выдачи разных книг одному читателю не обошли лимиты
+func checkLoanLimits(ctx context.Context, tx queryer, userID, maxActive int, fineLimit int64) error {
+	err := tx.QueryRowContext(ctx, "SELECT id FROM users WHERE id = $1 FOR UPDATE", userID).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return repositories.ErrUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	var balance int64
+	err = tx.QueryRowContext(ctx, "SELECT COALESCE(SUM(amount - paid), 0) FROM fines WHERE user_id = $1 AND status = $2",
+		userID, entities.FineOutstanding).Scan(&balance)
+	if err != nil {
+		return err
+	}
+	if balance > fineLimit {
+		return fmt.Errorf("%w: owed %d, limit %d", repositories.ErrFineLimit, balance, fineLimit)
+	}
+
+	if maxActive <= 0 {
+		return nil
+	}
+	var active int
+	if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM loans WHERE user_id = $1 AND returned_at IS NULL", userID).Scan(&active); err != nil {
+		return err
+	}
+	if active >= maxActive {
+		return repositories.ErrLoanLimit
+	}
+	return nil
+}
+
+// ReturnBook закрывает активную выдачу пользователя в одной транзакции и начисляет fineRate за каждый
+// начатый день просрочки. Если на книгу есть очередь, экземпляр откладывается для первого в очереди
+// на pickupWindow, иначе освобождается
+func (r *PostgresBookRepository) ReturnBook(ctx context.Context, index, userID int, pickupWindow time.Duration, fineRate int64) (entities.Book, error) {
+	tx, err := r.db.begin(ctx)
+	if err != nil {
+		return entities.Book{}, err
+	}
+	defer tx.Rollback()
+
+	// Строка книги блокируется первой, как и в TakeBook, чтобы не было взаимоблокировок
+	book, err := lockBook(ctx, tx, index)
+	if err != nil {
+		return entities.Book{}, err
+	}
+
+	var (
+		loanID, copyID    int
+		dueAt, returnedAt time.Time
+	)
+	err = tx.QueryRowContext(ctx, "UPDATE loans SET returned_at = NOW() WHERE book_index = $1 AND user_id = $2 AND returned_at IS NULL RETURNING id, copy_id, due_at, returned_at",
+		index, userID).Scan(&loanID, &copyID, &dueAt, &returnedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entities.Book{}, repositories.ErrLoanNotFound
+	}
+	if err != nil {
+		return entities.Book{}, err
+	}
+
+	if amount := entities.OverdueFine(dueAt, returnedAt, fineRate); amount > 0 {
+		_, err = tx.ExecContext(ctx, "INSERT INTO fines (user_id, loan_id, amount, status) VALUES ($1, $2, $3, $4)",
+			userID, loanID, amount, entities.FineOutstanding)
+		if err != nil {
+			return entities.Book{}, err
+		}
+	}
+
+	if err := assignNextHold(ctx, tx, index, copyID, pickupWindow); err != nil {
+		return entities.Book{}, err
+	}
+	if err := countCopies(ctx, tx, &book); err != nil {
+		return entities.Book{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return entities.Book{}, err
+	}
+	return book, nil
+}
+
+// RenewLoan продлевает активную выдачу на period, если лимит продлений не исчерпан.
+// Новый срок отсчитывается от старого или от текущего момента, если книга уже просрочена
+func (r *PostgresBookRepository) RenewLoan(ctx context.Context, index, userID int, period time.Duration, maxRenewals int) (entities.Loan, error) {
+	tx, err := r.db.begin(ctx)
+	if err != nil {
+		return entities.Loan{}, err
+	}
+	defer tx.Rollback()
+
+	var loan entities.Loan
+	query := `
+	SELECT l.id, l.book_index, l.copy_id, c.barcode, b.book, b.author, l.user_id, l.taken_at, l.due_at, l.renewals
+	FROM loans l
+	JOIN book b ON b.index = l.book_index
+	JOIN copies c ON c.id = l.copy_id
+	WHERE l.book_index = $1 AND l.user_id = $2 AND l.returned_at IS NULL
+	FOR UPDATE OF l`
+	err = tx.QueryRowContext(ctx, query, index, userID).
+		Scan(&loan.ID, &loan.BookIndex, &loan.CopyID, &loan.Barcode, &loan.Book, &loan.Author, &loan.UserID, &loan.TakenAt, &loan.DueAt, &loan.Renewals)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entities.Loan{}, repositories.ErrLoanNotFound
+	}
+	if err != nil {
+		return entities.Loan{}, err
+	}
+	if loan.Renewals >= maxRenewals {
+		return entities.Loan{}, repositories.ErrRenewLimit
+	}
+
+	var waiting bool
+	err = tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM holds WHERE book_index = $1 AND status = $2)", index, entities.HoldWaiting).Scan(&waiting)
+	if err != nil {
+		return entities.Loan{}, err
+	}
+	if waiting {
+		return entities.Loan{}, repositories.ErrBookOnHold
+	}
+
+	dueAt := loan.DueAt
+	if now := time.Now(); dueAt.Before(now) {
+		dueAt = now
+	}
+	loan.DueAt = dueAt.Add(period)
+	loan.Renewals++
+
+	_, err = tx.ExecContext(ctx, "UPDATE loans SET due_at = $1, renewals = $2, overdue = FALSE WHERE id = $3", loan.DueAt, loan.Renewals, loan.ID)
+	if err != nil {
+		return entities.Loan{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return entities.Loan{}, err
+	}
+	return loan, nil
+}
+
+// MarkOverdue помечает просроченными активные выдачи с истекшим сроком и возвращает их количество
+func (r *PostgresBookRepository) MarkOverdue(ctx context.Context) (int64, error) {
+	result, err := r.db.ExecContext(ctx, "UPDATE loans SET overdue = TRUE WHERE returned_at IS NULL AND NOT overdue AND due_at < NOW()")
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// AddCopy регистрирует новый экземпляр книги. Если barcode пустой, он формируется по номеру экземпляра.
+// Новый экземпляр сразу уходит первому в очереди на книгу, если она есть
+func (r *PostgresBookRepository) AddCopy(ctx context.Context, index int, barcode string, pickupWindow time.Duration) (entities.Copy, error) {
+	tx, err := r.db.begin(ctx)
+	if err != nil {
+		return entities.Copy{}, err
+	}
+	defer tx.Rollback()
+
+	book, err := lockBook(ctx, tx, index)
+	if err != nil {
+		return entities.Copy{}, err
+	}
+	if barcode == "" {
+		if err := countCopies(ctx, tx, &book); err != nil {
+			return entities.Copy{}, err
+		}
+		barcode = entities.CopyBarcode(index, book.TotalCopies+1)
+	}
+
+	item := entities.Copy{BookIndex: index, Barcode: barcode}
+	err = tx.QueryRowContext(ctx, "INSERT INTO copies (book_index, barcode, status) VALUES ($1, $2, $3) RETURNING id",
+		index, barcode, entities.CopyAvailable).Scan(&item.ID)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return entities.Copy{}, repositories.ErrBarcodeExists
+	}
+	if err != nil {
+		return entities.Copy{}, err
+	}
+
+	if err := assignNextHold(ctx, tx, index, item.ID, pickupWindow); err != nil {
+		return entities.Copy{}, err
+	}
+	if err := tx.QueryRowContext(ctx, "SELECT status FROM copies WHERE id = $1", item.ID).Scan(&item.Status); err != nil {
+		return entities.Copy{}, err
+	}
+
+	return item, tx.Commit()
+}
+
+// ListCopies возвращает все экземпляры книги
+func (r *PostgresBookRepository) ListCopies(ctx context.Context, index int) ([]entities.Copy, error) {
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM book WHERE index = $1)", index).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, repositories.ErrBookNotFound
+	}
+
+	rows, err := r.db.QueryContext(ctx, "SELECT id, book_index, barcode, status FROM copies WHERE book_index = $1 ORDER BY id", index)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	copies := []entities.Copy{}
+	for rows.Next() {
+		var item entities.Copy
+		if err := rows.Scan(&item.ID, &item.BookIndex, &item.Barcode, &item.Status); err != nil {
+			return nil, err
+		}
+		copies = append(copies, item)
+	}
+	return copies, rows.Err()
+}
+
+// lockBook читает книгу с блокировкой строки до конца транзакции. Все операции с экземплярами
+// книги и очередью на нее выполняются под этой блокировкой
+func lockBook(ctx context.Context, tx queryer, index int) (entities.Book, error) {
+	var book entities.Book
+	query := "SELECT index, book, author, take_count FROM book WHERE index = $1 FOR UPDATE"
+	err := tx.QueryRowContext(ctx, query, index).Scan(&book.Index, &book.Book, &book.Author, &book.TakeCount)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entities.Book{}, repositories.ErrBookNotFound
+	}
+	if err != nil {
+		return entities.Book{}, err
+	}
+	return book, nil
+}
+
+// countCopies заполняет у книги количество свободных и всех экземпляров
+func countCopies(ctx context.Context, tx queryer, book *entities.Book) error {
+	query := "SELECT COUNT(*) FILTER (WHERE status = $2), COUNT(*) FROM copies WHERE book_index = $1"
+	if err := tx.QueryRowContext(ctx, query, book.Index, entities.CopyAvailable).Scan(&book.AvailableCopies, &book.TotalCopies); err != nil {
+		return err
+	}
+	block := book.AvailableCopies == 0
+	book.Block = &block
+	return nil
+}
+
+// bookSummary книги с числом экземпляров и сроками возврата в порядке scanListedBook.
+// Запрос нужно завершить группировкой GROUP BY b.index
+const bookSummary = `SELECT b.index, b.book, b.author, b.take_count,
+		COUNT(c.id) FILTER (WHERE c.status = '` + entities.CopyAvailable + `') AS available_copies,
+		COUNT(c.id) AS total_copies,
+		(SELECT MIN(l.due_at) FROM loans l WHERE l.book_index = b.index AND l.returned_at IS NULL) AS due_at,
+		EXISTS(SELECT 1 FROM loans l WHERE l.book_index = b.index AND l.returned_at IS NULL AND l.overdue) AS overdue
+	FROM book b
+	LEFT JOIN copies c ON c.book_index = b.index`
+
+// bookList поля, по которым можно фильтровать и сортировать каталог.
+// Число свободных экземпляров считается в подзапросе, чтобы по нему можно было фильтровать
+var bookList = listSchema[entities.Book]{
+	from: "(" + bookSummary + " GROUP BY b.index) AS books",
+	fields: map[string]listField[entities.Book]{
+		"index":            {column: "index", kind: kindInt, value: func(b entities.Book) interface{} { return b.Index }},
+		"book":             {column: "book", kind: kindText, value: func(b entities.Book) interface{} { return b.Book }},
+		"author":           {column: "author", kind: kindText, value: func(b entities.Book) interface{} { return b.Author }},
+		"take_count":       {column: "take_count", kind: kindInt, value: func(b entities.Book) interface{} { return b.TakeCount }},
+		"available_copies": {column: "available_copies", kind: kindInt, value: func(b entities.Book) interface{} { return b.AvailableCopies }},
+		"total_copies":     {column: "total_copies", kind: kindInt, value: func(b entities.Book) interface{} { return b.TotalCopies }},
+		"available":        {column: "(available_copies > 0)", kind: kindBool, value: func(b entities.Book) interface{} { return b.AvailableCopies > 0 }},
+	},
+	key:  "index",
+	scan: scanListedBook,
+}
+
+// List возвращает страницу каталога с числом экземпляров и сроками возврата
+func (r *PostgresBookRepository) List(ctx context.Context, opts repositories.ListOptions) (repositories.Page[entities.Book], error) {
+	return bookList.list(ctx, r.db, opts)
+}
+
+func scanListedBook(row fineScanner) (entities.Book, error) {
+	var book entities.Book
+	err := row.Scan(&book.Index, &book.Book, &book.Author, &book.TakeCount, &book.AvailableCopies, &book.TotalCopies, &book.DueAt, &book.Overdue)
+	block := book.AvailableCopies == 0
+	book.Block = &block
+	return book, err
+}
@@ -0,0 +1,150 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/entities"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+)
+
+// authorSimilarity порог похожести для поиска автора с опечатками, по умолчанию в pg_trgm он 0.6
+const authorSimilarity = "0.4"
+
+// highlightOptions ts_headline отмечает совпадения управляющими символами, а не тегами:
+// текст книги экранируется уже после выделения, см. markHighlight
+const highlightOptions = `format('StartSel="%s", StopSel="%s", HighlightAll=true', chr(2), chr(3))`
+
+// bookMatches совпадения по поисковому вектору или похожему автору с их рангом
+const bookMatches = `
+	WITH q AS (
+		SELECT websearch_to_tsquery('english', $1) || websearch_to_tsquery('simple', $1) AS query
+	), matches AS (
+		SELECT b.index, b.book, b.author, b.take_count,
+			COUNT(c.id) FILTER (WHERE c.status = '` + entities.CopyAvailable + `') AS available_copies,
+			COUNT(c.id) AS total_copies,
+			ts_rank(b.search, (SELECT query FROM q)) + word_similarity($1, b.author) AS rank,
+			(SELECT query FROM q) AS query
+		FROM book b
+		LEFT JOIN copies c ON c.book_index = b.index
+		WHERE b.search @@ (SELECT query FROM q) OR $1 <% b.author
+		GROUP BY b.index
+	)`
+
+// Search ищет книги по названию и автору. Результаты сортируются по релевантности,
+// фасеты по авторам и наличию считаются в том же снимке данных
+func (r *PostgresBookRepository) Search(ctx context.Context, search repositories.BookSearch) (entities.BookSearchResult, error) {
+	tx, err := r.db.begin(ctx)
+	if err != nil {
+		return entities.BookSearchResult{}, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "SELECT set_config('pg_trgm.word_similarity_threshold', $1, true)", authorSimilarity); err != nil {
+		return entities.BookSearchResult{}, err
+	}
+
+	args := []interface{}{search.Query}
+	where := ""
+	if search.Author != "" {
+		args = append(args, search.Author)
+		where += fmt.Sprintf(" AND LOWER(author) = LOWER($%d)", len(args))
+	}
+	if search.Available != nil {
+		args = append(args, *search.Available)
+		where += fmt.Sprintf(" AND (available_copies > 0) = $%d", len(args))
+	}
+	args = append(args, search.Limit, search.Offset)
+
+	query := bookMatches + `
+	SELECT index, book, author, take_count, available_copies, total_copies, rank,
+		ts_headline('english', translate(book, chr(2) || chr(3), ''), query, ` + highlightOptions + `),
+		ts_headline('simple', translate(author, chr(2) || chr(3), ''), query, ` + highlightOptions + `),
+		COUNT(*) OVER ()
+	FROM matches
+	WHERE TRUE` + where + fmt.Sprintf(`
+	ORDER BY rank DESC, index
+	LIMIT $%d OFFSET $%d`, len(args)-1, len(args))
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return entities.BookSearchResult{}, err
+	}
+	defer rows.Close()
+
+	result := entities.BookSearchResult{
+		Hits:   []entities.BookSearchHit{},
+		Facets: entities.BookFacets{Authors: []entities.FacetCount{}, Availability: []entities.FacetCount{}},
+	}
+	for rows.Next() {
+		var hit entities.BookSearchHit
+		if err := rows.Scan(&hit.Index, &hit.Book.Book, &hit.Author, &hit.TakeCount, &hit.AvailableCopies, &hit.TotalCopies,
+			&hit.Rank, &hit.Highlight.Book, &hit.Highlight.Author, &result.Total); err != nil {
+			return entities.BookSearchResult{}, err
+		}
+		hit.Highlight.Book = markHighlight(hit.Highlight.Book)
+		hit.Highlight.Author = markHighlight(hit.Highlight.Author)
+		block := hit.AvailableCopies == 0
+		hit.Block = &block
+		result.Hits = append(result.Hits, hit)
+	}
+	if err := rows.Err(); err != nil {
+		return entities.BookSearchResult{}, err
+	}
+
+	// За пределами последней страницы окно пустое, общее число считаем отдельно
+	if len(result.Hits) == 0 && search.Offset > 0 {
+		countQuery := bookMatches + " SELECT COUNT(*) FROM matches WHERE TRUE" + where
+		if err := tx.QueryRowContext(ctx, countQuery, args[:len(args)-2]...).Scan(&result.Total); err != nil {
+			return entities.BookSearchResult{}, err
+		}
+	}
+
+	if result.Facets, err = searchFacets(ctx, tx, search.Query); err != nil {
+		return entities.BookSearchResult{}, err
+	}
+	return result, tx.Commit()
+}
+
+// markHighlight экранирует текст и заменяет отметки ts_headline на <mark>
+func markHighlight(text string) string {
+	return highlightMarks.Replace(html.EscapeString(text))
+}
+
+var highlightMarks = strings.NewReplacer("\x02", "<mark>", "\x03", "</mark>")
+
+func searchFacets(ctx context.Context, tx queryer, q string) (entities.BookFacets, error) {
+	query := bookMatches + `
+	SELECT GROUPING(author) = 0, COALESCE(author, ''), COALESCE(available_copies > 0, FALSE), COUNT(*)
+	FROM matches
+	GROUP BY GROUPING SETS ((author), (available_copies > 0))
+	ORDER BY 1 DESC, 4 DESC, 2`
+
+	rows, err := tx.QueryContext(ctx, query, q)
+	if err != nil {
+		return entities.BookFacets{}, err
+	}
+	defer rows.Close()
+
+	facets := entities.BookFacets{Authors: []entities.FacetCount{}, Availability: []entities.FacetCount{}}
+	for rows.Next() {
+		var (
+			byAuthor, available bool
+			author              string
+			count               int
+		)
+		if err := rows.Scan(&byAuthor, &author, &available, &count); err != nil {
+			return entities.BookFacets{}, err
+		}
+		switch {
+		case !byAuthor:
+			facets.Availability = append(facets.Availability, entities.FacetCount{Value: strconv.FormatBool(available), Count: count})
+		case len(facets.Authors) < repositories.MaxAuthorFacets:
+			facets.Authors = append(facets.Authors, entities.FacetCount{Value: author, Count: count})
+		}
+	}
+	return facets, rows.Err()
+}
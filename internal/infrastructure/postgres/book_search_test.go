@@ -0,0 +1,71 @@
+package postgres
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+)
+
+func TestSearchBooks(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewPostgresBookRepository(db)
+	ctx := context.Background()
+
+	var hobbit, silmarillion int
+	if err := db.QueryRow("INSERT INTO book (book, author) VALUES ('The Hobbit', 'Searchtest Tolkien') RETURNING index").Scan(&hobbit); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.QueryRow("INSERT INTO book (book, author) VALUES ('The Silmarillion', 'Searchtest Tolkien') RETURNING index").Scan(&silmarillion); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Exec("DELETE FROM book WHERE index IN ($1, $2)", hobbit, silmarillion) })
+	if _, err := db.Exec("INSERT INTO copies (book_index, barcode) VALUES ($1, 'SEARCH-HOBBIT-01')", hobbit); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Exec("DELETE FROM copies WHERE book_index = $1", hobbit) })
+
+	result, err := repo.Search(ctx, repositories.BookSearch{Query: "hobbits", Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Hits) == 0 || result.Hits[0].Index != hobbit {
+		t.Fatalf("hits = %+v, want The Hobbit first", result.Hits)
+	}
+	if !strings.Contains(result.Hits[0].Highlight.Book, "<mark>Hobbit</mark>") {
+		t.Fatalf("highlight = %q", result.Hits[0].Highlight.Book)
+	}
+
+	// Опечатка в имени автора
+	result, err = repo.Search(ctx, repositories.BookSearch{Query: "Serchtest Tolkein", Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != 2 {
+		t.Fatalf("total = %d, want 2", result.Total)
+	}
+	if len(result.Facets.Authors) != 1 || result.Facets.Authors[0].Count != 2 {
+		t.Fatalf("author facets = %+v", result.Facets.Authors)
+	}
+
+	available := true
+	result, err = repo.Search(ctx, repositories.BookSearch{Query: "Searchtest", Available: &available, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != 1 || result.Hits[0].Index != hobbit {
+		t.Fatalf("available hits = %+v", result.Hits)
+	}
+	if len(result.Facets.Availability) != 2 {
+		t.Fatalf("availability facets = %+v, want both values", result.Facets.Availability)
+	}
+}
+
+func TestMarkHighlightEscapesText(t *testing.T) {
+	got := markHighlight("\x02War\x03 <img src=x onerror=alert(1)> & \x02Peace\x03")
+	want := "<mark>War</mark> &lt;img src=x onerror=alert(1)&gt; &amp; <mark>Peace</mark>"
+	if got != want {
+		t.Fatalf("markHighlight = %q, want %q", got, want)
+	}
+}
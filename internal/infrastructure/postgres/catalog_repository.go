@@ -0,0 +1,104 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/entities"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+)
+
+// CreateBook добавляет книгу с copies экземплярами. Индекс книги присваивает база,
+// повтор названия и автора отсекает уникальный индекс
+func (r *PostgresBookRepository) CreateBook(ctx context.Context, title, author string, copies int) (entities.Book, error) {
+	tx, err := r.db.begin(ctx)
+	if err != nil {
+		return entities.Book{}, err
+	}
+	defer tx.Rollback()
+
+	authorID, err := registerAuthor(ctx, tx, author)
+	if err != nil {
+		return entities.Book{}, err
+	}
+
+	book := entities.Book{Book: title, Author: author}
+	err = tx.QueryRowContext(ctx, "INSERT INTO book (book, author, author_id) VALUES ($1, $2, $3) RETURNING index, take_count",
+		title, author, authorID).Scan(&book.Index, &book.TakeCount)
+	if err != nil {
+		return entities.Book{}, bookExistsError(err)
+	}
+	for n := 1; n <= copies; n++ {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO copies (book_index, barcode) VALUES ($1, $2)", book.Index, entities.CopyBarcode(book.Index, n)); err != nil {
+			return entities.Book{}, err
+		}
+	}
+	if err := countCopies(ctx, tx, &book); err != nil {
+		return entities.Book{}, err
+	}
+	return book, tx.Commit()
+}
+
+// UpdateBook меняет название и автора книги и возвращает ее в новом виде
+func (r *PostgresBookRepository) UpdateBook(ctx context.Context, index int, title, author string) (entities.Book, error) {
+	tx, err := r.db.begin(ctx)
+	if err != nil {
+		return entities.Book{}, err
+	}
+	defer tx.Rollback()
+
+	authorID, err := registerAuthor(ctx, tx, author)
+	if err != nil {
+		return entities.Book{}, err
+	}
+
+	var book entities.Book
+	err = tx.QueryRowContext(ctx, "UPDATE book SET book = $1, author = $2, author_id = $3 WHERE index = $4 RETURNING index, book, author, take_count",
+		title, author, authorID, index).Scan(&book.Index, &book.Book, &book.Author, &book.TakeCount)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entities.Book{}, repositories.ErrBookNotFound
+	}
+	if err != nil {
+		return entities.Book{}, bookExistsError(err)
+	}
+	if err := countCopies(ctx, tx, &book); err != nil {
+		return entities.Book{}, err
+	}
+	return book, tx.Commit()
+}
+
+// ListLoans возвращает выдачи пользователя, начиная с последней
+func (r *PostgresBookRepository) ListLoans(ctx context.Context, userID int) ([]entities.Loan, error) {
+	query := `
+	SELECT l.id, l.book_index, l.copy_id, c.barcode, b.book, b.author, l.user_id, l.taken_at, l.due_at, l.returned_at, l.renewals, l.overdue
+	FROM loans l
+	JOIN book b ON b.index = l.book_index
+	JOIN copies c ON c.id = l.copy_id
+	WHERE l.user_id = $1
+	ORDER BY l.taken_at DESC`
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var loans []entities.Loan
+	for rows.Next() {
+		var loan entities.Loan
+		if err := rows.Scan(&loan.ID, &loan.BookIndex, &loan.CopyID, &loan.Barcode, &loan.Book, &loan.Author, &loan.UserID, &loan.TakenAt, &loan.DueAt, &loan.ReturnedAt, &loan.Renewals, &loan.Overdue); err != nil {
+			return nil, err
+		}
+		loans = append(loans, loan)
+	}
+	return loans, rows.Err()
+}
+
+func bookExistsError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return repositories.ErrBookExists
+	}
+	return err
+}
@@ -0,0 +1,72 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+)
+
+func TestCreateBookReturnsDatabaseIndex(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewPostgresBookRepository(db)
+	ctx := context.Background()
+	title := fmt.Sprintf("Catalog test %d", time.Now().UnixNano())
+	author := title + " author"
+
+	book, err := repo.CreateBook(ctx, title, author, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Exec("DELETE FROM copies WHERE book_index = $1", book.Index)
+		db.Exec("DELETE FROM book WHERE index = $1", book.Index)
+		db.Exec("DELETE FROM authors WHERE name = $1", author)
+	})
+	if book.Index == 0 || book.TotalCopies != 2 || book.AvailableCopies != 2 {
+		t.Fatalf("created book = %+v", book)
+	}
+
+	if _, err := repo.CreateBook(ctx, title, author, 1); !errors.Is(err, repositories.ErrBookExists) {
+		t.Fatalf("second CreateBook error = %v, want repositories.ErrBookExists", err)
+	}
+
+	authors, err := NewPostgresAuthorRepository(db).List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, a := range authors {
+		found = found || a.Name == author
+	}
+	if !found {
+		t.Fatalf("author %q was not registered", author)
+	}
+}
+
+func TestUpdateBook(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewPostgresBookRepository(db)
+	ctx := context.Background()
+	index := insertTestBook(t, db, 1)
+
+	book, err := repo.UpdateBook(ctx, index, "Renamed book", "Test author")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if book.Index != index || book.Book != "Renamed book" || book.TotalCopies != 1 {
+		t.Fatalf("updated book = %+v", book)
+	}
+
+	other := insertTestBook(t, db, 1)
+	if _, err := repo.UpdateBook(ctx, other, "Renamed book", "Test author"); !errors.Is(err, repositories.ErrBookExists) {
+		t.Fatalf("UpdateBook to an existing title error = %v, want repositories.ErrBookExists", err)
+	}
+
+	if _, err := repo.UpdateBook(ctx, -1, "Missing", "Nobody"); !errors.Is(err, repositories.ErrBookNotFound) {
+		t.Fatalf("UpdateBook on missing book error = %v, want repositories.ErrBookNotFound", err)
+	}
+}
@@ -0,0 +1,131 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/entities"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+)
+
+type PostgresFineRepository struct {
+	db database
+}
+
+func NewPostgresFineRepository(db *sql.DB) *PostgresFineRepository {
+	return &PostgresFineRepository{db: sqlDB{db}}
+}
+
+const fineColumns = "f.id, f.user_id, f.loan_id, l.book_index, f.amount, f.paid, f.status, f.note, f.created_at, f.closed_at"
+
+// List возвращает все штрафы пользователя, начиная с последних
+func (r *PostgresFineRepository) List(ctx context.Context, userID int) ([]entities.Fine, error) {
+	query := "SELECT " + fineColumns + " FROM fines f JOIN loans l ON l.id = f.loan_id WHERE f.user_id = $1 ORDER BY f.created_at DESC"
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var fines []entities.Fine
+	for rows.Next() {
+		fine, err := scanFine(rows)
+		if err != nil {
+			return nil, err
+		}
+		fines = append(fines, fine)
+	}
+	return fines, rows.Err()
+}
+
+// Balance возвращает неоплаченный остаток по всем открытым штрафам пользователя
+func (r *PostgresFineRepository) Balance(ctx context.Context, userID int) (int64, error) {
+	var balance int64
+	query := "SELECT COALESCE(SUM(amount - paid), 0) FROM fines WHERE user_id = $1 AND status = $2"
+	err := r.db.QueryRowContext(ctx, query, userID, entities.FineOutstanding).Scan(&balance)
+	return balance, err
+}
+
+// Pay записывает ручную оплату штрафа. Штраф закрывается, когда оплачен полностью
+func (r *PostgresFineRepository) Pay(ctx context.Context, id int, amount int64, note string) (entities.Fine, error) {
+	tx, err := r.db.begin(ctx)
+	if err != nil {
+		return entities.Fine{}, err
+	}
+	defer tx.Rollback()
+
+	fine, err := lockOpenFine(ctx, tx, id)
+	if err != nil {
+		return entities.Fine{}, err
+	}
+	if amount <= 0 || amount > fine.Amount-fine.Paid {
+		return entities.Fine{}, repositories.ErrInvalidAmount
+	}
+
+	if _, err := tx.ExecContext(ctx, "INSERT INTO fine_payments (fine_id, amount, note) VALUES ($1, $2, $3)", id, amount, note); err != nil {
+		return entities.Fine{}, err
+	}
+
+	status := entities.FineOutstanding
+	if fine.Paid+amount == fine.Amount {
+		status = entities.FinePaid
+	}
+	fine, err = updateFine(ctx, tx, id, "UPDATE fines f SET paid = paid + $2, status = $3, closed_at = CASE WHEN $3 = 'outstanding' THEN NULL ELSE NOW() END FROM loans l WHERE f.id = $1 AND l.id = f.loan_id RETURNING "+fineColumns,
+		amount, status)
+	if err != nil {
+		return entities.Fine{}, err
+	}
+
+	return fine, tx.Commit()
+}
+
+// Waive списывает остаток штрафа
+func (r *PostgresFineRepository) Waive(ctx context.Context, id int, note string) (entities.Fine, error) {
+	tx, err := r.db.begin(ctx)
+	if err != nil {
+		return entities.Fine{}, err
+	}
+	defer tx.Rollback()
+
+	if _, err := lockOpenFine(ctx, tx, id); err != nil {
+		return entities.Fine{}, err
+	}
+
+	fine, err := updateFine(ctx, tx, id, "UPDATE fines f SET status = $2, note = $3, closed_at = NOW() FROM loans l WHERE f.id = $1 AND l.id = f.loan_id RETURNING "+fineColumns,
+		entities.FineWaived, note)
+	if err != nil {
+		return entities.Fine{}, err
+	}
+
+	return fine, tx.Commit()
+}
+
+func lockOpenFine(ctx context.Context, tx queryer, id int) (entities.Fine, error) {
+	row := tx.QueryRowContext(ctx, "SELECT "+fineColumns+" FROM fines f JOIN loans l ON l.id = f.loan_id WHERE f.id = $1 FOR UPDATE OF f", id)
+	fine, err := scanFine(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entities.Fine{}, repositories.ErrFineNotFound
+	}
+	if err != nil {
+		return entities.Fine{}, err
+	}
+	if fine.Status != entities.FineOutstanding {
+		return entities.Fine{}, repositories.ErrFineClosed
+	}
+	return fine, nil
+}
+
+func updateFine(ctx context.Context, tx queryer, id int, query string, args ...interface{}) (entities.Fine, error) {
+	return scanFine(tx.QueryRowContext(ctx, query, append([]interface{}{id}, args...)...))
+}
+
+type fineScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanFine(row fineScanner) (entities.Fine, error) {
+	var fine entities.Fine
+	err := row.Scan(&fine.ID, &fine.UserID, &fine.LoanID, &fine.BookIndex, &fine.Amount, &fine.Paid, &fine.Status, &fine.Note, &fine.CreatedAt, &fine.ClosedAt)
+	return fine, err
+}
@@ -0,0 +1,83 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/entities"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+)
+
+func TestOverdueFine(t *testing.T) {
+	due := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		returned time.Time
+		want     int64
+	}{
+		{due.Add(-time.Hour), 0},
+		{due, 0},
+		{due.Add(time.Minute), 100},
+		{due.Add(24 * time.Hour), 100},
+		{due.Add(25 * time.Hour), 200},
+	}
+	for _, c := range cases {
+		if got := entities.OverdueFine(due, c.returned, 100); got != c.want {
+			t.Errorf("entities.OverdueFine(%v) = %d, want %d", c.returned.Sub(due), got, c.want)
+		}
+	}
+}
+
+func TestFinePayAndWaive(t *testing.T) {
+	db := openTestDB(t)
+	books := NewPostgresBookRepository(db)
+	fines := NewPostgresFineRepository(db)
+	index := insertTestBook(t, db)
+	ctx := context.Background()
+	reader := insertTestUser(t, db, "fined-reader")
+
+	// Книга просрочена на два начатых дня
+	if _, err := books.TakeBook(ctx, index, reader, time.Now().Add(-25*time.Hour), "", 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := books.ReturnBook(ctx, index, reader, time.Hour, 100); err != nil {
+		t.Fatal(err)
+	}
+
+	balance, err := fines.Balance(ctx, reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance != 200 {
+		t.Fatalf("expected balance 200, got %d", balance)
+	}
+
+	list, err := fines.List(ctx, reader)
+	if err != nil || len(list) != 1 {
+		t.Fatalf("expected one fine, got %v %v", list, err)
+	}
+	id := list[0].ID
+
+	if _, err := fines.Pay(ctx, id, 500, ""); !errors.Is(err, repositories.ErrInvalidAmount) {
+		t.Fatalf("expected repositories.ErrInvalidAmount for overpayment, got %v", err)
+	}
+	fine, err := fines.Pay(ctx, id, 50, "cash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fine.Paid != 50 || fine.Status != entities.FineOutstanding {
+		t.Fatalf("unexpected fine after partial payment: %+v", fine)
+	}
+
+	fine, err = fines.Waive(ctx, id, "first time")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fine.Status != entities.FineWaived || fine.ClosedAt == nil {
+		t.Fatalf("unexpected fine after waive: %+v", fine)
+	}
+	if _, err := fines.Pay(ctx, id, 10, ""); !errors.Is(err, repositories.ErrFineClosed) {
+		t.Fatalf("expected repositories.ErrFineClosed, got %v", err)
+	}
+}
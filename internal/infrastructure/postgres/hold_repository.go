@@ -0,0 +1,227 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/entities"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+)
+
+// PlaceHold ставит пользователя в конец очереди на занятую книгу
+func (r *PostgresBookRepository) PlaceHold(ctx context.Context, index, userID int) (entities.Hold, error) {
+	tx, err := r.db.begin(ctx)
+	if err != nil {
+		return entities.Hold{}, err
+	}
+	defer tx.Rollback()
+
+	book, err := lockBook(ctx, tx, index)
+	if err != nil {
+		return entities.Hold{}, err
+	}
+	if err := countCopies(ctx, tx, &book); err != nil {
+		return entities.Hold{}, err
+	}
+	if book.AvailableCopies > 0 {
+		return entities.Hold{}, repositories.ErrBookAvailable
+	}
+
+	var exists bool
+	query := `
+	SELECT EXISTS(SELECT 1 FROM holds WHERE book_index = $1 AND user_id = $2 AND status IN ($3, $4))
+		OR EXISTS(SELECT 1 FROM loans WHERE book_index = $1 AND user_id = $2 AND returned_at IS NULL)`
+	err = tx.QueryRowContext(ctx, query, index, userID, entities.HoldWaiting, entities.HoldReady).Scan(&exists)
+	if err != nil {
+		return entities.Hold{}, err
+	}
+	if exists {
+		return entities.Hold{}, repositories.ErrHoldExists
+	}
+
+	hold := entities.Hold{
+		BookIndex: index,
+		Book:      book.Book,
+		UserID:    userID,
+		Status:    entities.HoldWaiting,
+	}
+	err = tx.QueryRowContext(ctx, "INSERT INTO holds (book_index, user_id, status) VALUES ($1, $2, $3) RETURNING id, created_at",
+		index, userID, entities.HoldWaiting).Scan(&hold.ID, &hold.CreatedAt)
+	if err != nil {
+		return entities.Hold{}, err
+	}
+
+	err = tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM holds WHERE book_index = $1 AND status = $2 AND id <= $3",
+		index, entities.HoldWaiting, hold.ID).Scan(&hold.Position)
+	if err != nil {
+		return entities.Hold{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return entities.Hold{}, err
+	}
+	return hold, nil
+}
+
+// CancelHold отменяет бронь пользователя. Если экземпляр уже был отложен для него,
+// он переходит следующему в очереди
+func (r *PostgresBookRepository) CancelHold(ctx context.Context, index, userID int, pickupWindow time.Duration) error {
+	tx, err := r.db.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := lockBook(ctx, tx, index); err != nil {
+		return err
+	}
+
+	var (
+		holdID int
+		status string
+		copyID sql.NullInt64
+	)
+	err = tx.QueryRowContext(ctx, "SELECT id, status, copy_id FROM holds WHERE book_index = $1 AND user_id = $2 AND status IN ($3, $4) FOR UPDATE",
+		index, userID, entities.HoldWaiting, entities.HoldReady).Scan(&holdID, &status, &copyID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return repositories.ErrHoldNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE holds SET status = $1 WHERE id = $2", entities.HoldCancelled, holdID); err != nil {
+		return err
+	}
+
+	if status == entities.HoldReady && copyID.Valid {
+		if err := assignNextHold(ctx, tx, index, int(copyID.Int64), pickupWindow); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ListHolds возвращает брони пользователя вместе с местом в очереди
+func (r *PostgresBookRepository) ListHolds(ctx context.Context, userID int) ([]entities.Hold, error) {
+	query := `
+	SELECT h.id, h.book_index, b.book, h.user_id, h.status, h.created_at, h.pickup_deadline, COALESCE(c.barcode, ''),
+		CASE WHEN h.status = $2 THEN
+			(SELECT COUNT(*) FROM holds q WHERE q.book_index = h.book_index AND q.status = $2 AND q.id <= h.id)
+		ELSE 0 END
+	FROM holds h
+	JOIN book b ON b.index = h.book_index
+	LEFT JOIN copies c ON c.id = h.copy_id AND h.status = $3
+	WHERE h.user_id = $1
+	ORDER BY h.created_at DESC`
+	rows, err := r.db.QueryContext(ctx, query, userID, entities.HoldWaiting, entities.HoldReady)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var holds []entities.Hold
+	for rows.Next() {
+		var hold entities.Hold
+		if err := rows.Scan(&hold.ID, &hold.BookIndex, &hold.Book, &hold.UserID, &hold.Status, &hold.CreatedAt, &hold.PickupDeadline, &hold.Barcode, &hold.Position); err != nil {
+			return nil, err
+		}
+		holds = append(holds, hold)
+	}
+	return holds, rows.Err()
+}
+
+// ExpireHolds снимает брони, по которым книгу не забрали вовремя, и передает книгу дальше по очереди
+func (r *PostgresBookRepository) ExpireHolds(ctx context.Context, pickupWindow time.Duration) (int64, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT DISTINCT book_index FROM holds WHERE status = $1 AND pickup_deadline < NOW()", entities.HoldReady)
+	if err != nil {
+		return 0, err
+	}
+	var indexes []int
+	for rows.Next() {
+		var index int
+		if err := rows.Scan(&index); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		indexes = append(indexes, index)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	var expired int64
+	for _, index := range indexes {
+		n, err := r.expireBookHold(ctx, index, pickupWindow)
+		if err != nil {
+			return expired, err
+		}
+		expired += n
+	}
+	return expired, nil
+}
+
+func (r *PostgresBookRepository) expireBookHold(ctx context.Context, index int, pickupWindow time.Duration) (int64, error) {
+	tx, err := r.db.begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := lockBook(ctx, tx, index); err != nil {
+		return 0, err
+	}
+
+	rows, err := tx.QueryContext(ctx, "UPDATE holds SET status = $1 WHERE book_index = $2 AND status = $3 AND pickup_deadline < NOW() RETURNING copy_id",
+		entities.HoldExpired, index, entities.HoldReady)
+	if err != nil {
+		return 0, err
+	}
+	var copyIDs []int
+	for rows.Next() {
+		var copyID int
+		if err := rows.Scan(&copyID); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		copyIDs = append(copyIDs, copyID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, copyID := range copyIDs {
+		if err := assignNextHold(ctx, tx, index, copyID, pickupWindow); err != nil {
+			return 0, err
+		}
+	}
+	return int64(len(copyIDs)), tx.Commit()
+}
+
+// assignNextHold откладывает освободившийся экземпляр для первого в очереди или делает его свободным,
+// если очередь пуста. Строка книги должна быть заблокирована вызывающей транзакцией
+func assignNextHold(ctx context.Context, tx queryer, index, copyID int, pickupWindow time.Duration) error {
+	var holdID int
+	err := tx.QueryRowContext(ctx, "SELECT id FROM holds WHERE book_index = $1 AND status = $2 ORDER BY id LIMIT 1 FOR UPDATE",
+		index, entities.HoldWaiting).Scan(&holdID)
+	if errors.Is(err, sql.ErrNoRows) {
+		_, err := tx.ExecContext(ctx, "UPDATE copies SET status = $1 WHERE id = $2", entities.CopyAvailable, copyID)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE holds SET status = $1, copy_id = $2, pickup_deadline = $3 WHERE id = $4",
+		entities.HoldReady, copyID, time.Now().Add(pickupWindow), holdID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "UPDATE copies SET status = $1 WHERE id = $2", entities.CopyOnHold, copyID)
+	return err
+}
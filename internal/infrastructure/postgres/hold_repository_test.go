@@ -0,0 +1,107 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/entities"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+)
+
+func TestHoldQueue(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewPostgresBookRepository(db)
+	index := insertTestBook(t, db)
+	firstReader := insertTestUser(t, db, "first")
+	secondReader := insertTestUser(t, db, "second")
+	owner := insertTestUser(t, db, "owner")
+	ctx := context.Background()
+	due := time.Now().Add(time.Hour)
+
+	if _, err := repo.PlaceHold(ctx, index, firstReader); !errors.Is(err, repositories.ErrBookAvailable) {
+		t.Fatalf("expected repositories.ErrBookAvailable, got %v", err)
+	}
+	if _, err := repo.TakeBook(ctx, index, owner, due, "", 0, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := repo.PlaceHold(ctx, index, firstReader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := repo.PlaceHold(ctx, index, secondReader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Position != 1 || second.Position != 2 {
+		t.Fatalf("unexpected queue positions %d and %d", first.Position, second.Position)
+	}
+	if _, err := repo.PlaceHold(ctx, index, firstReader); !errors.Is(err, repositories.ErrHoldExists) {
+		t.Fatalf("expected repositories.ErrHoldExists, got %v", err)
+	}
+
+	// После возврата книга откладывается для первого в очереди
+	book, err := repo.ReturnBook(ctx, index, owner, time.Hour, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !*book.Block {
+		t.Fatal("returned book must stay blocked for the next holder")
+	}
+	if _, err := repo.TakeBook(ctx, index, secondReader, due, "", 0, 0); !errors.Is(err, repositories.ErrBookTaken) {
+		t.Fatalf("expected repositories.ErrBookTaken for second holder, got %v", err)
+	}
+
+	// Истекшая бронь передает книгу следующему
+	if _, err := db.Exec("UPDATE holds SET pickup_deadline = NOW() - INTERVAL '1 minute' WHERE book_index = $1 AND status = $2", index, entities.HoldReady); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.ExpireHolds(ctx, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.TakeBook(ctx, index, firstReader, due, "", 0, 0); !errors.Is(err, repositories.ErrBookTaken) {
+		t.Fatalf("expected repositories.ErrBookTaken after expiry, got %v", err)
+	}
+	if _, err := repo.TakeBook(ctx, index, secondReader, due, "", 0, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	holds, err := repo.ListHolds(ctx, secondReader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(holds) != 1 || holds[0].Status != entities.HoldFulfilled {
+		t.Fatalf("unexpected holds: %+v", holds)
+	}
+}
+
+func TestCancelReadyHold(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewPostgresBookRepository(db)
+	index := insertTestBook(t, db)
+	firstReader := insertTestUser(t, db, "first")
+	owner := insertTestUser(t, db, "owner")
+	ctx := context.Background()
+
+	if _, err := repo.TakeBook(ctx, index, owner, time.Now().Add(time.Hour), "", 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.PlaceHold(ctx, index, firstReader); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.ReturnBook(ctx, index, owner, time.Hour, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.CancelHold(ctx, index, firstReader, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.CancelHold(ctx, index, firstReader, time.Hour); !errors.Is(err, repositories.ErrHoldNotFound) {
+		t.Fatalf("expected repositories.ErrHoldNotFound, got %v", err)
+	}
+
+	if status := copyStatus(t, db, index); status != entities.CopyAvailable {
+		t.Fatalf("copy must be released when the queue is empty, got %s", status)
+	}
+}
@@ -0,0 +1,277 @@
+package postgres
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+)
+
+type fieldKind int
+
+const (
+	kindText fieldKind = iota
+	kindInt
+	kindBool
+)
+
+// listField поле, по которому можно фильтровать и сортировать. value достает значение из строки для курсора
+type listField[T any] struct {
+	column string
+	kind   fieldKind
+	value  func(T) interface{}
+}
+
+// listSchema описывает список: базовый запрос, разрешенные поля и ключ, замыкающий сортировку
+type listSchema[T any] struct {
+	from   string // Подзапрос или таблица, к колонкам которой обращаются поля
+	fields map[string]listField[T]
+	key    string // Уникальное поле, делает порядок однозначным
+	scan   func(fineScanner) (T, error)
+}
+
+type cursor struct {
+	Sort   string        `json:"s"`
+	Values []interface{} `json:"v"`
+}
+
+// list выбирает страницу по ключу последней строки (keyset), а не по смещению,
+// поэтому вставки между запросами не сдвигают страницы
+func (s listSchema[T]) list(ctx context.Context, db queryer, opts repositories.ListOptions) (repositories.Page[T], error) {
+	limit := opts.Limit
+	if limit == 0 {
+		limit = repositories.DefaultListLimit
+	}
+	if limit < 1 || limit > repositories.MaxListLimit {
+		return repositories.Page[T]{}, fmt.Errorf("%w: limit must be between 1 and %d", repositories.ErrInvalidListOptions, repositories.MaxListLimit)
+	}
+
+	sortFields, err := s.sortFields(opts.Sort)
+	if err != nil {
+		return repositories.Page[T]{}, err
+	}
+
+	var (
+		where []string
+		args  []interface{}
+	)
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	for _, filter := range opts.Filters {
+		condition, err := s.filter(filter, arg)
+		if err != nil {
+			return repositories.Page[T]{}, err
+		}
+		where = append(where, condition)
+	}
+
+	var total int
+	countQuery := "SELECT COUNT(*) FROM " + s.from + whereClause(where)
+	if err := db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+		return repositories.Page[T]{}, err
+	}
+
+	if opts.After != "" {
+		condition, err := s.after(opts.After, sortFields, arg)
+		if err != nil {
+			return repositories.Page[T]{}, err
+		}
+		where = append(where, condition)
+	}
+
+	order := make([]string, len(sortFields))
+	for i, f := range sortFields {
+		order[i] = s.fields[f.Field].column
+		if f.Desc {
+			order[i] += " DESC"
+		}
+	}
+
+	// Лишняя строка показывает, есть ли следующая страница
+	query := fmt.Sprintf("SELECT * FROM %s%s ORDER BY %s LIMIT %s", s.from, whereClause(where), strings.Join(order, ", "), arg(limit+1))
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return repositories.Page[T]{}, err
+	}
+	defer rows.Close()
+
+	page := repositories.Page[T]{Items: []T{}, Total: total}
+	for rows.Next() {
+		item, err := s.scan(rows)
+		if err != nil {
+			return repositories.Page[T]{}, err
+		}
+		page.Items = append(page.Items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return repositories.Page[T]{}, err
+	}
+
+	if len(page.Items) > limit {
+		page.Items = page.Items[:limit]
+		page.NextCursor, err = s.encodeCursor(sortFields, page.Items[limit-1])
+		if err != nil {
+			return repositories.Page[T]{}, err
+		}
+	}
+	return page, nil
+}
+
+// sortFields проверяет поля сортировки и добавляет ключ, если его нет
+func (s listSchema[T]) sortFields(requested []repositories.SortField) ([]repositories.SortField, error) {
+	fields := make([]repositories.SortField, 0, len(requested)+1)
+	seen := map[string]bool{}
+	for _, f := range requested {
+		if _, ok := s.fields[f.Field]; !ok {
+			return nil, fmt.Errorf("%w: cannot sort by %q", repositories.ErrInvalidListOptions, f.Field)
+		}
+		if seen[f.Field] {
+			return nil, fmt.Errorf("%w: %q appears twice in sort", repositories.ErrInvalidListOptions, f.Field)
+		}
+		seen[f.Field] = true
+		fields = append(fields, f)
+	}
+	if !seen[s.key] {
+		fields = append(fields, repositories.SortField{Field: s.key})
+	}
+	return fields, nil
+}
+
+func (s listSchema[T]) filter(filter repositories.Filter, arg func(interface{}) string) (string, error) {
+	field, ok := s.fields[filter.Field]
+	if !ok {
+		return "", fmt.Errorf("%w: cannot filter by %q", repositories.ErrInvalidListOptions, filter.Field)
+	}
+
+	switch {
+	case filter.Op == repositories.FilterContains && field.kind == kindText:
+		return fmt.Sprintf("%s ILIKE %s", field.column, arg("%"+likeEscaper.Replace(filter.Value)+"%")), nil
+	case filter.Op == repositories.FilterContains:
+		return "", fmt.Errorf("%w: %q does not support ~=", repositories.ErrInvalidListOptions, filter.Field)
+	case filter.Op != repositories.FilterEquals:
+		return "", fmt.Errorf("%w: unknown filter operator %q", repositories.ErrInvalidListOptions, filter.Op)
+	}
+
+	value, err := parseValue(field.kind, filter.Value)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s: %v", repositories.ErrInvalidListOptions, filter.Field, err)
+	}
+	if field.kind == kindText {
+		return fmt.Sprintf("LOWER(%s) = LOWER(%s)", field.column, arg(value)), nil
+	}
+	return fmt.Sprintf("%s = %s", field.column, arg(value)), nil
+}
+
+// after строит условие «строго после курсора» с учетом направления каждого поля сортировки
+func (s listSchema[T]) after(token string, sortFields []repositories.SortField, arg func(interface{}) string) (string, error) {
+	invalid := fmt.Errorf("%w: invalid cursor", repositories.ErrInvalidListOptions)
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return "", invalid
+	}
+	var c cursor
+	if err := json.Unmarshal(raw, &c); err != nil || len(c.Values) != len(sortFields) {
+		return "", invalid
+	}
+	if c.Sort != sortString(sortFields) {
+		return "", fmt.Errorf("%w: cursor was issued for a different sort", repositories.ErrInvalidListOptions)
+	}
+
+	placeholders := make([]string, len(sortFields))
+	for i, f := range sortFields {
+		value, ok := cursorValue(s.fields[f.Field].kind, c.Values[i])
+		if !ok {
+			return "", invalid
+		}
+		placeholders[i] = arg(value)
+	}
+
+	var alternatives []string
+	for i, f := range sortFields {
+		var parts []string
+		for j := 0; j < i; j++ {
+			parts = append(parts, fmt.Sprintf("%s = %s", s.fields[sortFields[j].Field].column, placeholders[j]))
+		}
+		op := ">"
+		if f.Desc {
+			op = "<"
+		}
+		parts = append(parts, fmt.Sprintf("%s %s %s", s.fields[f.Field].column, op, placeholders[i]))
+		alternatives = append(alternatives, "("+strings.Join(parts, " AND ")+")")
+	}
+	return "(" + strings.Join(alternatives, " OR ") + ")", nil
+}
+
+func (s listSchema[T]) encodeCursor(sortFields []repositories.SortField, last T) (string, error) {
+	c := cursor{Sort: sortString(sortFields)}
+	for _, f := range sortFields {
+		c.Values = append(c.Values, s.fields[f.Field].value(last))
+	}
+	raw, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func sortString(fields []repositories.SortField) string {
+	parts := make([]string, len(fields))
+	for i, f := range fields {
+		parts[i] = f.Field
+		if f.Desc {
+			parts[i] = "-" + f.Field
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+func parseValue(kind fieldKind, value string) (interface{}, error) {
+	switch kind {
+	case kindInt:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an integer", value)
+		}
+		return n, nil
+	case kindBool:
+		switch value {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+		return nil, fmt.Errorf("%q is not true or false", value)
+	}
+	return value, nil
+}
+
+// cursorValue приводит значение из JSON курсора к типу поля
+func cursorValue(kind fieldKind, value interface{}) (interface{}, bool) {
+	switch kind {
+	case kindInt:
+		n, ok := value.(float64)
+		return int(n), ok && n == float64(int(n))
+	case kindBool:
+		b, ok := value.(bool)
+		return b, ok
+	}
+	str, ok := value.(string)
+	return str, ok
+}
+
+func whereClause(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(conditions, " AND ")
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
@@ -0,0 +1,111 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/entities"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+)
+
+func TestListCursorRoundTrip(t *testing.T) {
+	sortFields, err := bookList.sortFields([]repositories.SortField{{Field: "take_count", Desc: true}, {Field: "author"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := bookList.encodeCursor(sortFields, entities.Book{Index: 7, Author: "Tolkien", TakeCount: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	condition, err := bookList.after(token, sortFields, arg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "((take_count < $1) OR (take_count = $1 AND author > $2) OR (take_count = $1 AND author = $2 AND index > $3))"
+	if condition != want {
+		t.Fatalf("condition = %s, want %s", condition, want)
+	}
+	if fmt.Sprint(args) != "[3 Tolkien 7]" {
+		t.Fatalf("args = %v", args)
+	}
+
+	// Курсор другой сортировки отклоняется
+	if _, err := bookList.after(token, []repositories.SortField{{Field: "index"}}, arg); !errors.Is(err, repositories.ErrInvalidListOptions) {
+		t.Fatalf("foreign cursor: %v, want repositories.ErrInvalidListOptions", err)
+	}
+	if _, err := bookList.after("not a cursor", sortFields, arg); !errors.Is(err, repositories.ErrInvalidListOptions) {
+		t.Fatalf("garbage cursor: %v, want repositories.ErrInvalidListOptions", err)
+	}
+}
+
+func TestListRejectsUnknownFields(t *testing.T) {
+	arg := func(interface{}) string { return "$1" }
+
+	if _, err := bookList.sortFields([]repositories.SortField{{Field: "password"}}); !errors.Is(err, repositories.ErrInvalidListOptions) {
+		t.Fatalf("unknown sort: %v", err)
+	}
+	if _, err := bookList.sortFields([]repositories.SortField{{Field: "author"}, {Field: "author", Desc: true}}); !errors.Is(err, repositories.ErrInvalidListOptions) {
+		t.Fatalf("repeated sort: %v", err)
+	}
+	for _, f := range []repositories.Filter{
+		{Field: "password", Op: repositories.FilterEquals, Value: "x"},
+		{Field: "available", Op: repositories.FilterEquals, Value: "yes"},
+		{Field: "take_count", Op: repositories.FilterEquals, Value: "1.5"},
+		{Field: "take_count", Op: repositories.FilterContains, Value: "1"},
+	} {
+		if _, err := bookList.filter(f, arg); !errors.Is(err, repositories.ErrInvalidListOptions) {
+			t.Errorf("filter %+v: %v, want repositories.ErrInvalidListOptions", f, err)
+		}
+	}
+}
+
+func TestUserListPagination(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewPostgresUserRepository(db)
+	ctx := context.Background()
+
+	for _, name := range []string{"Pager Carol", "Pager Alice", "Pager Bob"} {
+		user, err := repo.Create(ctx, entities.User{Name: name})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Exec("DELETE FROM users WHERE id = $1", user.ID) })
+	}
+
+	opts := repositories.ListOptions{
+		Limit:   2,
+		Sort:    []repositories.SortField{{Field: "name", Desc: true}},
+		Filters: []repositories.Filter{{Field: "name", Op: repositories.FilterContains, Value: "pager"}},
+	}
+	var names []string
+	for {
+		page, err := repo.List(ctx, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if page.Total != 3 {
+			t.Fatalf("total = %d, want 3", page.Total)
+		}
+		for _, u := range page.Items {
+			names = append(names, u.Name)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		opts.After = page.NextCursor
+	}
+
+	if fmt.Sprint(names) != "[Pager Carol Pager Bob Pager Alice]" {
+		t.Fatalf("names = %v", names)
+	}
+}
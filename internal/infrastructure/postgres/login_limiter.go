@@ -0,0 +1,76 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/infrastructure/limiter"
+)
+
+// PostgresLoginLimiter хранит счетчики неудачных входов в базе, чтобы их видели все экземпляры сервиса
+type PostgresLoginLimiter struct {
+	db     *sql.DB
+	policy limiter.Policy
+	scope  string // user или ip, чтобы счетчики разных видов не пересекались
+}
+
+func NewPostgresLoginLimiter(db *sql.DB, policy limiter.Policy, scope string) *PostgresLoginLimiter {
+	return &PostgresLoginLimiter{db: db, policy: policy, scope: scope}
+}
+
+func (l *PostgresLoginLimiter) Check(ctx context.Context, key string) (time.Duration, error) {
+	var wait float64
+	err := l.db.QueryRowContext(ctx, "SELECT GREATEST(EXTRACT(EPOCH FROM blocked_until - NOW()), 0) FROM login_failures WHERE scope = $1 AND key = $2", l.scope, key).Scan(&wait)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(wait * float64(time.Second)), nil
+}
+
+func (l *PostgresLoginLimiter) Fail(ctx context.Context, key string) (bool, error) {
+	tx, err := l.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	// Строка создается заранее, чтобы параллельные неудачи сериализовались на ее блокировке
+	if _, err := tx.ExecContext(ctx, "INSERT INTO login_failures (scope, key, last_failure) VALUES ($1, $2, 'epoch') ON CONFLICT DO NOTHING", l.scope, key); err != nil {
+		return false, err
+	}
+
+	var failures int
+	var lastFailure, now time.Time
+	err = tx.QueryRowContext(ctx, "SELECT failures, last_failure, NOW() FROM login_failures WHERE scope = $1 AND key = $2 FOR UPDATE", l.scope, key).
+		Scan(&failures, &lastFailure, &now)
+	if err != nil {
+		return false, err
+	}
+
+	failures, blockedUntil, locked := l.policy.Next(failures, lastFailure, now)
+	_, err = tx.ExecContext(ctx, "UPDATE login_failures SET failures = $3, last_failure = $4, blocked_until = $5 WHERE scope = $1 AND key = $2",
+		l.scope, key, failures, now, blockedUntil)
+	if err != nil {
+		return false, err
+	}
+	return locked, tx.Commit()
+}
+
+func (l *PostgresLoginLimiter) Reset(ctx context.Context, key string) error {
+	_, err := l.db.ExecContext(ctx, "DELETE FROM login_failures WHERE scope = $1 AND key = $2", l.scope, key)
+	return err
+}
+
+// Purge удаляет счетчики, которые уже не влияют ни на паузу, ни на блокировку
+func (l *PostgresLoginLimiter) Purge(ctx context.Context) (int64, error) {
+	res, err := l.db.ExecContext(ctx, "DELETE FROM login_failures WHERE scope = $1 AND blocked_until < NOW() AND last_failure < NOW() - $2 * INTERVAL '1 second'",
+		l.scope, l.policy.Lockout.Seconds())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,57 @@
+package postgres
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/infrastructure/limiter"
+)
+
+func TestLoginLimiterConcurrentFailures(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	l := NewPostgresLoginLimiter(db, limiter.Policy{MaxFailures: 5, BaseDelay: time.Second, MaxDelay: time.Second, Lockout: time.Minute}, "test")
+	t.Cleanup(func() { db.Exec("DELETE FROM login_failures WHERE scope = 'test'") })
+
+	// Параллельные неудачи не теряются, и блокировку получает ровно одна из них
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	lockouts := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			locked, err := l.Fail(ctx, "alice")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if locked {
+				mu.Lock()
+				lockouts++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if lockouts != 1 {
+		t.Fatalf("lockouts = %d, want 1", lockouts)
+	}
+	wait, err := l.Check(ctx, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wait < 50*time.Second {
+		t.Fatalf("wait = %v, want about a minute", wait)
+	}
+
+	if err := l.Reset(ctx, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if wait, _ := l.Check(ctx, "alice"); wait != 0 {
+		t.Fatalf("wait after reset = %v", wait)
+	}
+}
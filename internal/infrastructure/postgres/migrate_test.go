@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+)
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := loadMigrations(migrationFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("no migrations embedded")
+	}
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			t.Fatalf("migration %d has version %d, versions must be consecutive", i, m.Version)
+		}
+		if m.Up == "" || m.Down == "" {
+			t.Errorf("%04d_%s: both up and down files are required", m.Version, m.Name)
+		}
+	}
+}
+
+func TestLoadMigrationsRejectsBadFiles(t *testing.T) {
+	cases := map[string]fstest.MapFS{
+		"bad name":     {"migrations/init.sql": {Data: []byte("SELECT 1")}},
+		"no up":        {"migrations/0001_users.down.sql": {Data: []byte("SELECT 1")}},
+		"two names":    {"migrations/0001_a.up.sql": {Data: []byte("SELECT 1")}, "migrations/0001_b.down.sql": {Data: []byte("SELECT 1")}},
+		"wrong suffix": {"migrations/0001_users.sideways.sql": {Data: []byte("SELECT 1")}},
+	}
+	for name, files := range cases {
+		if _, err := loadMigrations(files); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestMigrateDownAndUp(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	applied, err := migrator.Up(ctx)
+	if err != nil || len(applied) != 0 {
+		t.Fatalf("second up applied %v, err %v", applied, err)
+	}
+
+	last := migrator.migrations[len(migrator.migrations)-1]
+	reverted, err := migrator.Down(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reverted) != 1 || reverted[0].Version != last.Version {
+		t.Fatalf("reverted %v, want only %04d", reverted, last.Version)
+	}
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := statuses[len(statuses)-1]; s.Version != last.Version || s.AppliedAt != nil {
+		t.Fatalf("status after down = %+v, want pending", s)
+	}
+
+	if applied, err := migrator.Up(ctx); err != nil || len(applied) != 1 {
+		t.Fatalf("up after down applied %v, err %v", applied, err)
+	}
+
+	// Измененный файл уже примененной миграции останавливает миграции
+	if _, err := db.Exec("UPDATE schema_migrations SET checksum = 'edited' WHERE version = 1"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Exec("UPDATE schema_migrations SET checksum = $1 WHERE version = 1", migrator.migrations[0].Checksum)
+	})
+	if _, err := migrator.Up(ctx); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("up with edited migration: %v, want ErrChecksumMismatch", err)
+	}
+}
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS book (
+	index SERIAL PRIMARY KEY,
+	book VARCHAR(50) NOT NULL,
+	author VARCHAR(255) NOT NULL,
+	take_count INT DEFAULT 0
+);
+CREATE TABLE IF NOT EXISTS copies (
+	id SERIAL PRIMARY KEY,
+	book_index INT NOT NULL REFERENCES book(index),
+	barcode VARCHAR(64) NOT NULL UNIQUE,
+	status VARCHAR(20) NOT NULL DEFAULT 'available'
+);
+CREATE INDEX IF NOT EXISTS copies_book_status_idx ON copies (book_index, status);
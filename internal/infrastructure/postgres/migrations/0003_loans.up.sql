@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS loans (
+	id SERIAL PRIMARY KEY,
+	book_index INT NOT NULL REFERENCES book(index),
+	user_id INT NOT NULL REFERENCES users(id),
+	taken_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	returned_at TIMESTAMP NULL
+);
+ALTER TABLE loans ADD COLUMN IF NOT EXISTS user_id INT REFERENCES users(id);
+ALTER TABLE loans ADD COLUMN IF NOT EXISTS due_at TIMESTAMP NOT NULL DEFAULT NOW();
+ALTER TABLE loans ADD COLUMN IF NOT EXISTS renewals INT NOT NULL DEFAULT 0;
+ALTER TABLE loans ADD COLUMN IF NOT EXISTS overdue BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE loans ADD COLUMN IF NOT EXISTS copy_id INT REFERENCES copies(id);
+CREATE INDEX IF NOT EXISTS loans_user_idx ON loans (user_id);
+DROP INDEX IF EXISTS loans_active_book_idx;
+CREATE UNIQUE INDEX IF NOT EXISTS loans_active_copy_idx ON loans (copy_id) WHERE returned_at IS NULL;
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS holds (
+	id SERIAL PRIMARY KEY,
+	book_index INT NOT NULL REFERENCES book(index),
+	user_id INT NOT NULL REFERENCES users(id),
+	status VARCHAR(20) NOT NULL DEFAULT 'waiting',
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	pickup_deadline TIMESTAMP NULL
+);
+ALTER TABLE holds ADD COLUMN IF NOT EXISTS user_id INT REFERENCES users(id);
+ALTER TABLE holds ADD COLUMN IF NOT EXISTS copy_id INT REFERENCES copies(id);
+CREATE INDEX IF NOT EXISTS holds_queue_idx ON holds (book_index, status, id);
+CREATE UNIQUE INDEX IF NOT EXISTS holds_active_reader_idx ON holds (book_index, user_id) WHERE status IN ('waiting', 'ready');
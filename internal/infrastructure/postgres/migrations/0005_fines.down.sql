@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS fine_payments;
+DROP TABLE IF EXISTS fines;
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS fines (
+	id SERIAL PRIMARY KEY,
+	user_id INT NOT NULL REFERENCES users(id),
+	loan_id INT NOT NULL REFERENCES loans(id),
+	amount BIGINT NOT NULL,
+	paid BIGINT NOT NULL DEFAULT 0,
+	status VARCHAR(20) NOT NULL DEFAULT 'outstanding',
+	note TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	closed_at TIMESTAMP NULL
+);
+ALTER TABLE fines ADD COLUMN IF NOT EXISTS user_id INT REFERENCES users(id);
+CREATE INDEX IF NOT EXISTS fines_user_idx ON fines (user_id, status);
+CREATE TABLE IF NOT EXISTS fine_payments (
+	id SERIAL PRIMARY KEY,
+	fine_id INT NOT NULL REFERENCES fines(id),
+	amount BIGINT NOT NULL,
+	note TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1 @@
+-- Перенос данных не откатывается: экземпляры остаются, таблицы удаляются предыдущими миграциями
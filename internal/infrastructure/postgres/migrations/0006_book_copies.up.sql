@@ -0,0 +1,11 @@
+-- Книги, созданные до появления экземпляров, получают по одному экземпляру,
+-- выдачи и готовые брони привязываются к нему, колонка block удаляется
+INSERT INTO copies (book_index, barcode)
+SELECT b.index, 'LIB-' || LPAD(b.index::text, 6, '0') || '-01'
+FROM book b
+WHERE NOT EXISTS (SELECT 1 FROM copies c WHERE c.book_index = b.index);
+UPDATE loans SET copy_id = c.id FROM copies c WHERE loans.copy_id IS NULL AND c.book_index = loans.book_index;
+UPDATE holds SET copy_id = c.id FROM copies c WHERE holds.copy_id IS NULL AND holds.status = 'ready' AND c.book_index = holds.book_index;
+UPDATE copies SET status = 'on_loan' WHERE status = 'available' AND id IN (SELECT copy_id FROM loans WHERE returned_at IS NULL);
+UPDATE copies SET status = 'on_hold' WHERE status = 'available' AND id IN (SELECT copy_id FROM holds WHERE status = 'ready');
+ALTER TABLE book DROP COLUMN IF EXISTS block;
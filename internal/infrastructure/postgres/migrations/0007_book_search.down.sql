@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS book_author_trgm_idx;
+DROP INDEX IF EXISTS book_search_idx;
+ALTER TABLE book DROP COLUMN IF EXISTS search;
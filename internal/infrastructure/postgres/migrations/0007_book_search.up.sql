@@ -0,0 +1,8 @@
+-- Название разбирается английским словарем, имена авторов не стеммятся.
+-- Триграммный индекс по авторам нужен для поиска с опечатками
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+ALTER TABLE book ADD COLUMN IF NOT EXISTS search tsvector GENERATED ALWAYS AS (
+	setweight(to_tsvector('english', book), 'A') || setweight(to_tsvector('simple', author), 'B')
+) STORED;
+CREATE INDEX IF NOT EXISTS book_search_idx ON book USING GIN (search);
+CREATE INDEX IF NOT EXISTS book_author_trgm_idx ON book USING GIN (author gin_trgm_ops);
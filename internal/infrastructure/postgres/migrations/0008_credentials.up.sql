@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS credentials (
+	username VARCHAR(255) PRIMARY KEY,
+	password_hash VARCHAR(255) NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+ALTER TABLE credentials ADD COLUMN IF NOT EXISTS email VARCHAR(255);
+ALTER TABLE credentials ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMP;
+ALTER TABLE credentials ADD COLUMN IF NOT EXISTS user_id INT UNIQUE REFERENCES users(id) ON DELETE CASCADE;
+CREATE UNIQUE INDEX IF NOT EXISTS credentials_email_idx ON credentials(LOWER(email));
+CREATE TABLE IF NOT EXISTS user_roles (
+	username VARCHAR(255) NOT NULL REFERENCES credentials(username) ON DELETE CASCADE,
+	role VARCHAR(32) NOT NULL,
+	granted_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	PRIMARY KEY (username, role)
+);
+DO $$
+BEGIN
+	IF EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'credentials' AND column_name = 'role') THEN
+		INSERT INTO user_roles (username, role)
+		SELECT username, role FROM credentials WHERE role <> 'patron'
+		ON CONFLICT DO NOTHING;
+		ALTER TABLE credentials DROP COLUMN role;
+	END IF;
+END $$;
@@ -0,0 +1,2 @@
+-- Колонки username в выдачах, бронях и штрафах не восстанавливаются
+ALTER TABLE credentials ALTER COLUMN user_id DROP NOT NULL;
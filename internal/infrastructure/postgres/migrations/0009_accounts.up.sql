@@ -0,0 +1,39 @@
+-- Каждой учетной записи без пользователя создается строка users, выдачи, брони и штрафы
+-- переводятся с имени читателя на user_id. Читатели без учетной записи получают пользователя без логина
+WITH created AS (
+	INSERT INTO users (name, email, username)
+	SELECT LEFT(c.username, 50), COALESCE(c.email, ''), c.username
+	FROM credentials c
+	WHERE c.user_id IS NULL
+	RETURNING id, username
+)
+UPDATE credentials c SET user_id = created.id FROM created WHERE c.username = created.username;
+ALTER TABLE credentials ALTER COLUMN user_id SET NOT NULL;
+DO $$
+DECLARE
+	legacy RECORD;
+	new_id INT;
+BEGIN
+	IF EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'loans' AND column_name = 'username') THEN
+		FOR legacy IN
+			SELECT DISTINCT r.username FROM (
+				SELECT username FROM loans UNION SELECT username FROM holds UNION SELECT username FROM fines
+			) r
+			WHERE NOT EXISTS (SELECT 1 FROM users u WHERE u.username = r.username)
+		LOOP
+			INSERT INTO users (name, email) VALUES (LEFT(legacy.username, 50), '') RETURNING id INTO new_id;
+			UPDATE loans SET user_id = new_id WHERE username = legacy.username;
+			UPDATE holds SET user_id = new_id WHERE username = legacy.username;
+			UPDATE fines SET user_id = new_id WHERE username = legacy.username;
+		END LOOP;
+		UPDATE loans l SET user_id = u.id FROM users u WHERE l.user_id IS NULL AND u.username = l.username;
+		UPDATE holds h SET user_id = u.id FROM users u WHERE h.user_id IS NULL AND u.username = h.username;
+		UPDATE fines f SET user_id = u.id FROM users u WHERE f.user_id IS NULL AND u.username = f.username;
+		ALTER TABLE loans DROP COLUMN username;
+		ALTER TABLE holds DROP COLUMN username;
+		ALTER TABLE fines DROP COLUMN username;
+	END IF;
+END $$;
+ALTER TABLE loans ALTER COLUMN user_id SET NOT NULL;
+ALTER TABLE holds ALTER COLUMN user_id SET NOT NULL;
+ALTER TABLE fines ALTER COLUMN user_id SET NOT NULL;
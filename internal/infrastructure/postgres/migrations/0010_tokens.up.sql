@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+	id SERIAL PRIMARY KEY,
+	token_hash VARCHAR(64) NOT NULL UNIQUE,
+	family_id VARCHAR(64) NOT NULL,
+	username VARCHAR(255) NOT NULL REFERENCES credentials(username) ON DELETE CASCADE,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	expires_at TIMESTAMP NOT NULL,
+	used_at TIMESTAMP,
+	revoked_at TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS refresh_tokens_family_idx ON refresh_tokens(family_id);
+CREATE TABLE IF NOT EXISTS auth_tokens (
+	jti VARCHAR(64) PRIMARY KEY,
+	username VARCHAR(255) NOT NULL REFERENCES credentials(username) ON DELETE CASCADE,
+	purpose VARCHAR(32) NOT NULL,
+	email VARCHAR(255),
+	expires_at TIMESTAMP NOT NULL,
+	used_at TIMESTAMP
+);
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+	jti VARCHAR(64) PRIMARY KEY,
+	expires_at TIMESTAMP NOT NULL
+);
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS login_failures (
+	scope VARCHAR(16) NOT NULL,
+	key VARCHAR(255) NOT NULL,
+	failures INT NOT NULL DEFAULT 0,
+	last_failure TIMESTAMP NOT NULL DEFAULT NOW(),
+	blocked_until TIMESTAMP NOT NULL DEFAULT NOW(),
+	PRIMARY KEY (scope, key)
+);
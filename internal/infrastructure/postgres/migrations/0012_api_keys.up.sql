@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+	id SERIAL PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	prefix VARCHAR(16) NOT NULL UNIQUE,
+	key_hash VARCHAR(64) NOT NULL,
+	scopes TEXT[] NOT NULL DEFAULT '{}',
+	created_by VARCHAR(255) NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	last_used_at TIMESTAMP,
+	revoked_at TIMESTAMP
+);
@@ -0,0 +1,6 @@
+-- Справочник авторов вместо списка в памяти. Авторы существующих книг переносятся в него
+CREATE TABLE IF NOT EXISTS authors (
+	id SERIAL PRIMARY KEY,
+	name VARCHAR(255) NOT NULL UNIQUE
+);
+INSERT INTO authors (name) SELECT DISTINCT author FROM book ON CONFLICT (name) DO NOTHING;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS book_author_id_idx;
+ALTER TABLE book DROP COLUMN IF EXISTS author_id;
+ALTER TABLE authors DROP COLUMN IF EXISTS bio;
+ALTER TABLE authors DROP COLUMN IF EXISTS death_year;
+ALTER TABLE authors DROP COLUMN IF EXISTS birth_year;
@@ -0,0 +1,8 @@
+-- Годы жизни и биография автора. Книги ссылаются на автора по ID, имя в book остается для поиска
+ALTER TABLE authors ADD COLUMN IF NOT EXISTS birth_year INT;
+ALTER TABLE authors ADD COLUMN IF NOT EXISTS death_year INT;
+ALTER TABLE authors ADD COLUMN IF NOT EXISTS bio TEXT NOT NULL DEFAULT '';
+INSERT INTO authors (name) SELECT DISTINCT author FROM book ON CONFLICT (name) DO NOTHING;
+ALTER TABLE book ADD COLUMN IF NOT EXISTS author_id INT REFERENCES authors(id);
+UPDATE book b SET author_id = a.id FROM authors a WHERE a.name = b.author AND b.author_id IS NULL;
+CREATE INDEX IF NOT EXISTS book_author_id_idx ON book (author_id);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS book_title_author_idx;
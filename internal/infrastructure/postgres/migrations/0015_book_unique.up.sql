@@ -0,0 +1,32 @@
+-- Одна книга на пару название и автор. Проверка в приложении не спасала от одновременных запросов.
+-- Данные каталога миграция не меняет: если повторы уже есть, она останавливается с ошибкой.
+-- Повторы нужно объединить вручную до повторного запуска migrate up. Найти их можно так:
+--
+--   SELECT book, author, array_agg(index ORDER BY index) FROM book GROUP BY book, author HAVING COUNT(*) > 1;
+--
+-- Для каждой группы оставляется книга с меньшим index (:keep), остальные (:dup) переносятся на нее:
+--
+--   UPDATE copies SET book_index = :keep WHERE book_index = :dup;
+--   UPDATE loans  SET book_index = :keep WHERE book_index = :dup;
+--   UPDATE holds  SET book_index = :keep WHERE book_index = :dup;
+--   UPDATE book   SET take_count = take_count + (SELECT take_count FROM book WHERE index = :dup) WHERE index = :keep;
+--   DELETE FROM book WHERE index = :dup;
+--
+-- Если одна и та же книга действительно издана дважды, вместо объединения можно уточнить название
+DO $$
+DECLARE
+    duplicates TEXT;
+BEGIN
+    SELECT string_agg(format('%L by %L (indexes %s)', book, author, indexes), '; ')
+    INTO duplicates
+    FROM (
+        SELECT book, author, string_agg(index::TEXT, ', ' ORDER BY index) AS indexes
+        FROM book GROUP BY book, author HAVING COUNT(*) > 1
+    ) d;
+    IF duplicates IS NOT NULL THEN
+        RAISE EXCEPTION 'duplicate books must be merged before adding book_title_author_idx: %', duplicates
+            USING HINT = 'see the comment in migration 0015_book_unique for the merge steps';
+    END IF;
+END $$;
+
+CREATE UNIQUE INDEX IF NOT EXISTS book_title_author_idx ON book (book, author);
@@ -1,35 +1,48 @@
 package postgres
 
-import "database/sql"
+import (
+	"database/sql"
+
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+)
+
+var (
+	_ repositories.BookRepository   = (*PostgresBookRepository)(nil)
+	_ repositories.UserRepository   = (*PostgresUserRepository)(nil)
+	_ repositories.AuthorRepository = (*PostgresAuthorRepository)(nil)
+	_ repositories.FineRepository   = (*PostgresFineRepository)(nil)
+	_ repositories.AuthRepository   = (*PostgresAuthRepository)(nil)
+	_ repositories.APIKeyRepository = (*PostgresAPIKeyRepository)(nil)
+)
 
 func NewPostgresUserRepository(db *sql.DB) *PostgresUserRepository {
-	return &PostgresUserRepository{Db: db}
+	return &PostgresUserRepository{db: sqlDB{db}}
 }
 
 type PostgresBookRepository struct {
-	db *sql.DB
+	db database
 }
 
 func NewPostgresBookRepository(db *sql.DB) *PostgresBookRepository {
-	return &PostgresBookRepository{db: db}
+	return &PostgresBookRepository{db: sqlDB{db}}
 }
 
 type PostgresUserRepository struct {
-	Db *sql.DB
+	db database
 }
 
 func NewPostgresAuthorRepository(db *sql.DB) *PostgresAuthorRepository {
-	return &PostgresAuthorRepository{db: db}
+	return &PostgresAuthorRepository{db: sqlDB{db}}
 }
 
 type PostgresAuthorRepository struct {
-	db *sql.DB
+	db database
 }
 
 func NewPostgresAuthRepository(db *sql.DB) *PostgresAuthRepository {
-	return &PostgresAuthRepository{db: db}
+	return &PostgresAuthRepository{db: sqlDB{db}}
 }
 
 type PostgresAuthRepository struct {
-	db *sql.DB
+	db database
 }
@@ -0,0 +1,104 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+)
+
+// CreateRefreshToken сохраняет хеш нового refresh-токена в семействе familyID
+func (r *PostgresAuthRepository) CreateRefreshToken(ctx context.Context, username, familyID, tokenHash string, expiresAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, "INSERT INTO refresh_tokens (token_hash, family_id, username, expires_at) VALUES ($1, $2, $3, $4)",
+		tokenHash, familyID, username, expiresAt)
+	return err
+}
+
+// RotateRefreshToken погашает refresh-токен и выпускает в том же семействе новый.
+// Повторное предъявление уже погашенного токена отзывает все семейство
+func (r *PostgresAuthRepository) RotateRefreshToken(ctx context.Context, oldHash, newHash string, expiresAt time.Time) (username, familyID string, err error) {
+	tx, err := r.db.begin(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	defer tx.Rollback()
+
+	var usedAt, revokedAt sql.NullTime
+	var tokenExpiresAt time.Time
+	err = tx.QueryRowContext(ctx, "SELECT username, family_id, expires_at, used_at, revoked_at FROM refresh_tokens WHERE token_hash = $1 FOR UPDATE", oldHash).
+		Scan(&username, &familyID, &tokenExpiresAt, &usedAt, &revokedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", repositories.ErrTokenInvalid
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	if revokedAt.Valid {
+		return "", "", repositories.ErrTokenInvalid
+	}
+	if usedAt.Valid {
+		// Токен уже обменивали: его украли либо у клиента, либо у нас, закрываем всю сессию
+		if _, err := tx.ExecContext(ctx, "UPDATE refresh_tokens SET revoked_at = NOW() WHERE family_id = $1 AND revoked_at IS NULL", familyID); err != nil {
+			return "", "", err
+		}
+		if err := tx.Commit(); err != nil {
+			return "", "", err
+		}
+		return "", "", repositories.ErrTokenReused
+	}
+	if time.Now().After(tokenExpiresAt) {
+		return "", "", repositories.ErrTokenInvalid
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE refresh_tokens SET used_at = NOW() WHERE token_hash = $1", oldHash); err != nil {
+		return "", "", err
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO refresh_tokens (token_hash, family_id, username, expires_at) VALUES ($1, $2, $3, $4)",
+		newHash, familyID, username, expiresAt); err != nil {
+		return "", "", err
+	}
+
+	return username, familyID, tx.Commit()
+}
+
+// RevokeFamily отзывает все refresh-токены сессии
+func (r *PostgresAuthRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE refresh_tokens SET revoked_at = NOW() WHERE family_id = $1 AND revoked_at IS NULL", familyID)
+	return err
+}
+
+// DenyAccessToken запрещает access-токен с указанным jti до истечения его срока
+func (r *PostgresAuthRepository) DenyAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, "INSERT INTO revoked_tokens (jti, expires_at) VALUES ($1, $2) ON CONFLICT DO NOTHING", jti, expiresAt)
+	return err
+}
+
+// IsAccessTokenDenied сообщает, отозван ли access-токен
+func (r *PostgresAuthRepository) IsAccessTokenDenied(ctx context.Context, jti string) (bool, error) {
+	var denied bool
+	err := r.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $1)", jti).Scan(&denied)
+	return denied, err
+}
+
+// PurgeExpiredTokens удаляет истекшие записи списка отзыва и refresh-токенов
+func (r *PostgresAuthRepository) PurgeExpiredTokens(ctx context.Context) (int64, error) {
+	var total int64
+	for _, query := range []string{
+		"DELETE FROM revoked_tokens WHERE expires_at < NOW()",
+		"DELETE FROM refresh_tokens WHERE expires_at < NOW()",
+	} {
+		res, err := r.db.ExecContext(ctx, query)
+		if err != nil {
+			return total, err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	return total, nil
+}
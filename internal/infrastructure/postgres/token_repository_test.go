@@ -0,0 +1,52 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+)
+
+func TestRotateRefreshTokenReuse(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewPostgresAuthRepository(db)
+	ctx := context.Background()
+
+	const username = "test-refresh-user"
+	if _, err := repo.Create(ctx, username, "", "hash"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Exec("DELETE FROM users WHERE username = $1", username) })
+
+	expiresAt := time.Now().Add(time.Hour)
+	if err := repo.CreateRefreshToken(ctx, username, "family", "t1", expiresAt); err != nil {
+		t.Fatal(err)
+	}
+
+	gotUser, gotFamily, err := repo.RotateRefreshToken(ctx, "t1", "t2", expiresAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotUser != username || gotFamily != "family" {
+		t.Fatalf("rotate returned (%s, %s)", gotUser, gotFamily)
+	}
+
+	// Повторное использование t1 отзывает все семейство, включая свежий t2
+	if _, _, err := repo.RotateRefreshToken(ctx, "t1", "t3", expiresAt); !errors.Is(err, repositories.ErrTokenReused) {
+		t.Fatalf("reuse: %v, want repositories.ErrTokenReused", err)
+	}
+	if _, _, err := repo.RotateRefreshToken(ctx, "t2", "t4", expiresAt); !errors.Is(err, repositories.ErrTokenInvalid) {
+		t.Fatalf("rotate after reuse: %v, want repositories.ErrTokenInvalid", err)
+	}
+
+	if err := repo.DenyAccessToken(ctx, "test-jti", expiresAt); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Exec("DELETE FROM revoked_tokens WHERE jti = 'test-jti'") })
+	denied, err := repo.IsAccessTokenDenied(ctx, "test-jti")
+	if err != nil || !denied {
+		t.Fatalf("denied = %v, %v", denied, err)
+	}
+}
@@ -0,0 +1,104 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// queryer общие методы *sql.DB и *sql.Tx, через которые работают репозитории
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// database то, в чем репозиторий открывает свои транзакции: база или внешняя транзакция Tx
+type database interface {
+	queryer
+	begin(ctx context.Context) (transaction, error)
+}
+
+type transaction interface {
+	queryer
+	Commit() error
+	Rollback() error
+}
+
+type sqlDB struct {
+	*sql.DB
+}
+
+func (d sqlDB) begin(ctx context.Context) (transaction, error) {
+	return d.BeginTx(ctx, nil)
+}
+
+// Tx транзакция, в которой несколько репозиториев работают как одно целое.
+// Свои транзакции репозиториев внутри нее становятся точками сохранения
+type Tx struct {
+	*sql.Tx
+	savepoints int
+}
+
+func BeginTx(ctx context.Context, db *sql.DB) (*Tx, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Tx{Tx: tx}, nil
+}
+
+func (t *Tx) AuthRepository() *PostgresAuthRepository {
+	return &PostgresAuthRepository{db: t}
+}
+
+func (t *Tx) BookRepository() *PostgresBookRepository {
+	return &PostgresBookRepository{db: t}
+}
+
+func (t *Tx) AuthorRepository() *PostgresAuthorRepository {
+	return &PostgresAuthorRepository{db: t}
+}
+
+func (t *Tx) UserRepository() *PostgresUserRepository {
+	return &PostgresUserRepository{db: t}
+}
+
+func (t *Tx) FineRepository() *PostgresFineRepository {
+	return &PostgresFineRepository{db: t}
+}
+
+func (t *Tx) begin(ctx context.Context) (transaction, error) {
+	t.savepoints++
+	sp := &savepoint{Tx: t.Tx, ctx: ctx, name: fmt.Sprintf("repo_%d", t.savepoints)}
+	if _, err := t.ExecContext(ctx, "SAVEPOINT "+sp.name); err != nil {
+		return nil, err
+	}
+	return sp, nil
+}
+
+// savepoint вложенная транзакция репозитория. Rollback после Commit ничего не делает, как у sql.Tx
+type savepoint struct {
+	*sql.Tx
+	ctx  context.Context
+	name string
+	done bool
+}
+
+func (s *savepoint) Commit() error {
+	if s.done {
+		return sql.ErrTxDone
+	}
+	s.done = true
+	_, err := s.ExecContext(s.ctx, "RELEASE SAVEPOINT "+s.name)
+	return err
+}
+
+func (s *savepoint) Rollback() error {
+	if s.done {
+		return sql.ErrTxDone
+	}
+	s.done = true
+	_, err := s.ExecContext(s.ctx, "ROLLBACK TO SAVEPOINT "+s.name)
+	return err
+}
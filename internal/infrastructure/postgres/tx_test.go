@@ -0,0 +1,45 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+)
+
+func TestTxRepositoriesShareTransaction(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	const username = "test-tx-user"
+	t.Cleanup(func() { db.Exec("DELETE FROM users WHERE username = $1", username) })
+
+	tx, err := BeginTx(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	auth := tx.AuthRepository()
+	if _, err := auth.Create(ctx, username, "", "hash"); err != nil {
+		t.Fatal(err)
+	}
+	// Ошибка откатывает только точку сохранения, транзакция продолжает работать
+	if _, err := auth.Create(ctx, username, "", "hash"); !errors.Is(err, repositories.ErrUserExists) {
+		t.Fatalf("duplicate in tx: %v, want repositories.ErrUserExists", err)
+	}
+	if _, err := auth.GetUserID(ctx, username); err != nil {
+		t.Fatalf("user created in tx: %v", err)
+	}
+	if _, err := NewPostgresAuthRepository(db).GetUserID(ctx, username); !errors.Is(err, repositories.ErrUserNotFound) {
+		t.Fatalf("uncommitted user visible outside tx: %v", err)
+	}
+
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewPostgresAuthRepository(db).GetUserID(ctx, username); !errors.Is(err, repositories.ErrUserNotFound) {
+		t.Fatalf("user after rollback: %v, want repositories.ErrUserNotFound", err)
+	}
+}
@@ -2,57 +2,121 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 
+	"github.com/lib/pq"
 	"studentgit.kata.academy/Zhodaran/go-kata/internal/entities"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
 )
 
-func (r *PostgresUserRepository) Create(ctx context.Context, user entities.User) error {
-	query := "INSERT INTO users (id, name, email) VALUES ($1, $2, $3)"
-	_, err := r.Db.ExecContext(ctx, query, user.ID, user.Name, user.Email)
-	return err
+const userColumns = "id, COALESCE(username, ''), name, email, deleted_at"
+
+// Create добавляет пользователя без учетной записи и возвращает его с присвоенным ID
+func (r *PostgresUserRepository) Create(ctx context.Context, user entities.User) (entities.User, error) {
+	query := "INSERT INTO users (name, email) VALUES ($1, $2) RETURNING " + userColumns
+	created, err := scanUser(r.db.QueryRowContext(ctx, query, user.Name, user.Email))
+	return created, emailExistsError(err)
 }
 
-// GetByID получает пользователя по ID
-func (r *PostgresUserRepository) GetByID(ctx context.Context, id string) (entities.User, error) {
-	var user entities.User
-	query := "SELECT id, name, email, deleted_at FROM users WHERE id = $1"
-	err := r.Db.QueryRowContext(ctx, query, id).Scan(&user.ID, &user.Name, &user.Email, &user.DeletedAt)
+// GetByID получает пользователя по ID. Удаленные пользователи не находятся
+func (r *PostgresUserRepository) GetByID(ctx context.Context, id int) (entities.User, error) {
+	query := "SELECT " + userColumns + " FROM users WHERE id = $1 AND deleted_at IS NULL"
+	user, err := scanUser(r.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return entities.User{}, repositories.ErrUserNotFound
+	}
+	return user, err
+}
+
+// Update обновляет данные пользователя. Почта учетной записи меняется вместе с ним,
+// подтверждение сбрасывается, если адрес стал другим
+func (r *PostgresUserRepository) Update(ctx context.Context, user entities.User) (entities.User, error) {
+	tx, err := r.db.begin(ctx)
 	if err != nil {
 		return entities.User{}, err
 	}
-	return user, nil
-}
+	defer tx.Rollback()
 
-// Update обновляет данные пользователя
-func (r *PostgresUserRepository) Update(ctx context.Context, user entities.User) error {
-	query := "UPDATE users SET name = $1, email = $2 WHERE id = $3"
-	_, err := r.Db.ExecContext(ctx, query, user.Name, user.Email, user.ID)
-	return err
+	query := "UPDATE users SET name = $1, email = $2 WHERE id = $3 AND deleted_at IS NULL RETURNING " + userColumns
+	updated, err := scanUser(tx.QueryRowContext(ctx, query, user.Name, user.Email, user.ID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return entities.User{}, repositories.ErrUserNotFound
+	}
+	if err != nil {
+		return entities.User{}, emailExistsError(err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	UPDATE credentials SET
+		email = NULLIF($2, ''),
+		email_verified_at = CASE WHEN LOWER(email) = LOWER($2) THEN email_verified_at END,
+		updated_at = NOW()
+	WHERE user_id = $1`, user.ID, user.Email)
+	if err != nil {
+		return entities.User{}, emailExistsError(err)
+	}
+	return updated, tx.Commit()
 }
 
 // Delete помечает пользователя как удаленного
-func (r *PostgresUserRepository) Delete(ctx context.Context, id string) error {
-	query := "UPDATE users SET deleted_at = NOW() WHERE id = $1"
-	_, err := r.Db.ExecContext(ctx, query, id)
-	return err
-}
+func (r *PostgresUserRepository) Delete(ctx context.Context, id int) error {
+	tx, err := r.db.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, "UPDATE users SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL", id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return repositories.ErrUserNotFound
+	}
 
-// List возвращает список пользователей с пагинацией
-func (r *PostgresUserRepository) List(ctx context.Context, limit, offset int) ([]entities.User, error) {
-	query := "SELECT id, name, email, deleted_at FROM users WHERE deleted_at IS NULL LIMIT $1 OFFSET $2"
-	rows, err := r.Db.QueryContext(ctx, query, limit, offset)
+	// Открытые сессии удаленного пользователя больше не продлеваются
+	_, err = tx.ExecContext(ctx, `UPDATE refresh_tokens SET revoked_at = NOW()
+		WHERE username IN (SELECT username FROM credentials WHERE user_id = $1) AND revoked_at IS NULL`, id)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer rows.Close()
+	return tx.Commit()
+}
 
-	var users []entities.User
-	for rows.Next() {
-		var user entities.User
-		if err := rows.Scan(&user.ID, &user.Name, &user.Email, &user.DeletedAt); err != nil {
-			return nil, err
-		}
-		users = append(users, user)
+// userList поля, по которым можно фильтровать и сортировать пользователей
+var userList = listSchema[entities.User]{
+	from: `(SELECT id, COALESCE(username, '') AS username, name, email, deleted_at
+		FROM users WHERE deleted_at IS NULL) AS u`,
+	fields: map[string]listField[entities.User]{
+		"id":       {column: "id", kind: kindInt, value: func(u entities.User) interface{} { return u.ID }},
+		"username": {column: "username", kind: kindText, value: func(u entities.User) interface{} { return u.Username }},
+		"name":     {column: "name", kind: kindText, value: func(u entities.User) interface{} { return u.Name }},
+		"email":    {column: "email", kind: kindText, value: func(u entities.User) interface{} { return u.Email }},
+	},
+	key:  "id",
+	scan: scanUser,
+}
+
+// List возвращает страницу пользователей с фильтрами и сортировкой
+func (r *PostgresUserRepository) List(ctx context.Context, opts repositories.ListOptions) (repositories.Page[entities.User], error) {
+	return userList.list(ctx, r.db, opts)
+}
+
+func scanUser(row fineScanner) (entities.User, error) {
+	var user entities.User
+	err := row.Scan(&user.ID, &user.Username, &user.Name, &user.Email, &user.DeletedAt)
+	return user, err
+}
+
+func emailExistsError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return repositories.ErrEmailExists
 	}
-	return users, nil
+	return err
 }
@@ -0,0 +1,81 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/entities"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+)
+
+func TestUserCRUD(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewPostgresUserRepository(db)
+	ctx := context.Background()
+
+	created, err := repo.Create(ctx, entities.User{Name: "Reader", Email: "crud-reader@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Exec("DELETE FROM users WHERE id = $1", created.ID) })
+	if created.ID == 0 {
+		t.Fatal("created user has no id")
+	}
+
+	if _, err := repo.Create(ctx, entities.User{Name: "Copy", Email: "CRUD-reader@example.com"}); !errors.Is(err, repositories.ErrEmailExists) {
+		t.Fatalf("duplicate email: %v, want repositories.ErrEmailExists", err)
+	}
+
+	updated, err := repo.Update(ctx, entities.User{ID: created.ID, Name: "Renamed", Email: "crud-renamed@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Name != "Renamed" || updated.Email != "crud-renamed@example.com" {
+		t.Fatalf("updated user = %+v", updated)
+	}
+
+	if err := repo.Delete(ctx, created.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.GetByID(ctx, created.ID); !errors.Is(err, repositories.ErrUserNotFound) {
+		t.Fatalf("deleted user: %v, want repositories.ErrUserNotFound", err)
+	}
+	if err := repo.Delete(ctx, created.ID); !errors.Is(err, repositories.ErrUserNotFound) {
+		t.Fatalf("second delete: %v, want repositories.ErrUserNotFound", err)
+	}
+	if _, err := repo.Update(ctx, updated); !errors.Is(err, repositories.ErrUserNotFound) {
+		t.Fatalf("update deleted: %v, want repositories.ErrUserNotFound", err)
+	}
+}
+
+func TestDeletedUserCannotLogIn(t *testing.T) {
+	db := openTestDB(t)
+	auth := NewPostgresAuthRepository(db)
+	ctx := context.Background()
+
+	const username = "test-deleted-user"
+	userID, err := auth.Create(ctx, username, "", "hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Exec("DELETE FROM users WHERE id = $1", userID) })
+	expiresAt := time.Now().Add(time.Hour)
+	if err := auth.CreateRefreshToken(ctx, username, "deleted-family", "deleted-t1", expiresAt); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := NewPostgresUserRepository(db).Delete(ctx, userID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := auth.GetPasswordHash(ctx, username); !errors.Is(err, repositories.ErrUserNotFound) {
+		t.Fatalf("password hash of deleted user: %v, want repositories.ErrUserNotFound", err)
+	}
+	if _, err := auth.GetUserID(ctx, username); !errors.Is(err, repositories.ErrUserNotFound) {
+		t.Fatalf("user id of deleted user: %v, want repositories.ErrUserNotFound", err)
+	}
+	if _, _, err := auth.RotateRefreshToken(ctx, "deleted-t1", "deleted-t2", expiresAt); !errors.Is(err, repositories.ErrTokenInvalid) {
+		t.Fatalf("refresh after delete: %v, want repositories.ErrTokenInvalid", err)
+	}
+}
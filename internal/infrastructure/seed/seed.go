@@ -0,0 +1,283 @@
+package seed
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/brianvoe/gofakeit"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/entities"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/infrastructure/postgres"
+)
+
+// Config размеры набора данных. Одинаковые Seed и размеры дают одинаковый набор
+type Config struct {
+	Seed    int64
+	Authors int
+	Books   int
+	Users   int // Читатели, кроме учетных записей admin и librarian
+	Loans   int
+	Holds   int
+}
+
+// DefaultConfig набор, похожий на прежнее заполнение при запуске
+func DefaultConfig() Config {
+	return Config{Seed: 1, Authors: 10, Books: 100, Users: 50, Loans: 30, Holds: 10}
+}
+
+type BookFixture struct {
+	Title  string
+	Author string
+	Copies int
+}
+
+type UserFixture struct {
+	Username string
+	Name     string
+	Email    string
+	Role     string // Пусто для читателя
+}
+
+// LoanFixture выдача книги Book пользователю User (индексы в Dataset). DueInDays отрицателен для просроченных
+type LoanFixture struct {
+	Book      int
+	User      int
+	DueInDays int
+}
+
+type HoldFixture struct {
+	Book int
+	User int
+}
+
+// Dataset сгенерированный набор, еще не записанный в базу
+type Dataset struct {
+	Authors []string
+	Books   []BookFixture
+	Users   []UserFixture
+	Loans   []LoanFixture
+	Holds   []HoldFixture
+}
+
+// Plan генерирует набор без обращения к базе. Брони ставятся только на книги, все экземпляры
+// которых выданы, поэтому часть выдач уходит на то, чтобы занять такие книги целиком
+func Plan(cfg Config) Dataset {
+	gofakeit.Seed(cfg.Seed)
+	rnd := rand.New(rand.NewSource(cfg.Seed))
+	var data Dataset
+
+	for i := 0; i < cfg.Authors; i++ {
+		data.Authors = append(data.Authors, gofakeit.Name())
+	}
+	if len(data.Authors) > 0 {
+		// Пара название и автор уникальна в каталоге, повторы генерируются заново
+		seen := map[[2]string]bool{}
+		for attempts := 0; len(data.Books) < cfg.Books && attempts < cfg.Books*10; attempts++ {
+			book := BookFixture{
+				Title:  bookTitle(rnd),
+				Author: data.Authors[rnd.Intn(len(data.Authors))],
+				Copies: 1 + rnd.Intn(3),
+			}
+			if key := [2]string{book.Title, book.Author}; !seen[key] {
+				seen[key] = true
+				data.Books = append(data.Books, book)
+			}
+		}
+	}
+
+	data.Users = []UserFixture{
+		{Username: "admin", Name: "Library Admin", Email: "admin@library.test", Role: entities.RoleAdmin},
+		{Username: "librarian", Name: "Library Staff", Email: "librarian@library.test", Role: entities.RoleLibrarian},
+	}
+	for i := 0; i < cfg.Users; i++ {
+		first, last := gofakeit.FirstName(), gofakeit.LastName()
+		username := fmt.Sprintf("%s.%s%d", slug(first), slug(last), i+1)
+		data.Users = append(data.Users, UserFixture{
+			Username: username,
+			Name:     truncate(first+" "+last, 50),
+			Email:    username + "@library.test",
+		})
+	}
+
+	readers := make([]int, 0, cfg.Users)
+	for i := 2; i < len(data.Users); i++ {
+		readers = append(readers, i)
+	}
+	if len(data.Books) == 0 || len(readers) == 0 {
+		return data
+	}
+
+	available := make([]int, len(data.Books))
+	for i, b := range data.Books {
+		available[i] = b.Copies
+	}
+	borrowed := map[[2]int]bool{}
+	lend := func(book, user int) {
+		due := 14 - rnd.Intn(10)
+		if rnd.Intn(5) == 0 {
+			due = -1 - rnd.Intn(10) // Каждая пятая выдача просрочена
+		}
+		data.Loans = append(data.Loans, LoanFixture{Book: book, User: user, DueInDays: due})
+		available[book]--
+		borrowed[[2]int{book, user}] = true
+	}
+
+	// Книги под брони выдаются целиком, по две брони на книгу
+	var taken []int
+	for _, book := range rnd.Perm(len(data.Books)) {
+		if len(taken) >= (cfg.Holds+1)/2 {
+			break
+		}
+		copies := data.Books[book].Copies
+		if len(data.Loans)+copies > cfg.Loans || copies > len(readers) {
+			continue
+		}
+		for _, user := range rnd.Perm(len(readers))[:copies] {
+			lend(book, readers[user])
+		}
+		taken = append(taken, book)
+	}
+
+	for attempts := 0; len(data.Loans) < cfg.Loans && attempts < cfg.Loans*10; attempts++ {
+		book, user := rnd.Intn(len(data.Books)), readers[rnd.Intn(len(readers))]
+		if available[book] > 0 && !borrowed[[2]int{book, user}] {
+			lend(book, user)
+		}
+	}
+
+	held := map[[2]int]bool{}
+	for attempts := 0; len(taken) > 0 && len(data.Holds) < cfg.Holds && attempts < cfg.Holds*10; attempts++ {
+		book, user := taken[rnd.Intn(len(taken))], readers[rnd.Intn(len(readers))]
+		key := [2]int{book, user}
+		if !borrowed[key] && !held[key] {
+			data.Holds = append(data.Holds, HoldFixture{Book: book, User: user})
+			held[key] = true
+		}
+	}
+	return data
+}
+
+// Result сколько записей создано. Skipped означает, что в базе уже были данные
+type Result struct {
+	Skipped bool
+	Books   int
+	Copies  int
+	Users   int
+	Loans   int
+	Holds   int
+}
+
+// seedLockID ключ advisory-блокировки, чтобы два одновременных запуска не заполнили базу дважды
+const seedLockID int64 = 4271937019
+
+// Apply записывает набор, если каталог и пользователи пусты. Выдачи и брони проходят через
+// репозиторий, поэтому статусы экземпляров и очереди согласованы. Все пользователи
+// получают один пароль, passwordHash уже посчитан вызывающим.
+// Набор пишется одной транзакцией: при ошибке база остается пустой и следующий запуск заполнит ее заново
+func Apply(ctx context.Context, db *sql.DB, data Dataset, passwordHash string) (Result, error) {
+	tx, err := postgres.BeginTx(ctx, db)
+	if err != nil {
+		return Result{}, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", seedLockID); err != nil {
+		return Result{}, err
+	}
+	var exists bool
+	if err := tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM book) OR EXISTS(SELECT 1 FROM users)").Scan(&exists); err != nil {
+		return Result{}, err
+	}
+	if exists {
+		return Result{Skipped: true}, nil
+	}
+
+	result, err := apply(ctx, tx, data, passwordHash)
+	if err != nil {
+		return Result{}, err
+	}
+	return result, tx.Commit()
+}
+
+func apply(ctx context.Context, tx *postgres.Tx, data Dataset, passwordHash string) (Result, error) {
+	var result Result
+	auth := tx.AuthRepository()
+	books := tx.BookRepository()
+
+	userIDs := make([]int, len(data.Users))
+	for i, u := range data.Users {
+		id, err := auth.Create(ctx, u.Username, u.Email, passwordHash)
+		if err != nil {
+			return result, fmt.Errorf("user %s: %w", u.Username, err)
+		}
+		if _, err := tx.ExecContext(ctx, "UPDATE users SET name = $1 WHERE id = $2", u.Name, id); err != nil {
+			return result, err
+		}
+		if u.Role != "" {
+			if err := auth.GrantRole(ctx, u.Username, u.Role); err != nil {
+				return result, fmt.Errorf("user %s: %w", u.Username, err)
+			}
+		}
+		userIDs[i] = id
+		result.Users++
+	}
+
+	bookIndexes := make([]int, len(data.Books))
+	for i, b := range data.Books {
+		book, err := books.CreateBook(ctx, b.Title, b.Author, b.Copies)
+		if err != nil {
+			return result, fmt.Errorf("book %q: %w", b.Title, err)
+		}
+		bookIndexes[i] = book.Index
+		result.Copies += book.TotalCopies
+		result.Books++
+	}
+
+	now := time.Now()
+	for _, l := range data.Loans {
+		dueAt := now.AddDate(0, 0, l.DueInDays)
+		// Штрафов у новых читателей нет, а число книг на руках задает сам набор данных
+		if _, err := books.TakeBook(ctx, bookIndexes[l.Book], userIDs[l.User], dueAt, "", 0, 0); err != nil {
+			return result, fmt.Errorf("loan of book %d: %w", bookIndexes[l.Book], err)
+		}
+		result.Loans++
+	}
+
+	for _, h := range data.Holds {
+		if _, err := books.PlaceHold(ctx, bookIndexes[h.Book], userIDs[h.User]); err != nil {
+			return result, fmt.Errorf("hold on book %d: %w", bookIndexes[h.Book], err)
+		}
+		result.Holds++
+	}
+	return result, nil
+}
+
+func bookTitle(rnd *rand.Rand) string {
+	title := strings.TrimSuffix(gofakeit.Sentence(1+rnd.Intn(4)), ".")
+	return truncate(title, 50)
+}
+
+// truncate обрезает строку до n символов, размер колонок book.book и users.name
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return strings.TrimSpace(string([]rune(s)[:n]))
+}
+
+// slug оставляет в имени только латинские буквы в нижнем регистре, чтобы из него получались логин и почта
+func slug(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		}
+		return -1
+	}, s)
+}
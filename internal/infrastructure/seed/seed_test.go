@@ -0,0 +1,75 @@
+package seed
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPlanIsDeterministic(t *testing.T) {
+	cfg := DefaultConfig()
+	if !reflect.DeepEqual(Plan(cfg), Plan(cfg)) {
+		t.Fatal("the same config produced different datasets")
+	}
+
+	cfg.Seed = 2
+	if reflect.DeepEqual(Plan(DefaultConfig()), Plan(cfg)) {
+		t.Fatal("different seeds produced the same dataset")
+	}
+}
+
+func TestPlanIsConsistent(t *testing.T) {
+	cfg := DefaultConfig()
+	data := Plan(cfg)
+
+	if len(data.Books) != cfg.Books || len(data.Users) != cfg.Users+2 || len(data.Loans) != cfg.Loans || len(data.Holds) != cfg.Holds {
+		t.Fatalf("got %d books, %d users, %d loans, %d holds", len(data.Books), len(data.Users), len(data.Loans), len(data.Holds))
+	}
+
+	usernames := map[string]bool{}
+	for _, u := range data.Users {
+		if usernames[u.Username] {
+			t.Fatalf("duplicate username %s", u.Username)
+		}
+		usernames[u.Username] = true
+	}
+
+	titles := map[[2]string]bool{}
+	for _, b := range data.Books {
+		key := [2]string{b.Title, b.Author}
+		if titles[key] {
+			t.Fatalf("duplicate book %q by %s", b.Title, b.Author)
+		}
+		titles[key] = true
+	}
+
+	lent := make([]int, len(data.Books))
+	borrowed := map[[2]int]bool{}
+	for _, l := range data.Loans {
+		key := [2]int{l.Book, l.User}
+		if borrowed[key] {
+			t.Fatalf("user %d borrows book %d twice", l.User, l.Book)
+		}
+		borrowed[key] = true
+		lent[l.Book]++
+		if lent[l.Book] > data.Books[l.Book].Copies {
+			t.Fatalf("book %d lent more times than it has copies", l.Book)
+		}
+	}
+
+	// Бронь возможна только на книгу без свободных экземпляров и не для того, у кого она на руках
+	for _, h := range data.Holds {
+		if lent[h.Book] != data.Books[h.Book].Copies {
+			t.Fatalf("hold on book %d that still has free copies", h.Book)
+		}
+		if borrowed[[2]int{h.Book, h.User}] {
+			t.Fatalf("hold by user %d who already borrows book %d", h.User, h.Book)
+		}
+	}
+}
+
+func TestPlanWithoutBooks(t *testing.T) {
+	data := Plan(Config{Seed: 1, Users: 3, Loans: 5, Holds: 5})
+	if len(data.Books) != 0 || len(data.Loans) != 0 || len(data.Holds) != 0 {
+		t.Fatalf("dataset without books = %+v", data)
+	}
+}
@@ -0,0 +1,206 @@
+package tokens
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/lestrrat-go/jwx/jwa"
+	"github.com/lestrrat-go/jwx/jwk"
+	"github.com/lestrrat-go/jwx/jws"
+	"github.com/lestrrat-go/jwx/jwt"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/config"
+)
+
+var (
+	ErrNoSigningKey = errors.New("no signing key configured")
+	ErrUnknownKey   = errors.New("token signed with unknown key")
+	ErrAlgMismatch  = errors.New("token algorithm does not match key")
+)
+
+// key ключ с идентификатором. signKey пуст у ключей, которые остались только для проверки
+type key struct {
+	id        string
+	alg       jwa.SignatureAlgorithm
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+// KeySet подписывает токены текущим ключом и проверяет их любым из известных по kid
+type KeySet struct {
+	signing *key
+	keys    map[string]*key
+}
+
+// NewKeySet загружает ключ подписи и ключи, оставленные для проверки на время ротации
+func NewKeySet(signing config.JWTKey, verify ...config.JWTKey) (*KeySet, error) {
+	signingKey, err := loadKey(signing, true)
+	if err != nil {
+		return nil, fmt.Errorf("signing key %s: %w", signing.ID, err)
+	}
+
+	ks := &KeySet{signing: signingKey, keys: map[string]*key{signingKey.id: signingKey}}
+	for _, cfg := range verify {
+		k, err := loadKey(cfg, false)
+		if err != nil {
+			return nil, fmt.Errorf("verification key %s: %w", cfg.ID, err)
+		}
+		if _, ok := ks.keys[k.id]; ok {
+			return nil, fmt.Errorf("duplicate key id %s", k.id)
+		}
+		ks.keys[k.id] = k
+	}
+	return ks, nil
+}
+
+// Encode подписывает claims текущим ключом и указывает его kid в заголовке
+func (ks *KeySet) Encode(claims map[string]interface{}) (jwt.Token, string, error) {
+	t := jwt.New()
+	for name, value := range claims {
+		if err := t.Set(name, value); err != nil {
+			return nil, "", err
+		}
+	}
+
+	headers := jws.NewHeaders()
+	if err := headers.Set(jws.KeyIDKey, ks.signing.id); err != nil {
+		return nil, "", err
+	}
+	signed, err := jwt.Sign(t, ks.signing.alg, ks.signing.signKey, jwt.WithHeaders(headers))
+	if err != nil {
+		return nil, "", err
+	}
+	return t, string(signed), nil
+}
+
+// Verify проверяет подпись ключом из заголовка kid и срок действия токена.
+// Алгоритм берется из настроек ключа, заголовку alg не доверяем
+func (ks *KeySet) Verify(tokenString string) (jwt.Token, error) {
+	msg, err := jws.ParseString(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	signatures := msg.Signatures()
+	if len(signatures) != 1 {
+		return nil, errors.New("token must have exactly one signature")
+	}
+
+	headers := signatures[0].ProtectedHeaders()
+	k, ok := ks.keys[headers.KeyID()]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	if headers.Algorithm() != k.alg {
+		return nil, ErrAlgMismatch
+	}
+
+	return jwt.ParseString(tokenString, jwt.WithVerify(k.alg, k.verifyKey), jwt.WithValidate(true))
+}
+
+// PublicKeys возвращает открытые ключи в формате JWKS. Симметричные ключи не публикуются
+func (ks *KeySet) PublicKeys() (jwk.Set, error) {
+	set := jwk.NewSet()
+	for _, k := range ks.keys {
+		if k.alg == jwa.HS256 {
+			continue
+		}
+		pub, err := jwk.New(k.verifyKey)
+		if err != nil {
+			return nil, err
+		}
+		for name, value := range map[string]interface{}{
+			jwk.KeyIDKey:     k.id,
+			jwk.AlgorithmKey: k.alg.String(),
+			jwk.KeyUsageKey:  "sig",
+		} {
+			if err := pub.Set(name, value); err != nil {
+				return nil, err
+			}
+		}
+		set.Add(pub)
+	}
+	return set, nil
+}
+
+func loadKey(cfg config.JWTKey, signing bool) (*key, error) {
+	if cfg.ID == "" {
+		return nil, errors.New("key id is required")
+	}
+	k := &key{id: cfg.ID, alg: jwa.SignatureAlgorithm(cfg.Algorithm)}
+
+	switch k.alg {
+	case jwa.HS256:
+		secret := []byte(cfg.Secret)
+		if cfg.File != "" {
+			data, err := os.ReadFile(cfg.File)
+			if err != nil {
+				return nil, err
+			}
+			secret = []byte(strings.TrimSpace(string(data)))
+		}
+		if len(secret) == 0 {
+			return nil, ErrNoSigningKey
+		}
+		k.signKey, k.verifyKey = secret, secret
+		return k, nil
+	case jwa.RS256, jwa.EdDSA:
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", cfg.Algorithm)
+	}
+
+	if cfg.File == "" {
+		return nil, ErrNoSigningKey
+	}
+	data, err := os.ReadFile(cfg.File)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+
+	// Для проверки достаточно открытого ключа, для подписи нужен закрытый
+	var parsed interface{}
+	if strings.Contains(block.Type, "PRIVATE KEY") {
+		parsed, err = parsePrivateKey(block)
+	} else if !signing {
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	} else {
+		return nil, errors.New("signing requires a private key")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := parsed.(type) {
+	case *rsa.PrivateKey:
+		k.signKey, k.verifyKey = v, &v.PublicKey
+	case *rsa.PublicKey:
+		k.verifyKey = v
+	case ed25519.PrivateKey:
+		k.signKey, k.verifyKey = v, v.Public()
+	case ed25519.PublicKey:
+		k.verifyKey = v
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+
+	_, isRSA := k.verifyKey.(*rsa.PublicKey)
+	if isRSA != (k.alg == jwa.RS256) {
+		return nil, ErrAlgMismatch
+	}
+	return k, nil
+}
+
+func parsePrivateKey(block *pem.Block) (interface{}, error) {
+	if block.Type == "RSA PRIVATE KEY" {
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	}
+	return x509.ParsePKCS8PrivateKey(block.Bytes)
+}
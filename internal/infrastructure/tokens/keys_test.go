@@ -0,0 +1,146 @@
+package tokens
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/config"
+)
+
+func writePEM(t *testing.T, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func claims() map[string]interface{} {
+	return map[string]interface{}{"user_id": "alice", "exp": time.Now().Add(time.Hour).Unix()}
+}
+
+func TestKeySetAlgorithms(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edDER, err := x509.MarshalPKCS8PrivateKey(edKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, cfg := range []config.JWTKey{
+		{ID: "hs", Algorithm: "HS256", Secret: "secret"},
+		{ID: "rs", Algorithm: "RS256", File: writePEM(t, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))},
+		{ID: "ed", Algorithm: "EdDSA", File: writePEM(t, "PRIVATE KEY", edDER)},
+	} {
+		ks, err := NewKeySet(cfg)
+		if err != nil {
+			t.Fatalf("%s: %v", cfg.Algorithm, err)
+		}
+		_, signed, err := ks.Encode(claims())
+		if err != nil {
+			t.Fatalf("%s: encode: %v", cfg.Algorithm, err)
+		}
+		token, err := ks.Verify(signed)
+		if err != nil {
+			t.Fatalf("%s: verify: %v", cfg.Algorithm, err)
+		}
+		if userID, _ := token.Get("user_id"); userID != "alice" {
+			t.Errorf("%s: user_id = %v", cfg.Algorithm, userID)
+		}
+
+		set, err := ks.PublicKeys()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := map[string]int{"hs": 0, "rs": 1, "ed": 1}[cfg.ID]; set.Len() != want {
+			t.Errorf("%s: jwks has %d keys, want %d", cfg.Algorithm, set.Len(), want)
+		}
+	}
+}
+
+func TestKeySetRotation(t *testing.T) {
+	oldKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldPub, err := x509.MarshalPKIXPublicKey(&oldKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldSet, err := NewKeySet(config.JWTKey{ID: "2024", Algorithm: "RS256", File: writePEM(t, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(oldKey))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, oldToken, err := oldSet.Encode(claims())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// После ротации новый ключ подписывает, старый открытый ключ только проверяет
+	rotated, err := NewKeySet(
+		config.JWTKey{ID: "2025", Algorithm: "RS256", File: writePEM(t, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(newKey))},
+		config.JWTKey{ID: "2024", Algorithm: "RS256", File: writePEM(t, "PUBLIC KEY", oldPub)},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rotated.Verify(oldToken); err != nil {
+		t.Fatalf("old token rejected after rotation: %v", err)
+	}
+	if set, _ := rotated.PublicKeys(); set.Len() != 2 {
+		t.Errorf("jwks has %d keys, want 2", set.Len())
+	}
+
+	// Без старого ключа токен отклоняется
+	fresh, err := NewKeySet(config.JWTKey{ID: "2025", Algorithm: "RS256", File: writePEM(t, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(newKey))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fresh.Verify(oldToken); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("verify with retired key: %v, want ErrUnknownKey", err)
+	}
+}
+
+func TestKeySetRejectsAlgorithmSwitch(t *testing.T) {
+	// Токен HS256 с kid ключа RS256 не должен проверяться
+	hs, err := NewKeySet(config.JWTKey{ID: "shared", Algorithm: "HS256", Secret: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, signed, err := hs.Encode(claims())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs, err := NewKeySet(config.JWTKey{ID: "shared", Algorithm: "RS256", File: writePEM(t, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rs.Verify(signed); !errors.Is(err, ErrAlgMismatch) {
+		t.Fatalf("verify: %v, want ErrAlgMismatch", err)
+	}
+}
@@ -0,0 +1,60 @@
+package repositories
+
+import "errors"
+
+// Ошибки, которыми любая реализация хранилищ сообщает о нарушении правил.
+// Вызывающий код сравнивает их через errors.Is и не зависит от конкретной базы
+
+// Каталог, экземпляры и выдачи
+var (
+	ErrBookNotFound     = errors.New("book not found")
+	ErrBookExists       = errors.New("book already exists")
+	ErrBookTaken        = errors.New("no available copies")
+	ErrAlreadyBorrowed  = errors.New("user already holds a copy of this book")
+	ErrLoanNotFound     = errors.New("loan not found")
+	ErrRenewLimit       = errors.New("renewal limit reached")
+	ErrBookOnHold       = errors.New("book is on hold for other readers")
+	ErrBarcodeExists    = errors.New("barcode already exists")
+	ErrCopyNotAvailable = errors.New("copy not available")
+	ErrLoanLimit        = errors.New("active loans limit reached")
+	ErrFineLimit        = errors.New("outstanding fines exceed the limit")
+)
+
+// Брони
+var (
+	ErrBookAvailable = errors.New("book is available")
+	ErrHoldExists    = errors.New("hold already placed")
+	ErrHoldNotFound  = errors.New("hold not found")
+)
+
+// Авторы
+var (
+	ErrAuthorExists     = errors.New("author already exists")
+	ErrAuthorNotFound   = errors.New("author not found")
+	ErrAuthorHasBooks   = errors.New("author has books")
+	ErrAuthorBooksInUse = errors.New("author's books are on hold or have loan history")
+)
+
+// Штрафы
+var (
+	ErrFineNotFound  = errors.New("fine not found")
+	ErrFineClosed    = errors.New("fine already paid or waived")
+	ErrInvalidAmount = errors.New("invalid payment amount")
+)
+
+// Пользователи и учетные записи
+var (
+	ErrUserExists     = errors.New("user already exists")
+	ErrUserNotFound   = errors.New("user not found")
+	ErrEmailExists    = errors.New("email already in use")
+	ErrEmailNotSet    = errors.New("user has no email")
+	ErrRoleNotGranted = errors.New("role not granted")
+)
+
+// Токены и API-ключи
+var (
+	ErrTokenInvalid   = errors.New("refresh token is invalid or expired")
+	ErrTokenReused    = errors.New("refresh token reused, session revoked")
+	ErrAPIKeyInvalid  = errors.New("invalid or revoked api key")
+	ErrAPIKeyNotFound = errors.New("api key not found")
+)
@@ -0,0 +1,54 @@
+package repositories
+
+import "errors"
+
+// ErrInvalidListOptions неизвестное поле, неверное значение фильтра или испорченный курсор
+var ErrInvalidListOptions = errors.New("invalid list options")
+
+const (
+	DefaultListLimit = 20
+	MaxListLimit     = 100
+)
+
+const (
+	FilterEquals   = "eq"       // author=Tolkien
+	FilterContains = "contains" // name~=ann
+)
+
+// ListOptions параметры постраничного списка. After курсор из предыдущей страницы
+type ListOptions struct {
+	Limit   int
+	After   string
+	Sort    []SortField
+	Filters []Filter
+}
+
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+type Filter struct {
+	Field string
+	Op    string
+	Value string
+}
+
+// Page страница списка. NextCursor пуст на последней странице
+type Page[T any] struct {
+	Items      []T
+	Total      int
+	NextCursor string
+}
+
+// MaxAuthorFacets сколько авторов возвращать в фасете
+const MaxAuthorFacets = 10
+
+// BookSearch параметры поиска. Author и Available сужают выдачу, но не фасеты
+type BookSearch struct {
+	Query     string
+	Author    string
+	Available *bool
+	Limit     int
+	Offset    int
+}
@@ -2,55 +2,89 @@ package repositories
 
 import (
 	"context"
-	"database/sql"
-	"net/http"
-	"sync"
+	"time"
 
 	"studentgit.kata.academy/Zhodaran/go-kata/internal/entities"
 )
 
-type Gnida interface {
-	Register(w http.ResponseWriter, r *http.Request)
-	Login(w http.ResponseWriter, r *http.Request)
-}
+// Хранилища не знают об HTTP и о конкретной базе. Реализации в infrastructure/postgres
+// и infrastructure/memory зависят от этого пакета и возвращают ошибки из errors.go
 
+// BookRepository каталог, экземпляры, выдачи и брони
 type BookRepository interface {
-	AddBooks(books []entities.Book)
-	TakeBookHandler(resp Responder, db *sql.DB, Books *[]entities.Book, library *Library) http.HandlerFunc
-	ReturnBook(resp Responder, db *sql.DB, Books *[]entities.Book, library *Library) http.HandlerFunc
-	UpdateBook(resp Responder, db *sql.DB) http.HandlerFunc
-	AddBookHandler(resp Responder, db *sql.DB, library *Library, Books *[]entities.Book) http.HandlerFunc
+	List(ctx context.Context, opts ListOptions) (Page[entities.Book], error)
+	Search(ctx context.Context, search BookSearch) (entities.BookSearchResult, error)
+	CreateBook(ctx context.Context, title, author string, copies int) (entities.Book, error)
+	UpdateBook(ctx context.Context, index int, title, author string) (entities.Book, error)
+	AddCopy(ctx context.Context, index int, barcode string, pickupWindow time.Duration) (entities.Copy, error)
+	ListCopies(ctx context.Context, index int) ([]entities.Copy, error)
+	TakeBook(ctx context.Context, index, userID int, dueAt time.Time, barcode string, maxActive int, fineLimit int64) (entities.Loan, error)
+	ReturnBook(ctx context.Context, index, userID int, pickupWindow time.Duration, fineRate int64) (entities.Book, error)
+	RenewLoan(ctx context.Context, index, userID int, period time.Duration, maxRenewals int) (entities.Loan, error)
+	ListLoans(ctx context.Context, userID int) ([]entities.Loan, error)
+	PlaceHold(ctx context.Context, index, userID int) (entities.Hold, error)
+	CancelHold(ctx context.Context, index, userID int, pickupWindow time.Duration) error
+	ListHolds(ctx context.Context, userID int) ([]entities.Hold, error)
 }
 
+// UserRepository пользователи библиотеки. Удаленные не находятся
 type UserRepository interface {
-	CreateUser(w http.ResponseWriter, r *http.Request)
-	GetUser(w http.ResponseWriter, r *http.Request)
-	UpdateUser(w http.ResponseWriter, r *http.Request)
-	DeleteUser(w http.ResponseWriter, r *http.Request)
-	ListUsers(w http.ResponseWriter, r *http.Request)
-	Create(ctx context.Context, user entities.User) error
-	GetByID(ctx context.Context, id string) (entities.User, error)
-	Update(ctx context.Context, user entities.User) error
-	Delete(ctx context.Context, id string) error
-	List(ctx context.Context, limit, offset int) ([]entities.User, error)
+	Create(ctx context.Context, user entities.User) (entities.User, error)
+	GetByID(ctx context.Context, id int) (entities.User, error)
+	Update(ctx context.Context, user entities.User) (entities.User, error)
+	Delete(ctx context.Context, id int) error
+	List(ctx context.Context, opts ListOptions) (Page[entities.User], error)
 }
 
+// AuthorRepository справочник авторов. Книги ссылаются на автора по ID
 type AuthorRepository interface {
-	GetAuthorsHandler(resp Responder, library *Library) http.HandlerFunc
-	ListAuthorsHandler(resp Responder, library *Library) http.HandlerFunc
-	AddAuthorHandler(resp Responder, library *Library) http.HandlerFunc
+	Add(ctx context.Context, author entities.Author) (entities.Author, error)
+	List(ctx context.Context) ([]entities.Author, error)
+	GetByID(ctx context.Context, id int) (entities.Author, error)
+	Update(ctx context.Context, author entities.Author) (entities.Author, error)
+	Delete(ctx context.Context, id int, cascade bool) error
+	ListBooks(ctx context.Context, id int) ([]entities.Book, error)
+}
+
+// FineRepository штрафы за просрочку. Начисляются при возврате книги
+type FineRepository interface {
+	List(ctx context.Context, userID int) ([]entities.Fine, error)
+	Balance(ctx context.Context, userID int) (int64, error)
+	Pay(ctx context.Context, id int, amount int64, note string) (entities.Fine, error)
+	Waive(ctx context.Context, id int, note string) (entities.Fine, error)
 }
 
-type Responder interface {
-	OutputJSON(w http.ResponseWriter, responseData interface{})
-	ErrorUnauthorized(w http.ResponseWriter, err error)
-	ErrorBadRequest(w http.ResponseWriter, err error)
-	ErrorForbidden(w http.ResponseWriter, err error)
-	ErrorInternal(w http.ResponseWriter, err error)
+// Назначения одноразовых токенов из писем
+const (
+	PurposePasswordReset = "password_reset"
+	PurposeVerifyEmail   = "verify_email"
+)
+
+// AuthRepository учетные записи, роли, сессии и одноразовые токены писем
+type AuthRepository interface {
+	Create(ctx context.Context, username, email, passwordHash string) (int, error)
+	GetUserID(ctx context.Context, username string) (int, error)
+	GetPasswordHash(ctx context.Context, username string) (string, error)
+	UpdatePasswordHash(ctx context.Context, username, passwordHash string) error
+	GetRoles(ctx context.Context, username string) ([]string, error)
+	GrantRole(ctx context.Context, username, role string) error
+	RevokeRole(ctx context.Context, username, role string) error
+
+	CreateRefreshToken(ctx context.Context, username, familyID, tokenHash string, expiresAt time.Time) error
+	RotateRefreshToken(ctx context.Context, oldHash, newHash string, expiresAt time.Time) (username, familyID string, err error)
+	RevokeFamily(ctx context.Context, familyID string) error
+	DenyAccessToken(ctx context.Context, jti string, expiresAt time.Time) error
+
+	FindByLogin(ctx context.Context, login string) (username, email string, err error)
+	GetEmail(ctx context.Context, username string) (email string, verified bool, err error)
+	CreateAuthToken(ctx context.Context, jti, username, purpose, email string, expiresAt time.Time) error
+	ResetPassword(ctx context.Context, jti, passwordHash string) (string, error)
+	VerifyEmail(ctx context.Context, jti string) (string, error)
 }
 
-type Library struct {
-	Books   map[string][]entities.Book
-	Authors []string
-	mu      sync.RWMutex
+// APIKeyRepository ключи сервисов. Секрет ключа возвращается только при создании
+type APIKeyRepository interface {
+	Create(ctx context.Context, name string, scopes []string, createdBy string) (entities.APIKey, string, error)
+	List(ctx context.Context) ([]entities.APIKey, error)
+	Revoke(ctx context.Context, id int) (entities.APIKey, error)
 }
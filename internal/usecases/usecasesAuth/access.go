@@ -0,0 +1,32 @@
+package usecasesAuth
+
+import (
+	"context"
+
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/entities"
+)
+
+// GrantRole выдает пользователю роль. Повторная выдача ничего не меняет
+func (s *AuthService) GrantRole(ctx context.Context, username, role string) error {
+	return s.UserRepo.GrantRole(ctx, username, role)
+}
+
+// RevokeRole отзывает роль. Роль, которой нет, дает repositories.ErrRoleNotGranted
+func (s *AuthService) RevokeRole(ctx context.Context, username, role string) error {
+	return s.UserRepo.RevokeRole(ctx, username, role)
+}
+
+// CreateAPIKey выпускает ключ сервиса и возвращает его вместе с секретом, который больше не показывается
+func (s *AuthService) CreateAPIKey(ctx context.Context, name string, scopes []string, createdBy string) (entities.APIKey, string, error) {
+	return s.keys.Create(ctx, name, scopes, createdBy)
+}
+
+// APIKeys возвращает все ключи без секретов
+func (s *AuthService) APIKeys(ctx context.Context) ([]entities.APIKey, error) {
+	return s.keys.List(ctx)
+}
+
+// RevokeAPIKey отзывает ключ. Запросы с ним сразу перестают проходить
+func (s *AuthService) RevokeAPIKey(ctx context.Context, id int) (entities.APIKey, error) {
+	return s.keys.Revoke(ctx, id)
+}
@@ -0,0 +1,36 @@
+package usecasesAuth
+
+import (
+	"context"
+	"time"
+)
+
+// FindByLogin ищет пользователя по имени или почте и возвращает его имя и почту
+func (s *AuthService) FindByLogin(ctx context.Context, login string) (username, email string, err error) {
+	return s.UserRepo.FindByLogin(ctx, login)
+}
+
+// Email возвращает почту пользователя и признак ее подтверждения
+func (s *AuthService) Email(ctx context.Context, username string) (email string, verified bool, err error) {
+	return s.UserRepo.GetEmail(ctx, username)
+}
+
+// IssueLinkToken сохраняет одноразовый токен ссылки из письма
+func (s *AuthService) IssueLinkToken(ctx context.Context, jti, username, purpose, email string, expiresAt time.Time) error {
+	return s.UserRepo.CreateAuthToken(ctx, jti, username, purpose, email, expiresAt)
+}
+
+// ResetPassword погашает токен сброса и задает новый пароль. Возвращает имя пользователя.
+// Слишком длинный пароль дает password.ErrPasswordTooLong
+func (s *AuthService) ResetPassword(ctx context.Context, jti, pass string) (string, error) {
+	hash, err := s.hasher.Hash(pass)
+	if err != nil {
+		return "", err
+	}
+	return s.UserRepo.ResetPassword(ctx, jti, hash)
+}
+
+// VerifyEmail погашает токен подтверждения и отмечает почту подтвержденной
+func (s *AuthService) VerifyEmail(ctx context.Context, jti string) (string, error) {
+	return s.UserRepo.VerifyEmail(ctx, jti)
+}
@@ -1,13 +1,76 @@
 package usecasesAuth
 
 import (
-	"studentgit.kata.academy/Zhodaran/go-kata/internal/infrastructure/postgres"
+	"context"
+	"errors"
+	"net/mail"
+
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/infrastructure/password"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+)
+
+var (
+	ErrCredentialsRequired = errors.New("username and password are required")
+	ErrInvalidEmail        = errors.New("invalid email")
+	ErrInvalidCredentials  = errors.New("invalid credentials")
 )
 
 type AuthService struct {
-	UserRepo *postgres.PostgresAuthRepository
+	UserRepo repositories.AuthRepository
+	keys     repositories.APIKeyRepository
+	hasher   *password.Hasher
+}
+
+func NewAuthService(repo repositories.AuthRepository, keys repositories.APIKeyRepository, hasher *password.Hasher) *AuthService {
+	return &AuthService{UserRepo: repo, keys: keys, hasher: hasher}
+}
+
+// Register проверяет данные и создает учетную запись. Возвращает ID пользователя
+func (s *AuthService) Register(ctx context.Context, username, email, pass string) (int, error) {
+	if username == "" || pass == "" {
+		return 0, ErrCredentialsRequired
+	}
+	if email != "" && !ValidEmail(email) {
+		return 0, ErrInvalidEmail
+	}
+
+	hash, err := s.hasher.Hash(pass)
+	if err != nil {
+		return 0, err
+	}
+	return s.UserRepo.Create(ctx, username, email, hash)
+}
+
+// Authenticate сверяет пароль. Неизвестный пользователь и неверный пароль неразличимы
+// ни по ошибке, ни по времени ответа. Хеш с устаревшей стоимостью пересчитывается
+func (s *AuthService) Authenticate(ctx context.Context, username, pass string) error {
+	storedHash, err := s.UserRepo.GetPasswordHash(ctx, username)
+	if errors.Is(err, repositories.ErrUserNotFound) {
+		s.hasher.CompareDummy(pass)
+		return ErrInvalidCredentials
+	}
+	if err != nil {
+		return err
+	}
+
+	ok, needsRehash, err := s.hasher.Compare(storedHash, pass)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidCredentials
+	}
+
+	if needsRehash {
+		if newHash, err := s.hasher.Hash(pass); err == nil {
+			_ = s.UserRepo.UpdatePasswordHash(ctx, username, newHash)
+		}
+	}
+	return nil
 }
 
-func NewAuthService(repo *postgres.PostgresAuthRepository) *AuthService {
-	return &AuthService{UserRepo: repo}
+// ValidEmail принимает только голый адрес без имени и угловых скобок
+func ValidEmail(email string) bool {
+	addr, err := mail.ParseAddress(email)
+	return err == nil && addr.Address == email
 }
@@ -0,0 +1,48 @@
+package usecasesAuth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// UserID возвращает ID пользователя, который попадает в access-токен
+func (s *AuthService) UserID(ctx context.Context, username string) (int, error) {
+	return s.UserRepo.GetUserID(ctx, username)
+}
+
+// Roles возвращает роли пользователя. Роль patron есть у всех и всегда идет первой.
+// Неизвестный пользователь дает repositories.ErrUserNotFound
+func (s *AuthService) Roles(ctx context.Context, username string) ([]string, error) {
+	return s.UserRepo.GetRoles(ctx, username)
+}
+
+// StartSession сохраняет первый refresh-токен нового семейства familyID
+func (s *AuthService) StartSession(ctx context.Context, username, familyID, refreshToken string, expiresAt time.Time) error {
+	return s.UserRepo.CreateRefreshToken(ctx, username, familyID, hashToken(refreshToken), expiresAt)
+}
+
+// Refresh погашает refresh-токен и сохраняет вместо него next. Повторное предъявление
+// погашенного токена отзывает всю сессию и дает repositories.ErrTokenReused
+func (s *AuthService) Refresh(ctx context.Context, refreshToken, next string, expiresAt time.Time) (username, familyID string, err error) {
+	return s.UserRepo.RotateRefreshToken(ctx, hashToken(refreshToken), hashToken(next), expiresAt)
+}
+
+// Logout запрещает access-токен jti до истечения его срока и отзывает refresh-токены сессии.
+// Пустой familyID означает, что токен выпущен вне сессии
+func (s *AuthService) Logout(ctx context.Context, jti string, expiresAt time.Time, familyID string) error {
+	if err := s.UserRepo.DenyAccessToken(ctx, jti, expiresAt); err != nil {
+		return err
+	}
+	if familyID == "" {
+		return nil
+	}
+	return s.UserRepo.RevokeFamily(ctx, familyID)
+}
+
+// hashToken в базе хранятся только хеши refresh-токенов
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -1,13 +1,94 @@
 package usecasesAuthor
 
 import (
-	"studentgit.kata.academy/Zhodaran/go-kata/internal/infrastructure/postgres"
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/entities"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+)
+
+// MaxNameLength совпадает с размером колонки authors.name
+const MaxNameLength = 255
+
+// MaxBioLength ограничивает длину биографии
+const MaxBioLength = 5000
+
+var (
+	ErrInvalidName  = errors.New("author name must be between 1 and 255 characters")
+	ErrInvalidBio   = errors.New("bio must be at most 5000 characters")
+	ErrInvalidYears = errors.New("years must not be in the future and death_year must not precede birth_year")
 )
 
 type AuthorService struct {
-	UserRepo *postgres.PostgresAuthorRepository
+	UserRepo repositories.AuthorRepository
+	now      func() time.Time
+}
+
+func NewAuthorService(repo repositories.AuthorRepository) *AuthorService {
+	return &AuthorService{UserRepo: repo, now: time.Now}
+}
+
+// Add проверяет данные и регистрирует автора. Повторное имя дает repositories.ErrAuthorExists
+func (s *AuthorService) Add(ctx context.Context, author entities.Author) (entities.Author, error) {
+	author, err := s.normalize(author)
+	if err != nil {
+		return entities.Author{}, err
+	}
+	return s.UserRepo.Add(ctx, author)
+}
+
+// List возвращает авторов по алфавиту
+func (s *AuthorService) List(ctx context.Context) ([]entities.Author, error) {
+	return s.UserRepo.List(ctx)
 }
 
-func NewAuthorService(repo *postgres.PostgresAuthorRepository) *AuthorService {
-	return &AuthorService{UserRepo: repo}
+// Get возвращает автора по ID
+func (s *AuthorService) Get(ctx context.Context, id int) (entities.Author, error) {
+	return s.UserRepo.GetByID(ctx, id)
+}
+
+// Update проверяет данные и заменяет их у автора
+func (s *AuthorService) Update(ctx context.Context, author entities.Author) (entities.Author, error) {
+	author, err := s.normalize(author)
+	if err != nil {
+		return entities.Author{}, err
+	}
+	return s.UserRepo.Update(ctx, author)
+}
+
+// Delete удаляет автора. Книги автора удаляются только при cascade,
+// без него автор с книгами дает repositories.ErrAuthorHasBooks
+func (s *AuthorService) Delete(ctx context.Context, id int, cascade bool) error {
+	return s.UserRepo.Delete(ctx, id, cascade)
+}
+
+// Books возвращает книги автора
+func (s *AuthorService) Books(ctx context.Context, id int) ([]entities.Book, error) {
+	return s.UserRepo.ListBooks(ctx, id)
+}
+
+// normalize обрезает пробелы и проверяет имя, биографию и годы жизни
+func (s *AuthorService) normalize(author entities.Author) (entities.Author, error) {
+	author.Name = strings.TrimSpace(author.Name)
+	author.Bio = strings.TrimSpace(author.Bio)
+
+	if n := utf8.RuneCountInString(author.Name); n == 0 || n > MaxNameLength {
+		return entities.Author{}, ErrInvalidName
+	}
+	if utf8.RuneCountInString(author.Bio) > MaxBioLength {
+		return entities.Author{}, ErrInvalidBio
+	}
+
+	year := s.now().Year()
+	if (author.BirthYear != nil && *author.BirthYear > year) || (author.DeathYear != nil && *author.DeathYear > year) {
+		return entities.Author{}, ErrInvalidYears
+	}
+	if author.BirthYear != nil && author.DeathYear != nil && *author.DeathYear < *author.BirthYear {
+		return entities.Author{}, ErrInvalidYears
+	}
+	return author, nil
 }
@@ -1,11 +1,140 @@
 package usecasesBook
 
-import "studentgit.kata.academy/Zhodaran/go-kata/internal/infrastructure/postgres"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/config"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/entities"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+)
+
+// MaxTitleLength и MaxAuthorLength совпадают с размерами колонок book.book и book.author
+const (
+	MaxTitleLength  = 50
+	MaxAuthorLength = 255
+)
+
+var (
+	ErrInvalidTitle  = errors.New("book must be between 1 and 50 characters")
+	ErrInvalidAuthor = errors.New("author must be between 1 and 255 characters")
+	ErrInvalidCopies = errors.New("copies must be positive")
+)
 
 type BookService struct {
-	UserRepo *postgres.PostgresBookRepository
+	UserRepo repositories.BookRepository
+	cfg      config.LoanConfig
+	now      func() time.Time
+}
+
+func NewBookService(repo repositories.BookRepository, cfg config.LoanConfig) *BookService {
+	return &BookService{UserRepo: repo, cfg: cfg, now: time.Now}
+}
+
+// List возвращает страницу каталога
+func (s *BookService) List(ctx context.Context, opts repositories.ListOptions) (repositories.Page[entities.Book], error) {
+	return s.UserRepo.List(ctx, opts)
+}
+
+// Search ищет книги по названию и автору
+func (s *BookService) Search(ctx context.Context, search repositories.BookSearch) (entities.BookSearchResult, error) {
+	return s.UserRepo.Search(ctx, search)
+}
+
+// Create добавляет книгу. Без указания числа экземпляров заводится один
+func (s *BookService) Create(ctx context.Context, title, author string, copies int) (entities.Book, error) {
+	title, author, err := normalize(title, author)
+	if err != nil {
+		return entities.Book{}, err
+	}
+	if copies == 0 {
+		copies = 1
+	}
+	if copies < 0 {
+		return entities.Book{}, ErrInvalidCopies
+	}
+	return s.UserRepo.CreateBook(ctx, title, author, copies)
+}
+
+// Update меняет название и автора книги
+func (s *BookService) Update(ctx context.Context, index int, title, author string) (entities.Book, error) {
+	title, author, err := normalize(title, author)
+	if err != nil {
+		return entities.Book{}, err
+	}
+	return s.UserRepo.UpdateBook(ctx, index, title, author)
+}
+
+// AddCopy заводит экземпляр книги. Если книгу ждут по брони, экземпляр сразу откладывается
+func (s *BookService) AddCopy(ctx context.Context, index int, barcode string) (entities.Copy, error) {
+	return s.UserRepo.AddCopy(ctx, index, strings.TrimSpace(barcode), s.cfg.PickupWindow)
+}
+
+// Copies возвращает экземпляры книги
+func (s *BookService) Copies(ctx context.Context, index int) ([]entities.Copy, error) {
+	return s.UserRepo.ListCopies(ctx, index)
+}
+
+// Take выдает книгу на срок из настроек. Должникам сверх лимита и читателям,
+// у которых на руках уже максимум книг, книги не выдаются
+func (s *BookService) Take(ctx context.Context, index, userID int, barcode string) (entities.Loan, error) {
+	loan, err := s.UserRepo.TakeBook(ctx, index, userID, s.now().Add(s.cfg.Period), strings.TrimSpace(barcode), s.cfg.MaxActiveLoans, s.cfg.FineLimit)
+	if errors.Is(err, repositories.ErrLoanLimit) {
+		return entities.Loan{}, fmt.Errorf("%w: at most %d books at a time", err, s.cfg.MaxActiveLoans)
+	}
+	return loan, err
+}
+
+// Return закрывает выдачу, начисляет штраф за просрочку и передает книгу следующему в очереди
+func (s *BookService) Return(ctx context.Context, index, userID int) (entities.Book, error) {
+	return s.UserRepo.ReturnBook(ctx, index, userID, s.cfg.PickupWindow, s.cfg.FineDailyRate)
+}
+
+// Renew продлевает выдачу, пока не исчерпан лимит продлений
+func (s *BookService) Renew(ctx context.Context, index, userID int) (entities.Loan, error) {
+	loan, err := s.UserRepo.RenewLoan(ctx, index, userID, s.cfg.Period, s.cfg.MaxRenewals)
+	if errors.Is(err, repositories.ErrRenewLimit) {
+		return entities.Loan{}, fmt.Errorf("%w: at most %d renewals", err, s.cfg.MaxRenewals)
+	}
+	return loan, err
+}
+
+// Loans возвращает выдачи пользователя, начиная с последней
+func (s *BookService) Loans(ctx context.Context, userID int) ([]entities.Loan, error) {
+	return s.UserRepo.ListLoans(ctx, userID)
+}
+
+// PlaceHold ставит пользователя в очередь на книгу без свободных экземпляров
+func (s *BookService) PlaceHold(ctx context.Context, index, userID int) (entities.Hold, error) {
+	return s.UserRepo.PlaceHold(ctx, index, userID)
+}
+
+// CancelHold снимает бронь. Отложенный экземпляр переходит следующему в очереди
+func (s *BookService) CancelHold(ctx context.Context, index, userID int) error {
+	return s.UserRepo.CancelHold(ctx, index, userID, s.cfg.PickupWindow)
+}
+
+// Holds возвращает брони пользователя
+func (s *BookService) Holds(ctx context.Context, userID int) ([]entities.Hold, error) {
+	holds, err := s.UserRepo.ListHolds(ctx, userID)
+	if holds == nil && err == nil {
+		holds = []entities.Hold{}
+	}
+	return holds, err
 }
 
-func NewBookService(repo *postgres.PostgresBookRepository) *BookService {
-	return &BookService{UserRepo: repo}
+// normalize обрезает пробелы и проверяет длину названия и имени автора
+func normalize(title, author string) (string, string, error) {
+	title, author = strings.TrimSpace(title), strings.TrimSpace(author)
+	if n := utf8.RuneCountInString(title); n == 0 || n > MaxTitleLength {
+		return "", "", ErrInvalidTitle
+	}
+	if n := utf8.RuneCountInString(author); n == 0 || n > MaxAuthorLength {
+		return "", "", ErrInvalidAuthor
+	}
+	return title, author, nil
 }
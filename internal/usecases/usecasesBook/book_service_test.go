@@ -0,0 +1,116 @@
+package usecasesBook
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/config"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/entities"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+)
+
+// stubRepository реализует только методы, которые вызывают тесты. Остальные паникуют
+type stubRepository struct {
+	repositories.BookRepository
+	created []entities.Book
+	dueAt   time.Time
+	limits  [2]int64 // maxActive и fineLimit последней выдачи
+	take    error
+	renew   error
+}
+
+func (r *stubRepository) CreateBook(ctx context.Context, title, author string, copies int) (entities.Book, error) {
+	book := entities.Book{Index: len(r.created) + 1, Book: title, Author: author, TotalCopies: copies}
+	r.created = append(r.created, book)
+	return book, nil
+}
+
+func (r *stubRepository) TakeBook(ctx context.Context, index, userID int, dueAt time.Time, barcode string, maxActive int, fineLimit int64) (entities.Loan, error) {
+	r.dueAt = dueAt
+	r.limits = [2]int64{int64(maxActive), fineLimit}
+	return entities.Loan{BookIndex: index, UserID: userID}, r.take
+}
+
+func (r *stubRepository) RenewLoan(ctx context.Context, index, userID int, period time.Duration, maxRenewals int) (entities.Loan, error) {
+	return entities.Loan{}, r.renew
+}
+
+func TestCreateValidatesBook(t *testing.T) {
+	repo := &stubRepository{}
+	s := NewBookService(repo, config.LoanConfig{})
+	ctx := context.Background()
+
+	tests := []struct {
+		title, author string
+		copies        int
+		err           error
+	}{
+		{"", "Author", 1, ErrInvalidTitle},
+		{"   ", "Author", 1, ErrInvalidTitle},
+		{"This title is much longer than fifty characters in total", "Author", 1, ErrInvalidTitle},
+		{"Title", "", 1, ErrInvalidAuthor},
+		{"Title", "Author", -1, ErrInvalidCopies},
+	}
+	for _, tt := range tests {
+		if _, err := s.Create(ctx, tt.title, tt.author, tt.copies); !errors.Is(err, tt.err) {
+			t.Errorf("Create(%q, %q, %d) error = %v, want %v", tt.title, tt.author, tt.copies, err, tt.err)
+		}
+	}
+	if len(repo.created) != 0 {
+		t.Fatalf("invalid books reached the repository: %+v", repo.created)
+	}
+
+	book, err := s.Create(ctx, "  Title ", " Author", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if book.Book != "Title" || book.Author != "Author" || book.TotalCopies != 1 {
+		t.Fatalf("created book = %+v, want trimmed names and one copy", book)
+	}
+}
+
+func TestTakePassesLimits(t *testing.T) {
+	cfg := config.LoanConfig{Period: 14 * 24 * time.Hour, FineLimit: 500, MaxActiveLoans: 2}
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	repo := &stubRepository{}
+	s := NewBookService(repo, cfg)
+	s.now = func() time.Time { return now }
+	if _, err := s.Take(context.Background(), 1, 2, ""); err != nil {
+		t.Fatal(err)
+	}
+	if want := now.Add(cfg.Period); !repo.dueAt.Equal(want) {
+		t.Fatalf("due at %v, want %v", repo.dueAt, want)
+	}
+	if repo.limits != [2]int64{2, 500} {
+		t.Fatalf("limits %v, want loan limit 2 and fine limit 500", repo.limits)
+	}
+}
+
+func TestTakeLoanLimitNamesMaximum(t *testing.T) {
+	repo := &stubRepository{take: repositories.ErrLoanLimit}
+	s := NewBookService(repo, config.LoanConfig{MaxActiveLoans: 2})
+
+	_, err := s.Take(context.Background(), 1, 2, "")
+	if !errors.Is(err, repositories.ErrLoanLimit) {
+		t.Fatalf("Take error = %v, want ErrLoanLimit", err)
+	}
+	if want := "active loans limit reached: at most 2 books at a time"; err.Error() != want {
+		t.Fatalf("Take error = %q, want %q", err, want)
+	}
+}
+
+func TestRenewLimitNamesMaximum(t *testing.T) {
+	repo := &stubRepository{renew: repositories.ErrRenewLimit}
+	s := NewBookService(repo, config.LoanConfig{MaxRenewals: 2})
+
+	_, err := s.Renew(context.Background(), 1, 2)
+	if !errors.Is(err, repositories.ErrRenewLimit) {
+		t.Fatalf("Renew error = %v, want ErrRenewLimit", err)
+	}
+	if want := "renewal limit reached: at most 2 renewals"; err.Error() != want {
+		t.Fatalf("Renew error = %q, want %q", err, want)
+	}
+}
@@ -0,0 +1,43 @@
+package usecasesFine
+
+import (
+	"context"
+	"strings"
+
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/entities"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+)
+
+type FineService struct {
+	UserRepo repositories.FineRepository
+}
+
+func NewFineService(repo repositories.FineRepository) *FineService {
+	return &FineService{UserRepo: repo}
+}
+
+// List возвращает штрафы пользователя и неоплаченный остаток по ним
+func (s *FineService) List(ctx context.Context, userID int) ([]entities.Fine, int64, error) {
+	fines, err := s.UserRepo.List(ctx, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	balance, err := s.UserRepo.Balance(ctx, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if fines == nil {
+		fines = []entities.Fine{}
+	}
+	return fines, balance, nil
+}
+
+// Pay записывает оплату. Сумма должна быть положительной и не больше остатка, иначе repositories.ErrInvalidAmount
+func (s *FineService) Pay(ctx context.Context, id int, amount int64, note string) (entities.Fine, error) {
+	return s.UserRepo.Pay(ctx, id, amount, strings.TrimSpace(note))
+}
+
+// Waive списывает остаток штрафа
+func (s *FineService) Waive(ctx context.Context, id int, note string) (entities.Fine, error) {
+	return s.UserRepo.Waive(ctx, id, strings.TrimSpace(note))
+}
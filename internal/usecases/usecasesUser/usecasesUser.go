@@ -1,11 +1,76 @@
 package usecasesUser
 
-import "studentgit.kata.academy/Zhodaran/go-kata/internal/infrastructure/postgres"
+import (
+	"context"
+	"errors"
+	"net/mail"
+	"strings"
+	"unicode/utf8"
+
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/entities"
+	"studentgit.kata.academy/Zhodaran/go-kata/internal/repositories"
+)
+
+// MaxNameLength совпадает с размером колонки users.name
+const MaxNameLength = 50
+
+var (
+	ErrInvalidName  = errors.New("name must be between 1 and 50 characters")
+	ErrInvalidEmail = errors.New("invalid email")
+)
 
 type UserService struct {
-	UserRepo *postgres.PostgresUserRepository
+	UserRepo repositories.UserRepository
 }
 
-func NewUserService(repo *postgres.PostgresUserRepository) *UserService {
+func NewUserService(repo repositories.UserRepository) *UserService {
 	return &UserService{UserRepo: repo}
 }
+
+// Create проверяет данные и добавляет пользователя
+func (s *UserService) Create(ctx context.Context, user entities.User) (entities.User, error) {
+	user, err := normalize(user)
+	if err != nil {
+		return entities.User{}, err
+	}
+	return s.UserRepo.Create(ctx, user)
+}
+
+// Get возвращает пользователя по ID
+func (s *UserService) Get(ctx context.Context, id int) (entities.User, error) {
+	return s.UserRepo.GetByID(ctx, id)
+}
+
+// Update проверяет данные и заменяет имя и почту пользователя
+func (s *UserService) Update(ctx context.Context, user entities.User) (entities.User, error) {
+	user, err := normalize(user)
+	if err != nil {
+		return entities.User{}, err
+	}
+	return s.UserRepo.Update(ctx, user)
+}
+
+// Delete удаляет пользователя
+func (s *UserService) Delete(ctx context.Context, id int) error {
+	return s.UserRepo.Delete(ctx, id)
+}
+
+// List возвращает страницу пользователей
+func (s *UserService) List(ctx context.Context, opts repositories.ListOptions) (repositories.Page[entities.User], error) {
+	return s.UserRepo.List(ctx, opts)
+}
+
+// normalize обрезает пробелы и проверяет имя и почту
+func normalize(user entities.User) (entities.User, error) {
+	user.Name = strings.TrimSpace(user.Name)
+	user.Email = strings.TrimSpace(user.Email)
+
+	if n := utf8.RuneCountInString(user.Name); n == 0 || n > MaxNameLength {
+		return entities.User{}, ErrInvalidName
+	}
+	addr, err := mail.ParseAddress(user.Email)
+	if err != nil || addr.Address != user.Email {
+		return entities.User{}, ErrInvalidEmail
+	}
+	return user, nil
+}
@@ -0,0 +1,66 @@
+package workers
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type OverdueMarker interface {
+	MarkOverdue(ctx context.Context) (int64, error)
+}
+
+type HoldExpirer interface {
+	ExpireHolds(ctx context.Context, pickupWindow time.Duration) (int64, error)
+}
+
+type TokenPurger interface {
+	PurgeExpiredTokens(ctx context.Context) (int64, error)
+}
+
+type Purger interface {
+	Purge(ctx context.Context) (int64, error)
+}
+
+// RunOverdueSweeper периодически помечает просроченные выдачи, пока не отменен ctx
+func RunOverdueSweeper(ctx context.Context, marker OverdueMarker, interval time.Duration, logger *zap.Logger) {
+	runEvery(ctx, interval, logger, "loans marked overdue", marker.MarkOverdue)
+}
+
+// RunHoldSweeper периодически снимает брони, по которым книгу не забрали вовремя
+func RunHoldSweeper(ctx context.Context, expirer HoldExpirer, interval, pickupWindow time.Duration, logger *zap.Logger) {
+	runEvery(ctx, interval, logger, "holds expired", func(ctx context.Context) (int64, error) {
+		return expirer.ExpireHolds(ctx, pickupWindow)
+	})
+}
+
+// RunTokenPurger периодически удаляет истекшие refresh-токены и записи списка отзыва
+func RunTokenPurger(ctx context.Context, purger TokenPurger, interval time.Duration, logger *zap.Logger) {
+	runEvery(ctx, interval, logger, "expired tokens purged", purger.PurgeExpiredTokens)
+}
+
+// RunPurger периодически удаляет устаревшие записи, например счетчики неудачных входов
+func RunPurger(ctx context.Context, purger Purger, interval time.Duration, logger *zap.Logger, message string) {
+	runEvery(ctx, interval, logger, message, purger.Purge)
+}
+
+func runEvery(ctx context.Context, interval time.Duration, logger *zap.Logger, message string, sweep func(ctx context.Context) (int64, error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		count, err := sweep(ctx)
+		if err != nil {
+			logger.Error("sweep failed", zap.String("sweep", message), zap.Error(err))
+		} else if count > 0 {
+			logger.Info(message, zap.Int64("count", count))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}